@@ -1,6 +1,8 @@
 package main
 
 import (
+	"time"
+
 	"github.com/spf13/pflag"
 )
 
@@ -9,15 +11,18 @@ const (
 	defaultTmpDir = "/tmp"
 
 	//Kops
-	defaultKopsStateStore     = "s3://kops.state.seizadi.infoblox.com"
-	defaultKopsClusterDnsZone = "soheil.belamaric.com"
+	// Clusters without a ClusterProvider need these to be configured
+	defaultKopsStateStore     = ""
+	defaultKopsClusterDnsZone = ""
 	defaultSSHKey             = "kops.pub"
-	defaultKopsContainer = "soheileizadi/kops:v1.0"
-	defaultKopsKubeDir = "kube"
-	defaultKopsPath = ".bin/kops"
+	defaultKopsPath           = ".bin/kops"
 
-	//Docker
-	defaultDockerBinPath = "/usr/local/bin/docker"
+	// Kops command timeouts
+	defaultKopsTimeout              = 10 * time.Minute
+	defaultKopsUpdateTimeout        = 30 * time.Minute
+	defaultKopsRollingUpdateTimeout = 60 * time.Minute
+	defaultKopsValidateTimeout      = 5 * time.Minute
+	defaultKopsDeleteTimeout        = 30 * time.Minute
 
 	//Metrics
 	defaultMetricsHost               = "0.0.0.0"
@@ -40,23 +45,40 @@ const (
 	defaultClusterOperatorDevelopment bool = false
 
 	//Reaper
-	defaultReaper bool = false
+	defaultReaper         bool = false
+	defaultReaperInterval      = time.Hour
+	defaultReaperDryRun   bool = true
+	defaultReaperMinAge        = 24 * time.Hour
+
+	// Concurrency
+	defaultMaxConcurrentReconciles = 1
+	defaultStateStoreConcurrency   = 3
+
+	// Kubeconfig Secret
+	defaultKubeconfigSecretKey = "kubeconfig"
+
+	// Webhooks
+	defaultWebhookEnabled bool = false
+	defaultWebhookPort         = 9443
+	defaultWebhookCertDir      = "/tmp/k8s-webhook-server/serving-certs"
 )
 
 var (
 	// define flag overrides
-	flagTmpDir = pflag.String("tmp.dir", defaultTmpDir, "temp directory")
+	flagTmpDir = pflag.String("tmp.dir", defaultTmpDir, "root of the per cluster workspaces")
 
 	// Kops
-	flagKopsStateStore     = pflag.String("kops.state.store", defaultKopsStateStore, "kops state store")
+	flagKopsStateStore     = pflag.String("kops.state.store", defaultKopsStateStore, "kops state store, s3://, file:// or in development memory://")
 	flagKopsClusterDnsZone = pflag.String("kops.cluster.dns.zone", defaultKopsClusterDnsZone, "kops cluster DNS zone")
 	flagSSHKey             = pflag.String("kops.ssh.key", defaultSSHKey, "kops ssh key")
-	flagKopsContainer = pflag.String("kops.container", defaultKopsContainer, "kops container")
-	flagKopsKubeDir  = pflag.String("kops.kube.dir", defaultKopsKubeDir, "kops kube directory")
-	flagKopsPath = pflag.String("kops.path", defaultKopsPath, "kops path")
+	flagKopsPath           = pflag.String("kops.path", defaultKopsPath, "kops path")
 
-	//Docker
-	flagDockerBinPath = pflag.String("docker.bin.path", defaultDockerBinPath, "docker bin path")
+	// Kops command timeouts
+	flagKopsTimeout              = pflag.Duration("kops.timeout.default", defaultKopsTimeout, "timeout for kops commands without a specific timeout")
+	flagKopsUpdateTimeout        = pflag.Duration("kops.timeout.update", defaultKopsUpdateTimeout, "timeout for kops update cluster")
+	flagKopsRollingUpdateTimeout = pflag.Duration("kops.timeout.rolling.update", defaultKopsRollingUpdateTimeout, "timeout for kops rolling-update cluster")
+	flagKopsValidateTimeout      = pflag.Duration("kops.timeout.validate", defaultKopsValidateTimeout, "timeout for kops validate cluster")
+	flagKopsDeleteTimeout        = pflag.Duration("kops.timeout.delete", defaultKopsDeleteTimeout, "timeout for kops delete cluster")
 
 	// Metrics
 	flagMetricsHost         = pflag.String("metrics.host", defaultMetricsHost, "host of metrics")
@@ -76,5 +98,25 @@ var (
 	flagClusterOperatorDevelopment = pflag.Bool("development", defaultClusterOperatorDevelopment, "cluster operator development")
 
 	//Reaper
-	flagReaper = pflag.Bool("reaper", defaultReaper, "reaper value")
+	flagReaper          = pflag.Bool("reaper", defaultReaper, "remove orphaned kops clusters from the state stores")
+	flagReaperInterval  = pflag.Duration("reaper.interval", defaultReaperInterval, "how often the reaper checks the state stores")
+	flagReaperDryRun    = pflag.Bool("reaper.dry.run", defaultReaperDryRun, "only report orphaned kops clusters, do not delete them")
+	flagReaperMinAge    = pflag.Duration("reaper.min.age", defaultReaperMinAge, "how long a kops cluster has to be orphaned before it is deleted")
+	flagReaperAllowlist = pflag.StringSlice("reaper.allowlist", nil, "kops cluster names or patterns the reaper never deletes")
+
+	// Concurrency
+	flagMaxConcurrentReconciles = pflag.Int("max-concurrent-reconciles", defaultMaxConcurrentReconciles, "number of Clusters reconciled in parallel")
+	flagStateStoreConcurrency   = pflag.Int("kops.state.store.concurrency", defaultStateStoreConcurrency, "maximum concurrent kops mutations per state store")
+
+	// Kubeconfig Secret
+	flagKubeconfigSecretKey    = pflag.String("kubeconfig.secret.key", defaultKubeconfigSecretKey, "key of the kubeconfig in the Secret written for each cluster")
+	flagKubeconfigSecretLabels = pflag.StringSlice("kubeconfig.secret.labels", nil, "labels added to the kubeconfig Secrets, as key=value pairs")
+
+	// Cluster credentials
+	flagCredentialsAllowedNamespaces = pflag.StringSlice("credentials.allowed.namespaces", nil, "namespaces besides their own that Clusters may reference credentials Secrets in, * for all")
+
+	// Webhooks
+	flagWebhookEnabled = pflag.Bool("webhook.enabled", defaultWebhookEnabled, "serve the Cluster admission webhooks")
+	flagWebhookPort    = pflag.Int("webhook.port", defaultWebhookPort, "port of the webhook server")
+	flagWebhookCertDir = pflag.String("webhook.cert.dir", defaultWebhookCertDir, "directory with the tls.crt and tls.key of the webhook server")
 )
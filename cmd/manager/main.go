@@ -17,6 +17,9 @@ import (
 	"github.com/infobloxopen/cluster-operator/pkg/apis"
 	"github.com/infobloxopen/cluster-operator/pkg/controller"
 	"github.com/infobloxopen/cluster-operator/pkg/controller/cluster"
+	"github.com/infobloxopen/cluster-operator/pkg/webhook"
+	webhookcluster "github.com/infobloxopen/cluster-operator/pkg/webhook/cluster"
+	"github.com/infobloxopen/cluster-operator/pkg/webhook/conversion"
 	"github.com/infobloxopen/cluster-operator/version"
 
 	"github.com/operator-framework/operator-sdk/pkg/k8sutil"
@@ -68,7 +71,7 @@ func init() {
 	viper.BindPFlags(pflag.CommandLine)
 	viper.AutomaticEnv()
 	viper.SetEnvPrefix(viper.GetString("env.prefix"))
-	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_", "-", "_"))
 	viper.AddConfigPath(viper.GetString("config.source"))
 	if viper.GetString("config.file") != "" {
 		viper.SetConfigName(viper.GetString("config.file"))
@@ -87,11 +90,13 @@ func init() {
 			log.Error(errors.New("AWS_SECRET_ACCESS_KEY not configured"), "Missing Argument AWS_SECRET_ACCESS_KEY")
 		}
 	}
+	// Clusters with a ClusterProvider take these from it, they are only
+	// needed for the others
 	if len(viper.GetString("kops.state.store")) == 0 {
-		log.Error(errors.New("KOPS_STATE_STORE not configured"), "Missing Argument KOPS_STATE_STORE")
+		log.Info("KOPS_STATE_STORE not configured, Clusters need a spec.providerRef")
 	}
 	if len(viper.GetString("kops.cluster.dns.zone")) == 0 {
-		log.Error(errors.New("KOPS_CLUSTER_DNS_ZONE not configured"), "Missing Argument KOPS_CLUSTER_DNS_ZONE")
+		log.Info("KOPS_CLUSTER_DNS_ZONE not configured, Clusters need a spec.providerRef")
 	}
 }
 
@@ -121,15 +126,31 @@ func main() {
 
 	//Get reaper bool, set to false if not there
 	var rec cluster.ReconcilerConfig
-	rec.Reap, err = strconv.ParseBool(viper.GetString("reaper"))
+	rec.Reaper.Enabled, err = strconv.ParseBool(viper.GetString("reaper"))
 	if err != nil {
-		rec.Reap = false
+		rec.Reaper.Enabled = false
+	}
+	rec.Reaper.Interval = viper.GetDuration("reaper.interval")
+	rec.Reaper.DryRun = viper.GetBool("reaper.dry.run")
+	rec.Reaper.MinAge = viper.GetDuration("reaper.min.age")
+	rec.Reaper.Allowlist = cluster.ParseList(viper.GetStringSlice("reaper.allowlist"))
+	rec.Reaper.StateStore = viper.GetString("kops.state.store")
+	rec.MaxConcurrentReconciles = viper.GetInt("max-concurrent-reconciles")
+	rec.StateStoreConcurrency = viper.GetInt("kops.state.store.concurrency")
+	rec.KubeconfigSecret.Key = viper.GetString("kubeconfig.secret.key")
+	rec.Credentials.AllowedNamespaces = cluster.ParseList(viper.GetStringSlice("credentials.allowed.namespaces"))
+	rec.KubeconfigSecret.Labels, err = cluster.ParseLabels(viper.GetStringSlice("kubeconfig.secret.labels"))
+	if err != nil {
+		log.Error(err, "Invalid kubeconfig.secret.labels")
+		os.Exit(1)
 	}
 
 	// Create a new Cmd to provide shared dependencies and start components
 	rec.Mgr, err = manager.New(cfg, manager.Options{
 		Namespace:          namespace,
 		MetricsBindAddress: fmt.Sprintf("%s:%d", viper.GetString("metrics.host"), viper.GetInt32("metrics.port")),
+		Port:               viper.GetInt("webhook.port"),
+		CertDir:            viper.GetString("webhook.cert.dir"),
 	})
 	if err != nil {
 		log.Error(err, "")
@@ -149,6 +170,26 @@ func main() {
 		log.Error(err, "")
 		os.Exit(1)
 	}
+
+	// Clusters are stored as v1alpha2, the API server cannot serve them as
+	// v1alpha1 without the conversion webhook
+	if err := conversion.Add(rec.Mgr); err != nil {
+		log.Error(err, "")
+		os.Exit(1)
+	}
+
+	// Setup all Webhooks
+	if viper.GetBool("webhook.enabled") {
+		if err := webhook.AddToManager(rec.Mgr, webhookcluster.Config{
+			DNSZone:               viper.GetString("kops.cluster.dns.zone"),
+			StateStore:            viper.GetString("kops.state.store"),
+			CredentialsNamespaces: rec.Credentials.AllowedNamespaces,
+		}); err != nil {
+			log.Error(err, "")
+			os.Exit(1)
+		}
+	}
+
 	// Add the Metrics Service
 	addMetrics(ctx, cfg, namespace)
 
@@ -0,0 +1,148 @@
+package kops
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// driftKinds are the kops objects compared for drift, kops get does not
+// return the other kinds of the manifest
+var driftKinds = map[string]bool{
+	"Cluster":       true,
+	"InstanceGroup": true,
+}
+
+// DiffManifests compares the kops Cluster and InstanceGroups of the manifests
+// desired and actual and returns the paths where they differ, e.g.
+// InstanceGroup/nodes.spec.maxSize. Only the fields set in desired are
+// compared, kops fills in defaults for the others. Metadata kops adds and
+// empty values are ignored.
+func DiffManifests(desired, actual []byte) ([]string, error) {
+	want, err := manifestObjects(desired)
+	if err != nil {
+		return nil, fmt.Errorf("kops: desired manifest: %v", err)
+	}
+	got, err := manifestObjects(actual)
+	if err != nil {
+		return nil, fmt.Errorf("kops: state store manifest: %v", err)
+	}
+
+	var paths []string
+	for _, key := range sortedKeys(want, got) {
+		w, inWant := want[key]
+		g, inGot := got[key]
+		if !inWant || !inGot {
+			// a missing or an extra object is one difference
+			paths = append(paths, key)
+			continue
+		}
+		paths = append(paths, diffValues(key, w, g)...)
+	}
+	return paths, nil
+}
+
+// manifestObjects parses the multi document manifest data into its normalized
+// Cluster and InstanceGroups keyed by kind and name
+func manifestObjects(data []byte) (map[string]interface{}, error) {
+	objects := map[string]interface{}{}
+	for i, doc := range strings.Split("\n"+string(data), "\n---") {
+		obj := map[string]interface{}{}
+		if err := yaml.Unmarshal([]byte(doc), &obj); err != nil {
+			return nil, fmt.Errorf("document %d: %v", i, err)
+		}
+		kind, _ := obj["kind"].(string)
+		if !driftKinds[kind] {
+			continue
+		}
+		metadata, _ := obj["metadata"].(map[string]interface{})
+		name, _ := metadata["name"].(string)
+
+		objects[kind+"/"+name] = normalize(map[string]interface{}{
+			"metadata": map[string]interface{}{"labels": metadata["labels"]},
+			"spec":     obj["spec"],
+		})
+	}
+	return objects, nil
+}
+
+// normalize drops the empty values of v, kops leaves out what the operator
+// renders as empty and the other way around
+func normalize(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		out := map[string]interface{}{}
+		for key, value := range v {
+			if value = normalize(value); value != nil {
+				out[key] = value
+			}
+		}
+		if len(out) == 0 {
+			return nil
+		}
+		return out
+	case []interface{}:
+		if len(v) == 0 {
+			return nil
+		}
+		out := make([]interface{}, len(v))
+		for i, value := range v {
+			out[i] = normalize(value)
+		}
+		return out
+	case string:
+		if v == "" {
+			return nil
+		}
+	}
+	return v
+}
+
+// diffValues returns the paths below path where got does not have the values
+// of want. Keys of got that are not in want are defaults kops filled in and
+// not a difference, lists of different length are one difference.
+func diffValues(path string, want, got interface{}) []string {
+	wantMap, wantIsMap := want.(map[string]interface{})
+	gotMap, gotIsMap := got.(map[string]interface{})
+	if wantIsMap && gotIsMap {
+		var paths []string
+		for _, key := range sortedKeys(wantMap) {
+			paths = append(paths, diffValues(path+"."+key, wantMap[key], gotMap[key])...)
+		}
+		return paths
+	}
+
+	wantList, wantIsList := want.([]interface{})
+	gotList, gotIsList := got.([]interface{})
+	if wantIsList && gotIsList && len(wantList) == len(gotList) {
+		var paths []string
+		for i := range wantList {
+			paths = append(paths, diffValues(fmt.Sprintf("%s[%d]", path, i), wantList[i], gotList[i])...)
+		}
+		return paths
+	}
+
+	if !reflect.DeepEqual(want, got) {
+		return []string{path}
+	}
+	return nil
+}
+
+// sortedKeys returns the keys of maps in order, without duplicates
+func sortedKeys(maps ...map[string]interface{}) []string {
+	seen := map[string]bool{}
+	var keys []string
+	for _, m := range maps {
+		for key := range m {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
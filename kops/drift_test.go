@@ -0,0 +1,192 @@
+package kops
+
+import (
+	"strings"
+	"testing"
+)
+
+const driftDesired = `apiVersion: kops.k8s.io/v1alpha2
+kind: Cluster
+metadata:
+  name: test.example.com
+spec:
+  kubernetesVersion: 1.16.7
+  subnets:
+  - name: us-east-2a
+    zone: us-east-2a
+---
+apiVersion: kops.k8s.io/v1alpha2
+kind: InstanceGroup
+metadata:
+  name: nodes
+  labels:
+    kops.k8s.io/cluster: test.example.com
+spec:
+  role: Node
+  minSize: 2
+  maxSize: 2
+  subnets: [us-east-2a]
+---
+apiVersion: kops.k8s.io/v1alpha2
+kind: SSHCredential
+metadata:
+  name: admin
+spec:
+  publicKey: ssh-rsa AAAA
+`
+
+func TestDiffManifests(t *testing.T) {
+	tests := []struct {
+		name     string
+		actual   string
+		expected []string
+	}{
+		{
+			name: "in sync",
+			actual: `apiVersion: kops.k8s.io/v1alpha2
+kind: Cluster
+metadata:
+  creationTimestamp: "2020-02-01T12:00:00Z"
+  name: test.example.com
+spec:
+  additionalPolicies: {}
+  kubernetesVersion: 1.16.7
+  subnets:
+  - name: us-east-2a
+    zone: us-east-2a
+
+---
+apiVersion: kops.k8s.io/v1alpha2
+kind: InstanceGroup
+metadata:
+  creationTimestamp: "2020-02-01T12:00:00Z"
+  labels:
+    kops.k8s.io/cluster: test.example.com
+  name: nodes
+spec:
+  maxSize: 2
+  minSize: 2
+  role: Node
+  subnets:
+  - us-east-2a
+`,
+		},
+		{
+			name: "defaults filled in by kops",
+			actual: `apiVersion: kops.k8s.io/v1alpha2
+kind: Cluster
+metadata:
+  creationTimestamp: "2020-02-01T12:00:00Z"
+  name: test.example.com
+spec:
+  api:
+    dns: {}
+  etcdClusters:
+  - name: main
+    version: 3.3.10
+  kubernetesVersion: 1.16.7
+  networkCIDR: 172.20.0.0/16
+  subnets:
+  - cidr: 172.20.32.0/19
+    name: us-east-2a
+    type: Public
+    zone: us-east-2a
+---
+apiVersion: kops.k8s.io/v1alpha2
+kind: InstanceGroup
+metadata:
+  creationTimestamp: "2020-02-01T12:00:00Z"
+  labels:
+    kops.k8s.io/cluster: test.example.com
+    kops.k8s.io/instancegroup: nodes
+  name: nodes
+spec:
+  image: kope.io/k8s-1.16-debian-stretch-amd64-hvm-ebs-2020-01-17
+  machineType: t2.medium
+  maxSize: 2
+  minSize: 2
+  nodeLabels:
+    kops.k8s.io/instancegroup: nodes
+  role: Node
+  subnets:
+  - us-east-2a
+`,
+		},
+		{
+			name: "changed outside of the operator",
+			actual: `---
+apiVersion: kops.k8s.io/v1alpha2
+kind: Cluster
+metadata:
+  name: test.example.com
+spec:
+  kubernetesVersion: 1.16.8
+  subnets:
+  - name: us-east-2a
+    zone: us-east-2b
+  - name: us-east-2c
+    zone: us-east-2c
+---
+apiVersion: kops.k8s.io/v1alpha2
+kind: InstanceGroup
+metadata:
+  labels:
+    kops.k8s.io/cluster: test.example.com
+  name: nodes
+spec:
+  maxSize: 5
+  minSize: 2
+  role: Node
+  subnets:
+  - us-east-2a
+---
+apiVersion: kops.k8s.io/v1alpha2
+kind: InstanceGroup
+metadata:
+  name: extra
+spec:
+  role: Node
+`,
+			expected: []string{
+				"Cluster/test.example.com.spec.kubernetesVersion",
+				"Cluster/test.example.com.spec.subnets",
+				"InstanceGroup/extra",
+				"InstanceGroup/nodes.spec.maxSize",
+			},
+		},
+		{
+			name: "deleted from the state store",
+			actual: `apiVersion: kops.k8s.io/v1alpha2
+kind: Cluster
+metadata:
+  name: test.example.com
+spec:
+  kubernetesVersion: 1.16.7
+  subnets:
+  - name: us-east-2a
+    zone: us-east-2b
+`,
+			expected: []string{
+				"Cluster/test.example.com.spec.subnets[0].zone",
+				"InstanceGroup/nodes",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		paths, err := DiffManifests([]byte(driftDesired), []byte(test.actual))
+		if err != nil {
+			t.Errorf("%s: expected no error got %v", test.name, err)
+			continue
+		}
+		if strings.Join(paths, ",") != strings.Join(test.expected, ",") {
+			t.Errorf("%s: expected %v got %v", test.name, test.expected, paths)
+		}
+	}
+}
+
+func TestDiffManifestsInvalid(t *testing.T) {
+	if _, err := DiffManifests([]byte(driftDesired), []byte("kind: [")); err == nil {
+		t.Error("Expected error for an unparseable manifest")
+	}
+}
@@ -0,0 +1,39 @@
+package kops
+
+import (
+	"errors"
+	"regexp"
+
+	"github.com/infobloxopen/cluster-operator/utils"
+)
+
+// Patterns matched against kops stderr to classify a failed command. They are
+// checked in order, the first match wins, so Unauthorized comes before NotFound
+// to avoid treating "bucket not found / access denied" style output as a
+// missing cluster.
+var errorPatterns = []struct {
+	reason utils.ErrorReason
+	re     *regexp.Regexp
+}{
+	{utils.ReasonUnauthorized, regexp.MustCompile(`AccessDenied|InvalidAccessKeyId|SignatureDoesNotMatch|ExpiredToken|InvalidClientTokenId|UnrecognizedClientException|AuthFailure|NoCredentialProviders|Unauthorized|Forbidden`)},
+	{utils.ReasonThrottled, regexp.MustCompile(`Throttling|ThrottlingException|RequestLimitExceeded|SlowDown|TooManyRequests|Rate exceeded`)},
+	{utils.ReasonTransient, regexp.MustCompile(`RequestTimeout|ServiceUnavailable|InternalError|connection reset by peer|connection refused|i/o timeout|TLS handshake timeout|no such host`)},
+	{utils.ReasonNotFound, regexp.MustCompile(`cluster not found "[^"]+"|cluster "[^"]+" not found|cluster not found for name|No clusters found`)},
+}
+
+// classify fills in the Reason of a CommandError from the kops output. Errors
+// that are not a CommandError are returned unchanged.
+func classify(err error) error {
+	var cmdErr *utils.CommandError
+	if !errors.As(err, &cmdErr) || cmdErr.Reason != utils.ReasonUnknown {
+		return err
+	}
+
+	for _, p := range errorPatterns {
+		if p.re.MatchString(cmdErr.Stderr) {
+			cmdErr.Reason = p.reason
+			break
+		}
+	}
+	return err
+}
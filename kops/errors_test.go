@@ -0,0 +1,46 @@
+package kops
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/infobloxopen/cluster-operator/utils"
+)
+
+func TestClassify(t *testing.T) {
+	cases := []struct {
+		stderr string
+		reason utils.ErrorReason
+	}{
+		{`Error: cluster not found "test.soheil.belamaric.com"`, utils.ReasonNotFound},
+		{`error reading cluster configuration: cluster "test.soheil.belamaric.com" not found`, utils.ReasonNotFound},
+		{"AccessDenied: Access Denied\n\tstatus code: 403, request id: 1234", utils.ReasonUnauthorized},
+		{"NoCredentialProviders: no valid providers in chain", utils.ReasonUnauthorized},
+		{"InvalidAccessKeyId: The AWS Access Key Id you provided does not exist in our records.", utils.ReasonUnauthorized},
+		{"Throttling: Rate exceeded\n\tstatus code: 400", utils.ReasonThrottled},
+		{"SlowDown: Please reduce your request rate.", utils.ReasonThrottled},
+		{"dial tcp: lookup s3.amazonaws.com: no such host", utils.ReasonTransient},
+		{"read tcp 10.0.0.1:443: connection reset by peer", utils.ReasonTransient},
+		{"error: unknown flag --foo", utils.ReasonUnknown},
+		{"", utils.ReasonUnknown},
+	}
+
+	for _, c := range cases {
+		err := classify(&utils.CommandError{ExitCode: 1, Stderr: c.stderr, Reason: utils.ReasonUnknown, Err: errors.New("exit status 1")})
+		if r := utils.ErrorReasonFor(err); r != c.reason {
+			t.Errorf("%q: got: %s wanted: %s", c.stderr, r, c.reason)
+		}
+	}
+}
+
+func TestClassifyKeepsReason(t *testing.T) {
+	err := classify(&utils.CommandError{Stderr: `cluster "a" not found`, Reason: utils.ReasonTransient})
+	if r := utils.ErrorReasonFor(err); r != utils.ReasonTransient {
+		t.Errorf("got: %s wanted: %s", r, utils.ReasonTransient)
+	}
+
+	plain := errors.New("plain")
+	if classify(plain) != plain {
+		t.Error("expected plain errors to be returned unchanged")
+	}
+}
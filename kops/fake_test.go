@@ -0,0 +1,216 @@
+package kops
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/infobloxopen/cluster-operator/kops/kopstest"
+	clusteroperatorv1alpha1 "github.com/infobloxopen/cluster-operator/pkg/apis/clusteroperator/v1alpha1"
+	"github.com/infobloxopen/cluster-operator/utils"
+)
+
+// newFakeKops returns a KopsCmd running the fake kops against a new file state
+// store, both are removed when the returned func is called
+func newFakeKops(t *testing.T) (*KopsCmd, *kopstest.StateStore, func()) {
+	dir, err := ioutil.TempDir("", "fakekops")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path, err := kopstest.Build(dir)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	store, err := kopstest.NewStateStore(filepath.Join(dir, "state"))
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+
+	k, cleanup := newTestKops(t)
+	k.path = path
+	k.devMode = false
+	return k, store, func() {
+		cleanup()
+		os.RemoveAll(dir)
+	}
+}
+
+func fakeClusterSpec(nodes int32) clusteroperatorv1alpha1.ClusterSpec {
+	masters := int32(1)
+	return clusteroperatorv1alpha1.ClusterSpec{
+		Name:              "test",
+		KubernetesVersion: "1.15.7",
+		Kops: &clusteroperatorv1alpha1.KopsSpec{
+			Cluster: clusteroperatorv1alpha1.KopsClusterSpec{
+				CloudProvider: "aws",
+				Subnets:       []clusteroperatorv1alpha1.KopsSubnetSpec{{Name: "us-east-2a", Zone: "us-east-2a"}},
+			},
+			InstanceGroups: []clusteroperatorv1alpha1.KopsInstanceGroupSpec{
+				{Name: "master-us-east-2a", Role: "Master", MachineType: "t2.medium", MinSize: &masters, MaxSize: &masters, Subnets: []string{"us-east-2a"}},
+				{Name: "nodes", Role: "Node", MachineType: "t2.medium", MinSize: &nodes, MaxSize: &nodes, Subnets: []string{"us-east-2a"}},
+			},
+		},
+	}
+}
+
+func TestFakeKopsLifecycle(t *testing.T) {
+	k, store, cleanup := newFakeKops(t)
+	defer cleanup()
+	ctx := context.TODO()
+	kc := clusteroperatorv1alpha1.KopsConfig{Name: "test.example.com", StateStore: store.URL()}
+	labels := map[string]string{"owner": "test"}
+
+	if err := k.ReplaceCluster(ctx, kc, fakeClusterSpec(2), labels); err != nil {
+		t.Fatal(err)
+	}
+	if exists, err := k.GetCluster(ctx, kc); err != nil || !exists {
+		t.Fatalf("expected the cluster got %t, %v", exists, err)
+	}
+
+	plan, err := k.PlanCluster(ctx, kc)
+	if err != nil || len(plan.Changes) != 3 || plan.Changes[0].Action != clusteroperatorv1alpha1.KopsChangeCreate {
+		t.Fatalf("expected the cluster and 2 instance groups to be created got %+v, %v", plan, err)
+	}
+	if _, err := k.ValidateCluster(ctx, kc); !utils.IsRetryable(err) {
+		t.Errorf("expected a cluster that was not created to be unreachable got %v", err)
+	}
+	if err := k.UpdateCluster(ctx, kc); err != nil {
+		t.Fatal(err)
+	}
+	if plan, err := k.PlanCluster(ctx, kc); err != nil || len(plan.Changes) != 0 {
+		t.Errorf("expected no changes got %+v, %v", plan, err)
+	}
+
+	status, err := k.ValidateCluster(ctx, kc)
+	if err != nil || len(status.Nodes) != 3 || len(status.Failures) != 0 {
+		t.Errorf("expected 3 ready nodes got %+v, %v", status, err)
+	}
+	config, err := k.GetKubeConfig(ctx, kc)
+	if err != nil || config.CurrentContext != kc.Name {
+		t.Errorf("expected the kubeconfig of the cluster got %+v, %v", config, err)
+	}
+	if err := k.RollingUpdateCluster(ctx, kc, nil); err != nil {
+		t.Error(err)
+	}
+
+	desired, err := Manifest(kc, fakeClusterSpec(2), labels)
+	if err != nil {
+		t.Fatal(err)
+	}
+	actual, err := k.GetManifest(ctx, kc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if paths, err := DiffManifests(desired, actual); err != nil || len(paths) != 0 {
+		t.Errorf("expected no drift got %v, %v", paths, err)
+	}
+	metadata, err := k.GetClusterMetadata(ctx, kc)
+	if err != nil || metadata.Labels["owner"] != "test" {
+		t.Errorf("expected the labels of the cluster got %+v, %v", metadata, err)
+	}
+	summaries, err := k.ListClusters(ctx, store.URL())
+	if err != nil || len(summaries) != 1 || summaries[0].Name != kc.Name || summaries[0].CreationTimestamp.IsZero() {
+		t.Errorf("expected the cluster to be listed got %+v, %v", summaries, err)
+	}
+
+	if err := k.ReplaceCluster(ctx, kc, fakeClusterSpec(3), labels); err != nil {
+		t.Fatal(err)
+	}
+	plan, err = k.PlanCluster(ctx, kc)
+	if err != nil || len(plan.Changes) != 1 || plan.Changes[0].Action != clusteroperatorv1alpha1.KopsChangeModify || len(plan.Changes[0].Fields) != 2 {
+		t.Errorf("expected the nodes to be resized got %+v, %v", plan, err)
+	}
+
+	if err := k.DeleteCluster(ctx, kc); err != nil {
+		t.Fatal(err)
+	}
+	if exists, err := k.GetCluster(ctx, kc); err != nil || exists {
+		t.Errorf("expected the cluster to be deleted got %t, %v", exists, err)
+	}
+
+	calls, err := store.Calls()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(calls) == 0 || !strings.HasPrefix(calls[0], "replace cluster -f ") || !strings.HasPrefix(calls[len(calls)-1], "delete cluster --name=test.example.com") {
+		t.Errorf("unexpected calls %q", calls)
+	}
+}
+
+func TestFakeKopsUpgrade(t *testing.T) {
+	k, store, cleanup := newFakeKops(t)
+	defer cleanup()
+	ctx := context.TODO()
+	kc := clusteroperatorv1alpha1.KopsConfig{Name: "test.example.com", StateStore: store.URL()}
+
+	if err := k.ReplaceCluster(ctx, kc, fakeClusterSpec(2), nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := k.UpdateCluster(ctx, kc); err != nil {
+		t.Fatal(err)
+	}
+	if err := k.UpgradeCluster(ctx, kc, "1.16.7"); err != nil {
+		t.Fatal(err)
+	}
+	manifest, err := k.GetManifest(ctx, kc)
+	if err != nil || !strings.Contains(string(manifest), "kubernetesVersion: 1.16.7") {
+		t.Errorf("expected the spec to be upgraded got %s, %v", manifest, err)
+	}
+	plan, err := k.PlanCluster(ctx, kc)
+	if err != nil || len(plan.Changes) != 1 || plan.Changes[0].Action != clusteroperatorv1alpha1.KopsChangeModify {
+		t.Errorf("expected the upgrade to be applied by update cluster got %+v, %v", plan, err)
+	}
+
+	calls, err := store.Calls()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "upgrade cluster --state=" + store.URL() + " --name=test.example.com --target-version=1.16.7 --yes"
+	found := false
+	for _, call := range calls {
+		found = found || call == expected
+	}
+	if !found {
+		t.Errorf("expected %q got %q", expected, calls)
+	}
+}
+
+func TestFakeKopsScript(t *testing.T) {
+	k, store, cleanup := newFakeKops(t)
+	defer cleanup()
+	ctx := context.TODO()
+	kc := clusteroperatorv1alpha1.KopsConfig{Name: "test.example.com", StateStore: store.URL()}
+
+	if err := k.ReplaceCluster(ctx, kc, fakeClusterSpec(2), nil); err != nil {
+		t.Fatal(err)
+	}
+	err := store.Script(
+		kopstest.Step{Command: "update cluster", ExitCode: 1, Stderr: "error: AccessDenied: Access Denied\n", Times: 1},
+		kopstest.Step{Command: "validate cluster", Delay: 5 * time.Second},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := k.UpdateCluster(ctx, kc); utils.ErrorReasonFor(err) != utils.ReasonUnauthorized {
+		t.Errorf("expected Unauthorized got %v", err)
+	}
+	if err := k.UpdateCluster(ctx, kc); err != nil {
+		t.Errorf("expected the failure to be used up got %v", err)
+	}
+
+	k.timeouts.Validate = 200 * time.Millisecond
+	start := time.Now()
+	if _, err := k.ValidateCluster(ctx, kc); utils.ErrorReasonFor(err) != utils.ReasonTransient {
+		t.Errorf("expected the slow validation to time out got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 4*time.Second {
+		t.Errorf("expected the fake kops to be killed got %v", elapsed)
+	}
+}
@@ -2,50 +2,115 @@ package kops
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
+	"os"
+	"strconv"
 	"strings"
+	"time"
+
 	clusteroperatorv1alpha1 "github.com/infobloxopen/cluster-operator/pkg/apis/clusteroperator/v1alpha1"
 	"github.com/infobloxopen/cluster-operator/utils"
 	"github.com/spf13/viper"
-	"gopkg.in/yaml.v2"
+	"sigs.k8s.io/yaml"
 )
 
+// Timeouts bounds the run time of each kops operation
+type Timeouts struct {
+	Default       time.Duration
+	Update        time.Duration
+	RollingUpdate time.Duration
+	Validate      time.Duration
+	Delete        time.Duration
+}
+
 type KopsCmd struct {
-	devMode         bool
-	publicKey       string
-	runStreamingCmd func(string) error
-	runCmd          func(string) (*bytes.Buffer, error)
-	path string
+	devMode   bool
+	publicKey string
+	path      string
+	executor  utils.Executor
+	timeouts  Timeouts
+	workspace *utils.Workspace
+	memory    *memoryStore
 }
 
-func NewKops() (*KopsCmd, error) {
+// NewKops returns a KopsCmd running kops inside ws, files written for kops and
+// the exported kubeconfig stay in the workspace.
+func NewKops(ws *utils.Workspace) (*KopsCmd, error) {
+	if ws == nil {
+		return nil, errors.New("kops: workspace is required")
+	}
+
 	k := KopsCmd{
-		publicKey:       viper.GetString("kops.ssh.key"),
-		devMode:         viper.GetBool("development"),
-		runStreamingCmd: utils.RunStreamingCmd,
-		runCmd:          utils.RunCmd,
-		path: viper.GetString("kops.path"),
+		publicKey: viper.GetString("kops.ssh.key"),
+		devMode:   viper.GetBool("development"),
+		path:      viper.GetString("kops.path"),
+		executor:  utils.NewExecutor(nil),
+		workspace: ws,
+		memory:    memory,
+		timeouts: Timeouts{
+			Default:       viper.GetDuration("kops.timeout.default"),
+			Update:        viper.GetDuration("kops.timeout.update"),
+			RollingUpdate: viper.GetDuration("kops.timeout.rolling.update"),
+			Validate:      viper.GetDuration("kops.timeout.validate"),
+			Delete:        viper.GetDuration("kops.timeout.delete"),
+		},
 	}
 
 	return &k, nil
 }
 
-func (k *KopsCmd) ReplaceCluster(cluster clusteroperatorv1alpha1.ClusterSpec) error {
-	tempConfigFile := cluster.Name + ".yaml"
-	err := utils.CopyBufferContentsToTempFile([]byte(cluster.Config), tempConfigFile)
+// run executes the kops binary with args, bounded by timeout
+func (k *KopsCmd) run(ctx context.Context, timeout time.Duration, args ...string) (*utils.Result, error) {
+	if timeout == 0 {
+		timeout = k.timeouts.Default
+	}
+	res, err := k.executor.Run(ctx, utils.Command{
+		Path:    k.path,
+		Args:    args,
+		Env:     k.workspace.Env(),
+		Dir:     k.workspace.Dir,
+		Timeout: timeout,
+	})
+	return res, classify(err)
+}
+
+// ReplaceCluster writes the kops manifest for spec to the state store, labels
+// are added to the kops Cluster
+func (k *KopsCmd) ReplaceCluster(ctx context.Context, cluster clusteroperatorv1alpha1.KopsConfig, spec clusteroperatorv1alpha1.ClusterSpec, labels map[string]string) error {
+	store, err := k.stateStore(cluster.StateStore)
+	if err != nil {
+		return err
+	}
+	data, err := Manifest(cluster, spec, labels)
 	if err != nil {
 		return err
 	}
 
-	kopsCmdStr := k.path +
-		" replace cluster" +
-		" -f ." + viper.GetString("kops.kube.dir") + "/" + tempConfigFile +
-		" --state=" + viper.GetString("kops.state.store") +
-		" --force"
+	switch store.scheme {
+	case SchemeMemory:
+		return k.memory.put(store, cluster.Name, data)
+	case SchemeFile:
+		// kops does not create the directory of the state store
+		if err := os.MkdirAll(store.path, 0700); err != nil {
+			return fmt.Errorf("kops: cannot create state store %s: %v", store, err)
+		}
+	}
 
-	err = k.runStreamingCmd(kopsCmdStr)
+	manifest, err := k.workspace.WriteFile(cluster.Name+".yaml", data)
+	if err != nil {
+		return err
+	}
+
+	_, err = k.run(ctx, k.timeouts.Default,
+		"replace", "cluster",
+		"-f", manifest,
+		store.flag(),
+		"--force",
+	)
 	if err != nil {
 		return err
 	}
@@ -53,22 +118,28 @@ func (k *KopsCmd) ReplaceCluster(cluster clusteroperatorv1alpha1.ClusterSpec) er
 	return nil
 }
 
-func (k *KopsCmd) UpdateCluster(cluster clusteroperatorv1alpha1.KopsConfig) error {
-	if k.devMode { // Dry-run in Dev Mode and skip Update Cluster
-		return nil
+func (k *KopsCmd) UpdateCluster(ctx context.Context, cluster clusteroperatorv1alpha1.KopsConfig) error {
+	store, err := k.stateStore(cluster.StateStore)
+	if err != nil {
+		return err
+	}
+	if err := k.checkCluster(store, cluster.Name); err != nil {
+		return err
+	}
+	if store.scheme == SchemeMemory {
+		return k.memory.apply(store, cluster.Name)
 	}
 
-	kopsCmdStr := k.path +
-		" update cluster " +
-		" --state=" + viper.GetString("kops.state.store") +
-		" --name=" + cluster.Name +
+	_, err = k.run(ctx, k.timeouts.Update,
+		"update", "cluster",
+		store.flag(),
+		"--name="+cluster.Name,
 		// FIXME - Add in when we switch to kops config
 		// https://github.com/kubernetes/kops/blob/master/docs/iam_roles.md#use-existing-aws-instance-profiles
-		// " --lifecycle-overrides IAMRole=ExistsAndWarnIfChanges," +
-		// "IAMRolePolicy=ExistsAndWarnIfChanges,IAMInstanceProfileRole=ExistsAndWarnIfChanges" +
-		" --yes"
-
-	err := k.runStreamingCmd(kopsCmdStr)
+		// "--lifecycle-overrides", "IAMRole=ExistsAndWarnIfChanges," +
+		// "IAMRolePolicy=ExistsAndWarnIfChanges,IAMInstanceProfileRole=ExistsAndWarnIfChanges",
+		"--yes",
+	)
 	if err != nil {
 		return err
 	}
@@ -76,46 +147,192 @@ func (k *KopsCmd) UpdateCluster(cluster clusteroperatorv1alpha1.KopsConfig) erro
 	return nil
 }
 
-func (k *KopsCmd) GetCluster(cluster clusteroperatorv1alpha1.KopsConfig) (bool, error) {
-	kopsCmdStr := k.path +
-		" get cluster " +
-		" --state=" + viper.GetString("kops.state.store") +
-		" --name=" + cluster.Name
-	exists := true
-	err := utils.RunStreamingCmd(kopsCmdStr)
+// UpgradeCluster moves the spec in the state store to version with kops
+// upgrade cluster, which also picks the images and component versions kops
+// recommends for it. The cloud is changed by UpdateCluster and
+// RollingUpdateCluster afterwards.
+func (k *KopsCmd) UpgradeCluster(ctx context.Context, cluster clusteroperatorv1alpha1.KopsConfig, version string) error {
+	store, err := k.stateStore(cluster.StateStore)
 	if err != nil {
-		if strings.Contains(err.Error(), "exit status 1") {
-			exists = false
-		}
+		return err
+	}
+	if err := k.checkCluster(store, cluster.Name); err != nil {
+		return err
+	}
+	if store.scheme == SchemeMemory {
+		// the memory state store has no recommendations, the version was
+		// written with the spec
+		return nil
+	}
+
+	_, err = k.run(ctx, k.timeouts.Default,
+		"upgrade", "cluster",
+		store.flag(),
+		"--name="+cluster.Name,
+		"--target-version="+version,
+		"--yes",
+	)
+	return err
+}
+
+// PlanCluster returns the changes kops update cluster would apply to the
+// cloud, without applying them
+func (k *KopsCmd) PlanCluster(ctx context.Context, cluster clusteroperatorv1alpha1.KopsConfig) (clusteroperatorv1alpha1.KopsPlan, error) {
+	store, err := k.stateStore(cluster.StateStore)
+	if err != nil {
+		return clusteroperatorv1alpha1.KopsPlan{}, err
+	}
+	if err := k.checkCluster(store, cluster.Name); err != nil {
+		return clusteroperatorv1alpha1.KopsPlan{}, err
+	}
+	if store.scheme == SchemeMemory {
+		return clusteroperatorv1alpha1.KopsPlan{}, nil
+	}
+
+	out, err := k.run(ctx, k.timeouts.Update,
+		"update", "cluster",
+		store.flag(),
+		"--name="+cluster.Name,
+	)
+	if err != nil {
+		return clusteroperatorv1alpha1.KopsPlan{}, err
+	}
+
+	plan, err := ParsePlan(out.Stdout)
+	if err != nil {
+		return plan, fmt.Errorf("kops: cannot parse update output: %v", err)
+	}
+	return plan, nil
+}
+
+// GetCluster reports whether the cluster exists in the state store. A missing
+// cluster is only reported when kops positively says so, any other failure is
+// returned as an error. The file and memory state stores are looked at
+// directly.
+func (k *KopsCmd) GetCluster(ctx context.Context, cluster clusteroperatorv1alpha1.KopsConfig) (bool, error) {
+	store, err := k.stateStore(cluster.StateStore)
+	if err != nil {
+		return false, err
+	}
+	if exists, ok, err := k.clusterExists(store, cluster.Name); ok {
 		return exists, err
 	}
-	return exists, nil
+
+	_, err = k.run(ctx, k.timeouts.Default,
+		"get", "cluster",
+		store.flag(),
+		"--name="+cluster.Name,
+	)
+	if err != nil {
+		if utils.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
 }
 
-func (k *KopsCmd) RollingUpdateCluster(cluster clusteroperatorv1alpha1.KopsConfig) error {
+// GetManifest returns the kops Cluster and InstanceGroups stored in the state
+// store as a multi document manifest
+func (k *KopsCmd) GetManifest(ctx context.Context, cluster clusteroperatorv1alpha1.KopsConfig) ([]byte, error) {
+	store, err := k.stateStore(cluster.StateStore)
+	if err != nil {
+		return nil, err
+	}
+	if err := k.checkCluster(store, cluster.Name); err != nil {
+		return nil, err
+	}
+	if store.scheme == SchemeMemory {
+		c, _ := k.memory.get(store, cluster.Name)
+		return c.manifest, nil
+	}
 
-	if k.devMode { // Dry-run in Dev Mode and skip Update Cluster
-		return nil
+	var docs [][]byte
+	for _, kind := range []string{"cluster", "instancegroups"} {
+		out, err := k.run(ctx, k.timeouts.Default,
+			"get", kind,
+			store.flag(),
+			"--name="+cluster.Name,
+			"-o", "yaml",
+		)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, out.Stdout)
+	}
+	return bytes.Join(docs, []byte("\n---\n")), nil
+}
+
+// ClusterMetadata is the metadata of a kops Cluster in the state store
+type ClusterMetadata struct {
+	Name   string            `json:"name"`
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// GetClusterMetadata returns the metadata of the kops Cluster in the state
+// store
+func (k *KopsCmd) GetClusterMetadata(ctx context.Context, cluster clusteroperatorv1alpha1.KopsConfig) (ClusterMetadata, error) {
+	store, err := k.stateStore(cluster.StateStore)
+	if err != nil {
+		return ClusterMetadata{}, err
+	}
+	if err := k.checkCluster(store, cluster.Name); err != nil {
+		return ClusterMetadata{}, err
+	}
+	if store.scheme == SchemeMemory {
+		c, _ := k.memory.get(store, cluster.Name)
+		return c.metadata, nil
+	}
+
+	out, err := k.run(ctx, k.timeouts.Default,
+		"get", "cluster",
+		store.flag(),
+		"--name="+cluster.Name,
+		"-o", "yaml",
+	)
+	if err != nil {
+		return ClusterMetadata{}, err
+	}
+
+	var obj struct {
+		Metadata ClusterMetadata `json:"metadata"`
+	}
+	if err := yaml.Unmarshal(out.Stdout, &obj); err != nil {
+		return ClusterMetadata{}, fmt.Errorf("kops: cannot parse cluster: %v", err)
+	}
+	return obj.Metadata, nil
+}
+
+// RollingUpdateCluster replaces the instances that need to pick up changes,
+// spec overrides the kops defaults for the update
+func (k *KopsCmd) RollingUpdateCluster(ctx context.Context, cluster clusteroperatorv1alpha1.KopsConfig, spec *clusteroperatorv1alpha1.RollingUpdateSpec) error {
+	store, err := k.stateStore(cluster.StateStore)
+	if err != nil {
+		return err
+	}
+	if store.scheme == SchemeMemory {
+		// memory clusters have no nodes to replace
+		return k.checkCluster(store, cluster.Name)
 	}
 
-	// Make sure we have config in tmp/config.yaml
-	_, err := k.GetKubeConfig(cluster)
+	// Make sure we have the kubeconfig in the workspace
+	remove, err := k.exportKubeConfig(ctx, cluster)
 	if err != nil {
 		return err
 	}
+	defer remove()
 
-	kopsCmdStr := k.path +
-		" rolling-update cluster " +
-		" --state=" + viper.GetString("kops.state.store") +
-		" --name=" + cluster.Name +
-		" --fail-on-validate-error=false" +
+	args := []string{
+		"rolling-update", "cluster",
+		store.flag(),
+		"--name=" + cluster.Name,
 		// FIXME - Add in when we switch to kops config
 		// https://github.com/kubernetes/kops/blob/master/docs/iam_roles.md#use-existing-aws-instance-profiles
-		// " --lifecycle-overrides IAMRole=ExistsAndWarnIfChanges," +
-		// "IAMRolePolicy=ExistsAndWarnIfChanges,IAMInstanceProfileRole=ExistsAndWarnIfChanges" +
-		" --yes"
-
-	err = k.runStreamingCmd(kopsCmdStr)
+		// "--lifecycle-overrides", "IAMRole=ExistsAndWarnIfChanges," +
+		// "IAMRolePolicy=ExistsAndWarnIfChanges,IAMInstanceProfileRole=ExistsAndWarnIfChanges",
+	}
+	args = append(args, rollingUpdateArgs(spec)...)
+	_, err = k.run(ctx, k.timeouts.RollingUpdate, append(args, "--yes")...)
 	if err != nil {
 		return err
 	}
@@ -123,129 +340,247 @@ func (k *KopsCmd) RollingUpdateCluster(cluster clusteroperatorv1alpha1.KopsConfi
 	return nil
 }
 
-func (k *KopsCmd) DeleteCluster(cluster clusteroperatorv1alpha1.KopsConfig) error {
+// rollingUpdateArgs returns the kops rolling-update flags for spec, flags for
+// unset fields are left out so kops versions without them keep working
+func rollingUpdateArgs(spec *clusteroperatorv1alpha1.RollingUpdateSpec) []string {
+	if spec == nil {
+		spec = &clusteroperatorv1alpha1.RollingUpdateSpec{}
+	}
 
-	kopsCmdStr := k.path +
-		" delete cluster --name=" + cluster.Name +
-		" --state=" + cluster.StateStore +
-		" --yes"
+	args := []string{"--fail-on-validate-error=" + strconv.FormatBool(spec.FailOnValidateError)}
+	if spec.MasterInterval != nil {
+		args = append(args, "--master-interval="+spec.MasterInterval.Duration.String())
+	}
+	if spec.NodeInterval != nil {
+		args = append(args, "--node-interval="+spec.NodeInterval.Duration.String())
+	}
+	if len(spec.InstanceGroups) > 0 {
+		args = append(args, "--instance-group="+strings.Join(spec.InstanceGroups, ","))
+	}
+	if len(spec.InstanceGroupRoles) > 0 {
+		args = append(args, "--instance-group-roles="+strings.Join(spec.InstanceGroupRoles, ","))
+	}
+	if spec.CloudOnly {
+		args = append(args, "--cloudonly")
+	}
+	if spec.DrainTimeout != nil {
+		args = append(args, "--drain-timeout="+spec.DrainTimeout.Duration.String())
+	}
+	if spec.ValidationTimeout != nil {
+		args = append(args, "--validation-timeout="+spec.ValidationTimeout.Duration.String())
+	}
+	if spec.ValidateCount != nil {
+		args = append(args, "--validate-count="+strconv.Itoa(int(*spec.ValidateCount)))
+	}
+	return args
+}
 
-	//out, err := utils.RunCmd(kopsCmd)
-	err := k.runStreamingCmd(kopsCmdStr)
+// DeleteCluster deletes the cluster and its cloud resources, a cluster that is
+// already gone is not an error
+func (k *KopsCmd) DeleteCluster(ctx context.Context, cluster clusteroperatorv1alpha1.KopsConfig) error {
+	store, err := k.stateStore(cluster.StateStore)
 	if err != nil {
 		return err
 	}
+	if store.scheme == SchemeMemory {
+		k.memory.delete(store, cluster.Name)
+		return nil
+	}
+	if exists, ok, err := k.clusterExists(store, cluster.Name); ok && (err != nil || !exists) {
+		return err
+	}
+
+	_, err = k.run(ctx, k.timeouts.Delete,
+		"delete", "cluster",
+		"--name="+cluster.Name,
+		store.flag(),
+		"--yes",
+	)
+	if err != nil && !utils.IsNotFound(err) {
+		return err
+	}
 
 	return nil
 }
 
-//func (k *KopsCmd) DeleteCluster(cluster clusteroperatorv1alpha1.KopsConfig) (string, error) {
-//
-//	//kopsCmd := "./.bin/docker"
-//	kopsArgs := []string{"run", "--env-file=tmp/kops_env"}
-//	kopsArgs = append(kopsArgs,
-//		"soheileizadi/kops:v1.0",
-//		"delete",
-//		"cluster",
-//		"--name=" + cluster.Name,
-//		"--yes")
-//
-//	fmt.Println(kopsArgs)
-//	out, err := utils.RunDockerCmd(kopsArgs)
-//	if err != nil {
-//		return string(out.Bytes()), err
-//	}
-//
-//	return string(out.Bytes()), nil
-//}
-
-func (k *KopsCmd) ValidateCluster(cluster clusteroperatorv1alpha1.KopsConfig) (clusteroperatorv1alpha1.KopsStatus, error) {
-
+func (k *KopsCmd) ValidateCluster(ctx context.Context, cluster clusteroperatorv1alpha1.KopsConfig) (clusteroperatorv1alpha1.KopsStatus, error) {
 	status := clusteroperatorv1alpha1.KopsStatus{}
 
-	if k.devMode { // Dry-run in Dev Mode and skip Validate Cluster return Cluster Up Status
-		status = clusteroperatorv1alpha1.KopsStatus{
-			Nodes: []clusteroperatorv1alpha1.KopsNode{
-				{
-					Name:     "ip-172-17-17-143.compute.internal",
-					Zone:     "us-east-2a",
-					Role:     "Master",
-					Hostname: "ip-172-17-17-143.compute.internal",
-					Status:   "True",
-				},
-			},
-		}
-		return status, nil
-	}
-
-	// Make sure we have config in tmp/config.yaml
-	_, err := k.GetKubeConfig(cluster)
+	store, err := k.stateStore(cluster.StateStore)
 	if err != nil {
 		return status, err
 	}
+	if store.scheme == SchemeMemory {
+		if err := k.checkCluster(store, cluster.Name); err != nil {
+			return status, err
+		}
+		c, _ := k.memory.get(store, cluster.Name)
+		return c.status, nil
+	}
 
-	kopsCmdStr := k.path +
-		" validate cluster" +
-		" --state=" + cluster.StateStore +
-		" --name=" + cluster.Name + " -o json"
-	out, err := k.runCmd(kopsCmdStr)
+	// Make sure we have the kubeconfig in the workspace
+	remove, err := k.exportKubeConfig(ctx, cluster)
 	if err != nil {
 		return status, err
 	}
-
-	json.Unmarshal(out.Bytes(), &status)
-	if err != nil {
+	defer remove()
+
+	out, err := k.run(ctx, k.timeouts.Validate,
+		"validate", "cluster",
+		store.flag(),
+		"--name="+cluster.Name,
+		"-o", "json",
+	)
+	if out == nil {
 		return status, err
 	}
+	// kops exits non-zero when the cluster fails validation but still prints
+	// the result, only report an error when there is no result to look at
+	status, parseErr := ParseValidation(out.Stdout)
+	if parseErr != nil {
+		if err != nil {
+			return status, err
+		}
+		return status, fmt.Errorf("kops: cannot parse validate output: %v", parseErr)
+	}
 
-	fmt.Println("Kops Response: ", string(out.Bytes()))
 	return status, nil
 }
 
-func (k *KopsCmd) GetKubeConfig(cluster clusteroperatorv1alpha1.KopsConfig) (clusteroperatorv1alpha1.KubeConfig, error) {
-
-	if k.devMode { // Dry-run in Dev Mode and skip get kube.config
-		return clusteroperatorv1alpha1.KubeConfig{}, nil
+// GetKubeConfig exports the kubeconfig of cluster and returns it. The file
+// kops wrote is removed from the workspace once it is read, it holds the
+// cluster admin credentials.
+func (k *KopsCmd) GetKubeConfig(ctx context.Context, cluster clusteroperatorv1alpha1.KopsConfig) (KubeConfig, error) {
+	store, err := k.stateStore(cluster.StateStore)
+	if err != nil {
+		return KubeConfig{}, err
+	}
+	if store.scheme == SchemeMemory {
+		// memory clusters have no API server to export a kubeconfig for
+		return KubeConfig{}, k.checkCluster(store, cluster.Name)
 	}
 
-	config := clusteroperatorv1alpha1.KubeConfig{}
+	remove, err := k.exportKubeConfig(ctx, cluster)
+	if err != nil {
+		return KubeConfig{}, err
+	}
+	defer remove()
 
-	kopsCmdStr := k.path +
-		" export kubecfg" +
-		" --name=" + cluster.Name +
-		" --state=" + cluster.StateStore +
-		" --kubeconfig=" +  viper.GetString("tmp.dir") + "/config-" + cluster.Name
+	file, err := ioutil.ReadFile(k.workspace.KubeConfigPath())
+	if err != nil {
+		return KubeConfig{}, err
+	}
 
-	err := utils.RunStreamingCmd(kopsCmdStr)
+	config, err := ParseKubeConfig(file)
 	if err != nil {
-		return clusteroperatorv1alpha1.KubeConfig{}, err
+		return KubeConfig{}, fmt.Errorf("kops: cannot parse kubeconfig: %v", err)
 	}
 
-	file, err := ioutil.ReadFile(viper.GetString("tmp.dir") + "/config-" + cluster.Name)
+	return config, nil
+}
+
+// exportKubeConfig writes the kubeconfig of cluster to the workspace, where
+// the kops commands talking to the cluster read it from. The returned func
+// removes it again.
+func (k *KopsCmd) exportKubeConfig(ctx context.Context, cluster clusteroperatorv1alpha1.KopsConfig) (func(), error) {
+	store, err := k.stateStore(cluster.StateStore)
 	if err != nil {
-		return clusteroperatorv1alpha1.KubeConfig{}, err
+		return nil, err
+	}
+	if err := k.checkCluster(store, cluster.Name); err != nil {
+		return nil, err
 	}
 
-	err = yaml.Unmarshal([]byte(file), &config)
+	path := k.workspace.KubeConfigPath()
+	// anything left behind goes with the workspace at the end of the reconcile
+	remove := func() { os.Remove(path) }
+
+	_, err = k.run(ctx, k.timeouts.Default,
+		"export", "kubecfg",
+		"--name="+cluster.Name,
+		store.flag(),
+		"--kubeconfig="+path,
+	)
 	if err != nil {
-		return clusteroperatorv1alpha1.KubeConfig{}, err
+		remove()
+		return nil, err
 	}
+	return remove, nil
+}
 
-	return config, nil
+// ClusterSummary describes a kops Cluster in a state store
+type ClusterSummary struct {
+	Name              string
+	CreationTimestamp time.Time
+	CloudProvider     string
+	KubernetesVersion string
 }
 
-func (k *KopsCmd) ListClusters(stateStore string) ([]string, error) {
-	kopsCmd := viper.GetString("docker.bin.path") + "run" +
-		utils.GetDockerEnvFlags() +
-		" soheileizadi/kops:v1.0" +
-		" get cluster " +
-		" --state=" + stateStore +
-		" -o json | jq -r '.[][\"metadata\"][\"name\"]'"
+// ListClusters returns the kops Clusters in stateStore, a state store without
+// clusters gives an empty list, and so does a file state store that does not
+// exist yet
+func (k *KopsCmd) ListClusters(ctx context.Context, stateStore string) ([]ClusterSummary, error) {
+	store, err := k.stateStore(stateStore)
+	if err != nil {
+		return nil, err
+	}
+	switch store.scheme {
+	case SchemeMemory:
+		return k.memory.list(store), nil
+	case SchemeFile:
+		if _, err := os.Stat(store.path); os.IsNotExist(err) {
+			return nil, nil
+		}
+	}
 
-	out, err := utils.RunCmd(kopsCmd)
+	out, err := k.run(ctx, k.timeouts.Default,
+		"get", "clusters",
+		store.flag(),
+		"-o", "json",
+	)
 	if err != nil {
+		if utils.IsNotFound(err) {
+			return nil, nil
+		}
 		return nil, err
 	}
+	return parseClusterList(out.Stdout)
+}
+
+// parseClusterList parses the output of kops get clusters -o json, kops prints
+// a single cluster as an object and several as a list
+func parseClusterList(data []byte) ([]ClusterSummary, error) {
+	type cluster struct {
+		Metadata struct {
+			Name              string    `json:"name"`
+			CreationTimestamp time.Time `json:"creationTimestamp"`
+		} `json:"metadata"`
+		Spec struct {
+			CloudProvider     string `json:"cloudProvider"`
+			KubernetesVersion string `json:"kubernetesVersion"`
+		} `json:"spec"`
+	}
+
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil, nil
+	}
+	var clusters []cluster
+	if data[0] != '[' {
+		data = append(append([]byte("["), data...), ']')
+	}
+	if err := json.Unmarshal(data, &clusters); err != nil {
+		return nil, fmt.Errorf("kops: cannot parse cluster list: %v", err)
+	}
 
-	return strings.Split(string(out.Bytes()), "\n"), nil
+	summaries := make([]ClusterSummary, 0, len(clusters))
+	for _, c := range clusters {
+		summaries = append(summaries, ClusterSummary{
+			Name:              c.Metadata.Name,
+			CreationTimestamp: c.Metadata.CreationTimestamp,
+			CloudProvider:     c.Spec.CloudProvider,
+			KubernetesVersion: c.Spec.KubernetesVersion,
+		})
+	}
+	return summaries, nil
 }
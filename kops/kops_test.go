@@ -1,10 +1,18 @@
 package kops
 
 import (
-	"bytes"
-	clusteroperatorv1alpha1 "github.com/infobloxopen/cluster-operator/pkg/apis/clusteroperator/v1alpha1"
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
+
+	clusteroperatorv1alpha1 "github.com/infobloxopen/cluster-operator/pkg/apis/clusteroperator/v1alpha1"
+	"github.com/infobloxopen/cluster-operator/utils"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 var kopsConfig clusteroperatorv1alpha1.KopsConfig = clusteroperatorv1alpha1.KopsConfig{
@@ -23,33 +31,65 @@ type testCase struct {
 	found bool
 }
 
-var cmd string
-
-func mockRunStreamingCmd(cmdString string) error {
-	cmd = cmdString
-	return nil
+// mockExecutor records the commands it is asked to run and fails them with
+// stderr and exit code when exitCode is set
+type mockExecutor struct {
+	cmds     []utils.Command
+	exitCode int
+	stdout   string
+	stderr   string
+	// run is called with every command, e.g. to write the files kops would
+	run func(c utils.Command)
 }
 
-func mockRunCmd(cmdString string) (*bytes.Buffer, error) {
-	cmd = cmdString
-	return nil, nil
+func (m *mockExecutor) Run(ctx context.Context, c utils.Command) (*utils.Result, error) {
+	m.cmds = append(m.cmds, c)
+	if m.run != nil {
+		m.run(c)
+	}
+	res := &utils.Result{ExitCode: m.exitCode, Stdout: []byte(m.stdout), Stderr: []byte(m.stderr)}
+	if m.exitCode != 0 {
+		return res, &utils.CommandError{
+			Argv:     c.Argv(),
+			ExitCode: m.exitCode,
+			Stderr:   m.stderr,
+			Reason:   utils.ReasonUnknown,
+			Err:      errors.New("exit status 1"),
+		}
+	}
+	return res, nil
 }
 
-func TestCreateCluster(t *testing.T) {
-	k, err := NewKops()
+// newTestKops returns a KopsCmd with a workspace that is removed when the
+// returned func is called
+func newTestKops(t *testing.T) (*KopsCmd, func()) {
+	root, err := ioutil.TempDir("", "kops")
 	if err != nil {
-		t.Error("Expected no error got", err)
-		return
+		t.Fatal(err)
 	}
+	ws, err := utils.NewWorkspace(root, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	k, err := NewKops(ws)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k, func() { os.RemoveAll(root) }
+}
 
-	k.runStreamingCmd = mockRunStreamingCmd
-	k.runCmd = mockRunCmd
+func TestCreateCluster(t *testing.T) {
+	k, cleanup := newTestKops(t)
+	defer cleanup()
+
+	m := &mockExecutor{}
+	k.executor = m
 
 	values := []testCase{
 		{"replace", false},
 		{"cluster", false},
 		{"-f", false},
-		{"/TestCluster.yaml", false},
+		{"/TestCluster.example.com.yaml", false},
 		{"--state=", false},
 		{"--force", false},
 	}
@@ -59,17 +99,26 @@ func TestCreateCluster(t *testing.T) {
 		Config:     "",
 		KopsConfig: clusteroperatorv1alpha1.KopsConfig{},
 	}
+	kc := clusteroperatorv1alpha1.KopsConfig{Name: "TestCluster.example.com", StateStore: "s3://state"}
 
-	err = k.ReplaceCluster(cluster)
+	err := k.ReplaceCluster(context.TODO(), kc, cluster, nil)
 	if err != nil {
 		t.Error("Expected no error got", err)
 		return
 	}
 
-	cmdValues := strings.Split(cmd, " ")
-	for _, c := range cmdValues {
+	if len(m.cmds) != 1 {
+		t.Fatal("Expected 1 command got", len(m.cmds))
+	}
+	if m.cmds[0].Dir != k.workspace.Dir {
+		t.Error("Expected kops to run in", k.workspace.Dir, "got", m.cmds[0].Dir)
+	}
+	if _, err := os.Stat(k.workspace.Path("TestCluster.example.com.yaml")); err != nil {
+		t.Error("Expected manifest in workspace", err)
+	}
+	for _, c := range m.cmds[0].Args {
 		for i, v := range values {
-			if v.value == c {
+			if strings.HasPrefix(c, v.value) || strings.HasSuffix(c, v.value) {
 				values[i].found = true
 				break
 			}
@@ -82,3 +131,227 @@ func TestCreateCluster(t *testing.T) {
 		}
 	}
 }
+
+func TestTimeouts(t *testing.T) {
+	k, cleanup := newTestKops(t)
+	defer cleanup()
+
+	m := &mockExecutor{}
+	k.executor = m
+	k.timeouts = Timeouts{Default: 1, Update: 2, RollingUpdate: 3, Validate: 4, Delete: 5}
+
+	if err := k.UpdateCluster(context.TODO(), kopsConfig); err != nil {
+		t.Fatal(err)
+	}
+	if err := k.DeleteCluster(context.TODO(), kopsConfig); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := k.GetCluster(context.TODO(), kopsConfig); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []struct {
+		verb    string
+		timeout int64
+	}{
+		{"update", 2},
+		{"delete", 5},
+		{"get", 1},
+	}
+	if len(m.cmds) != len(expected) {
+		t.Fatal("Expected", len(expected), "commands got", len(m.cmds))
+	}
+	for i, e := range expected {
+		if m.cmds[i].Args[0] != e.verb {
+			t.Error("Expected", e.verb, "got", m.cmds[i].Args[0])
+		}
+		if int64(m.cmds[i].Timeout) != e.timeout {
+			t.Error("Expected timeout", e.timeout, "for", e.verb, "got", m.cmds[i].Timeout)
+		}
+	}
+}
+
+func TestGetCluster(t *testing.T) {
+	cases := []struct {
+		name     string
+		exitCode int
+		stderr   string
+		exists   bool
+		err      bool
+	}{
+		{"exists", 0, "", true, false},
+		{"not found", 1, `Error: cluster not found "test.soheil.belamaric.com"`, false, false},
+		{"access denied", 1, "error reading state store: AccessDenied: Access Denied\n\tstatus code: 403", false, true},
+		{"unclassified", 1, "something went wrong", false, true},
+	}
+
+	for _, c := range cases {
+		k, cleanup := newTestKops(t)
+		defer cleanup()
+		k.executor = &mockExecutor{exitCode: c.exitCode, stderr: c.stderr}
+
+		exists, err := k.GetCluster(context.TODO(), kopsConfig)
+		if exists != c.exists {
+			t.Errorf("%s: expected exists %v got %v", c.name, c.exists, exists)
+		}
+		if (err != nil) != c.err {
+			t.Errorf("%s: expected error %v got %v", c.name, c.err, err)
+		}
+	}
+}
+
+func TestGetClusterMetadata(t *testing.T) {
+	k, cleanup := newTestKops(t)
+	defer cleanup()
+	k.executor = &mockExecutor{stdout: `apiVersion: kops.k8s.io/v1alpha2
+kind: Cluster
+metadata:
+  creationTimestamp: "2020-02-01T12:00:00Z"
+  labels:
+    cluster-operator.infobloxopen.github.com/owner-uid: "1234"
+  name: test.soheil.belamaric.com
+spec:
+  kubernetesVersion: 1.16.7
+`}
+
+	metadata, err := k.GetClusterMetadata(context.TODO(), kopsConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if metadata.Name != kopsConfig.Name || metadata.Labels[clusteroperatorv1alpha1.OwnerUIDLabel] != "1234" {
+		t.Errorf("Expected %s with owner 1234 got %+v", kopsConfig.Name, metadata)
+	}
+}
+
+func TestListClusters(t *testing.T) {
+	created := time.Date(2020, 2, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		executor *mockExecutor
+		expected []ClusterSummary
+	}{
+		{
+			name: "several clusters",
+			executor: &mockExecutor{stdout: `[{"kind":"Cluster","metadata":{"name":"a.example.com","creationTimestamp":"2020-02-01T12:00:00Z"},"spec":{"cloudProvider":"aws","kubernetesVersion":"1.16.7"}},
+{"kind":"Cluster","metadata":{"name":"b.example.com","creationTimestamp":"2020-02-01T12:00:00Z"},"spec":{"cloudProvider":"gce","kubernetesVersion":"1.15.10"}}]`},
+			expected: []ClusterSummary{
+				{Name: "a.example.com", CreationTimestamp: created, CloudProvider: "aws", KubernetesVersion: "1.16.7"},
+				{Name: "b.example.com", CreationTimestamp: created, CloudProvider: "gce", KubernetesVersion: "1.15.10"},
+			},
+		},
+		{
+			name: "one cluster",
+			executor: &mockExecutor{stdout: `{"kind":"Cluster","metadata":{"name":"a.example.com","creationTimestamp":"2020-02-01T12:00:00Z"},"spec":{"cloudProvider":"aws","kubernetesVersion":"1.16.7"}}
+`},
+			expected: []ClusterSummary{
+				{Name: "a.example.com", CreationTimestamp: created, CloudProvider: "aws", KubernetesVersion: "1.16.7"},
+			},
+		},
+		{
+			name:     "no clusters",
+			executor: &mockExecutor{exitCode: 1, stderr: "Error: No clusters found"},
+		},
+	}
+
+	for _, test := range tests {
+		k, cleanup := newTestKops(t)
+		k.executor = test.executor
+		summaries, err := k.ListClusters(context.TODO(), "s3://state.example.com")
+		cleanup()
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", test.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(summaries, test.expected) {
+			t.Errorf("%s: expected %+v got %+v", test.name, test.expected, summaries)
+		}
+		if argv := strings.Join(test.executor.cmds[0].Args, " "); argv != "get clusters --state=s3://state.example.com -o json" {
+			t.Errorf("%s: unexpected arguments %s", test.name, argv)
+		}
+	}
+
+	k, cleanup := newTestKops(t)
+	defer cleanup()
+	k.executor = &mockExecutor{exitCode: 1, stderr: "AccessDenied: Access Denied"}
+	if _, err := k.ListClusters(context.TODO(), "s3://state.example.com"); utils.ErrorReasonFor(err) != utils.ReasonUnauthorized {
+		t.Error("Expected", utils.ReasonUnauthorized, "got", err)
+	}
+}
+
+func TestDeleteClusterNotFound(t *testing.T) {
+	k, cleanup := newTestKops(t)
+	defer cleanup()
+	k.executor = &mockExecutor{exitCode: 1, stderr: `cluster "test.soheil.belamaric.com" not found`}
+
+	if err := k.DeleteCluster(context.TODO(), kopsConfig); err != nil {
+		t.Error("Expected no error for a missing cluster got", err)
+	}
+
+	k.executor = &mockExecutor{exitCode: 1, stderr: "RequestLimitExceeded: Request limit exceeded."}
+	err := k.DeleteCluster(context.TODO(), kopsConfig)
+	if utils.ErrorReasonFor(err) != utils.ReasonThrottled {
+		t.Error("Expected", utils.ReasonThrottled, "got", err)
+	}
+}
+
+func TestGetKubeConfigRemovesFile(t *testing.T) {
+	k, cleanup := newTestKops(t)
+	defer cleanup()
+
+	k.executor = &mockExecutor{run: func(c utils.Command) {
+		ioutil.WriteFile(k.workspace.KubeConfigPath(), []byte("apiVersion: v1\nkind: Config\ncurrent-context: test\n"), 0600)
+	}}
+
+	config, err := k.GetKubeConfig(context.TODO(), kopsConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.CurrentContext != "test" {
+		t.Error("Expected current-context test got", config.CurrentContext)
+	}
+	if _, err := os.Stat(k.workspace.KubeConfigPath()); !os.IsNotExist(err) {
+		t.Error("Expected kubeconfig to be removed from the workspace got", err)
+	}
+}
+
+func TestRollingUpdateArgs(t *testing.T) {
+	validateCount := int32(2)
+	cases := []struct {
+		name     string
+		spec     *clusteroperatorv1alpha1.RollingUpdateSpec
+		expected []string
+	}{
+		{"defaults", nil, []string{"--fail-on-validate-error=false"}},
+		{
+			name: "all",
+			spec: &clusteroperatorv1alpha1.RollingUpdateSpec{
+				MasterInterval:      &metav1.Duration{Duration: 5 * time.Minute},
+				NodeInterval:        &metav1.Duration{Duration: 90 * time.Second},
+				InstanceGroups:      []string{"nodes-a", "nodes-b"},
+				InstanceGroupRoles:  []string{"Node"},
+				CloudOnly:           true,
+				DrainTimeout:        &metav1.Duration{Duration: 10 * time.Minute},
+				FailOnValidateError: true,
+				ValidationTimeout:   &metav1.Duration{Duration: 20 * time.Minute},
+				ValidateCount:       &validateCount,
+			},
+			expected: []string{
+				"--fail-on-validate-error=true",
+				"--master-interval=5m0s",
+				"--node-interval=1m30s",
+				"--instance-group=nodes-a,nodes-b",
+				"--instance-group-roles=Node",
+				"--cloudonly",
+				"--drain-timeout=10m0s",
+				"--validation-timeout=20m0s",
+				"--validate-count=2",
+			},
+		},
+	}
+
+	for _, c := range cases {
+		if args := rollingUpdateArgs(c.spec); !reflect.DeepEqual(args, c.expected) {
+			t.Errorf("%s: expected %v got %v", c.name, c.expected, args)
+		}
+	}
+}
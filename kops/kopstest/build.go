@@ -0,0 +1,29 @@
+package kopstest
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// fakePackage is the main package of the fake kops
+const fakePackage = "github.com/infobloxopen/cluster-operator/kops/kopstest/kops"
+
+// Build compiles the fake kops into dir and returns its path, the value for
+// kops.path. It is built with the -mod of GOFLAGS, so tests run with
+// GOFLAGS=-mod=mod build it the same way, and from the vendored dependencies
+// when GOFLAGS sets none so tests need no network.
+func Build(dir string) (string, error) {
+	path := filepath.Join(dir, "kops")
+	args := []string{"build", "-o", path}
+	if !strings.Contains(os.Getenv("GOFLAGS"), "-mod=") {
+		args = append(args, "-mod=vendor")
+	}
+	out, err := exec.Command("go", append(args, fakePackage)...).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("kopstest: cannot build the fake kops: %v: %s", err, out)
+	}
+	return path, nil
+}
@@ -0,0 +1,695 @@
+// Package kopstest provides a fake kops for tests. The fake is a real binary,
+// built with Build, that keeps clusters in a file:// state store and stands
+// in for the cloud, so tests drive KopsCmd through the same processes,
+// arguments and output parsing as with kops.
+package kopstest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/yaml"
+)
+
+// valueFlags take the next argument as their value when not given as
+// --flag=value, the other flags without a value are booleans
+var valueFlags = map[string]bool{
+	"f":          true,
+	"filename":   true,
+	"o":          true,
+	"output":     true,
+	"name":       true,
+	"state":      true,
+	"kubeconfig": true,
+
+	"target-version": true,
+}
+
+// invocation is a parsed kops command line
+type invocation struct {
+	line  string
+	words []string
+	flags map[string]string
+	store *StateStore
+	out   io.Writer
+}
+
+func parseArgs(args []string) invocation {
+	inv := invocation{line: strings.Join(args, " "), flags: map[string]string{}}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "-") {
+			inv.words = append(inv.words, arg)
+			continue
+		}
+		name := strings.TrimLeft(arg, "-")
+		if j := strings.Index(name, "="); j >= 0 {
+			inv.flags[name[:j]] = name[j+1:]
+		} else if valueFlags[name] && i+1 < len(args) {
+			inv.flags[name] = args[i+1]
+			i++
+		} else {
+			inv.flags[name] = "true"
+		}
+	}
+	return inv
+}
+
+func (inv invocation) flag(names ...string) string {
+	for _, name := range names {
+		if value, ok := inv.flags[name]; ok {
+			return value
+		}
+	}
+	return ""
+}
+
+// clusterName is the --name of the command, or the argument after the noun
+func (inv invocation) clusterName() string {
+	if name := inv.flag("name"); name != "" {
+		return name
+	}
+	if len(inv.words) > 2 {
+		return inv.words[2]
+	}
+	return ""
+}
+
+// commands are the fake kops commands by verb and noun
+var commands = map[string]func(invocation) error{
+	"replace cluster":        replaceCluster,
+	"update cluster":         updateCluster,
+	"upgrade cluster":        upgradeCluster,
+	"get cluster":            getClusters,
+	"get clusters":           getClusters,
+	"get instancegroups":     getInstanceGroups,
+	"get ig":                 getInstanceGroups,
+	"validate cluster":       validateCluster,
+	"export kubecfg":         exportKubecfg,
+	"rolling-update cluster": rollingUpdateCluster,
+	"delete cluster":         deleteCluster,
+}
+
+// Main runs the fake kops with the command line args, without the program
+// name, and returns its exit code
+func Main(args []string, stdout, stderr io.Writer) int {
+	inv := parseArgs(args)
+	inv.out = stdout
+
+	if len(inv.words) > 0 && inv.words[0] == "version" {
+		fmt.Fprintln(stdout, "Version 1.15.2 (fake)")
+		return 0
+	}
+
+	state := inv.flag("state")
+	if state == "" {
+		state = os.Getenv("KOPS_STATE_STORE")
+	}
+	if state == "" {
+		fmt.Fprintln(stderr, "Error: State Store: Required value: Please set the --state flag or export KOPS_STATE_STORE")
+		return 1
+	}
+	if !strings.HasPrefix(state, "file://") {
+		fmt.Fprintf(stderr, "Error: the fake kops only supports file:// state stores, not %q\n", state)
+		return 1
+	}
+	inv.store = &StateStore{Dir: strings.TrimPrefix(state, "file://")}
+
+	step, err := inv.store.record(inv.line)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	if step != nil {
+		time.Sleep(step.Delay)
+		if step.scripted() {
+			fmt.Fprint(stdout, step.Stdout)
+			fmt.Fprint(stderr, step.Stderr)
+			return step.ExitCode
+		}
+	}
+
+	key := strings.Join(inv.words, " ")
+	if len(inv.words) > 2 {
+		key = strings.Join(inv.words[:2], " ")
+	}
+	command, ok := commands[key]
+	if !ok {
+		fmt.Fprintf(stderr, "Error: unknown command %q\n", inv.line)
+		return 1
+	}
+	if err := command(inv); err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// errNotFound is the error kops fails with for a missing cluster
+func errNotFound(name string) error {
+	return fmt.Errorf("cluster not found %q", name)
+}
+
+// Objects of the state store, only the fields the fake looks at
+
+type cluster struct {
+	Kind     string `json:"kind"`
+	Metadata struct {
+		Name string `json:"name"`
+	} `json:"metadata"`
+	Spec struct {
+		KubernetesVersion string `json:"kubernetesVersion"`
+	} `json:"spec"`
+}
+
+type instanceGroup struct {
+	Kind     string `json:"kind"`
+	Metadata struct {
+		Name string `json:"name"`
+	} `json:"metadata"`
+	Spec struct {
+		Role        string   `json:"role"`
+		MachineType string   `json:"machineType"`
+		MinSize     *int     `json:"minSize"`
+		MaxSize     *int     `json:"maxSize"`
+		Subnets     []string `json:"subnets"`
+	} `json:"spec"`
+}
+
+// cloud is what kops update cluster --yes created for a cluster
+type cloud struct {
+	KubernetesVersion string                `json:"kubernetesVersion,omitempty"`
+	InstanceGroups    map[string]cloudGroup `json:"instanceGroups,omitempty"`
+}
+
+type cloudGroup struct {
+	Role        string   `json:"role"`
+	MachineType string   `json:"machineType,omitempty"`
+	MinSize     int      `json:"minSize"`
+	MaxSize     int      `json:"maxSize"`
+	Subnets     []string `json:"subnets,omitempty"`
+}
+
+// Paths of a cluster in the state store, laid out like kops does
+func clusterDir(s *StateStore, name string) string { return filepath.Join(s.Dir, name) }
+func configPath(s *StateStore, name string) string { return filepath.Join(s.Dir, name, "config") }
+func groupDir(s *StateStore, name string) string   { return filepath.Join(s.Dir, name, "instancegroup") }
+func cloudPath(s *StateStore, name string) string  { return filepath.Join(s.Dir, name, "cloud.json") }
+
+// readConfig returns the Cluster document of name
+func readConfig(s *StateStore, name string) ([]byte, error) {
+	data, err := ioutil.ReadFile(configPath(s, name))
+	if os.IsNotExist(err) {
+		return nil, errNotFound(name)
+	}
+	return data, err
+}
+
+// readGroups returns the InstanceGroup documents of name ordered by name
+func readGroups(s *StateStore, name string) ([][]byte, error) {
+	files, err := ioutil.ReadDir(groupDir(s, name))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var docs [][]byte
+	for _, f := range files {
+		data, err := ioutil.ReadFile(filepath.Join(groupDir(s, name), f.Name()))
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, data)
+	}
+	return docs, nil
+}
+
+// desired is the cloud the spec of name in the state store asks for
+func desired(s *StateStore, name string) (*cloud, error) {
+	data, err := readConfig(s, name)
+	if err != nil {
+		return nil, err
+	}
+	var c cluster
+	if err := yaml.Unmarshal(data, &c); err != nil {
+		return nil, err
+	}
+	docs, err := readGroups(s, name)
+	if err != nil {
+		return nil, err
+	}
+
+	want := &cloud{KubernetesVersion: c.Spec.KubernetesVersion, InstanceGroups: map[string]cloudGroup{}}
+	for _, doc := range docs {
+		var ig instanceGroup
+		if err := yaml.Unmarshal(doc, &ig); err != nil {
+			return nil, err
+		}
+		group := cloudGroup{Role: ig.Spec.Role, MachineType: ig.Spec.MachineType, Subnets: ig.Spec.Subnets}
+		if ig.Spec.MinSize != nil {
+			group.MinSize = *ig.Spec.MinSize
+		}
+		group.MaxSize = group.MinSize
+		if ig.Spec.MaxSize != nil {
+			group.MaxSize = *ig.Spec.MaxSize
+		}
+		want.InstanceGroups[ig.Metadata.Name] = group
+	}
+	return want, nil
+}
+
+// applied is the cloud of name, nil when kops update cluster --yes never ran
+func applied(s *StateStore, name string) (*cloud, error) {
+	data, err := ioutil.ReadFile(cloudPath(s, name))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	have := &cloud{}
+	return have, json.Unmarshal(data, have)
+}
+
+// replaceCluster stores the Cluster and InstanceGroups of the -f manifest
+func replaceCluster(inv invocation) error {
+	data, err := ioutil.ReadFile(inv.flag("f", "filename"))
+	if err != nil {
+		return err
+	}
+
+	var name string
+	var config []byte
+	groups := map[string][]byte{}
+	for i, doc := range strings.Split("\n"+string(data), "\n---") {
+		obj := map[string]interface{}{}
+		if err := yaml.Unmarshal([]byte(doc), &obj); err != nil {
+			return fmt.Errorf("document %d: %v", i, err)
+		}
+		metadata, ok := obj["metadata"].(map[string]interface{})
+		if !ok {
+			metadata = map[string]interface{}{}
+			obj["metadata"] = metadata
+		}
+		objName, _ := metadata["name"].(string)
+		switch obj["kind"] {
+		case "Cluster":
+			name = objName
+			if old, err := readConfig(inv.store, name); err == nil {
+				// kops keeps the creation time of the cluster
+				var prev map[string]interface{}
+				if err := yaml.Unmarshal(old, &prev); err == nil {
+					prevMeta, _ := prev["metadata"].(map[string]interface{})
+					metadata["creationTimestamp"] = prevMeta["creationTimestamp"]
+				}
+			} else if inv.flag("force") == "" {
+				return errNotFound(name)
+			}
+			if metadata["creationTimestamp"] == nil {
+				metadata["creationTimestamp"] = time.Now().UTC().Format(time.RFC3339)
+			}
+			if config, err = yaml.Marshal(obj); err != nil {
+				return err
+			}
+		case "InstanceGroup":
+			if groups[objName], err = yaml.Marshal(obj); err != nil {
+				return err
+			}
+		}
+	}
+	if name == "" {
+		return fmt.Errorf("no Cluster in %s", inv.flag("f", "filename"))
+	}
+
+	if err := os.MkdirAll(groupDir(inv.store, name), 0700); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(configPath(inv.store, name), config, 0600); err != nil {
+		return err
+	}
+	for group, doc := range groups {
+		if err := ioutil.WriteFile(filepath.Join(groupDir(inv.store, name), group), doc, 0600); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// updateCluster previews the changes to the cloud, and applies them with
+// --yes
+func updateCluster(inv invocation) error {
+	name := inv.clusterName()
+	want, err := desired(inv.store, name)
+	if err != nil {
+		return err
+	}
+	have, err := applied(inv.store, name)
+	if err != nil {
+		return err
+	}
+
+	plan := planChanges(name, have, want)
+	if inv.flag("yes") == "" {
+		if plan == "" {
+			fmt.Fprintln(inv.out, "No changes need to be applied")
+			return nil
+		}
+		fmt.Fprint(inv.out, plan)
+		fmt.Fprintln(inv.out, "Must specify --yes to apply changes")
+		return nil
+	}
+
+	data, err := json.Marshal(want)
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(cloudPath(inv.store, name), data, 0600); err != nil {
+		return err
+	}
+	fmt.Fprintln(inv.out, "Cluster changes have been applied to the cloud.")
+	return nil
+}
+
+// upgradeCluster sets the Kubernetes version of the Cluster to
+// --target-version with --yes, the cloud follows with update cluster
+func upgradeCluster(inv invocation) error {
+	name := inv.clusterName()
+	data, err := readConfig(inv.store, name)
+	if err != nil {
+		return err
+	}
+	version := inv.flag("target-version")
+	if version == "" {
+		return fmt.Errorf("--target-version is required by the fake kops")
+	}
+	obj := map[string]interface{}{}
+	if err := yaml.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	spec, ok := obj["spec"].(map[string]interface{})
+	if !ok {
+		spec = map[string]interface{}{}
+		obj["spec"] = spec
+	}
+	if spec["kubernetesVersion"] == version {
+		fmt.Fprintln(inv.out, "No upgrade required")
+		return nil
+	}
+	fmt.Fprintf(inv.out, "ITEM     PROPERTY          OLD     NEW\nCluster  KubernetesVersion %v  %s\n", spec["kubernetesVersion"], version)
+	if inv.flag("yes") == "" {
+		fmt.Fprintln(inv.out, "Must specify --yes to perform upgrade")
+		return nil
+	}
+
+	spec["kubernetesVersion"] = version
+	if data, err = yaml.Marshal(obj); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(configPath(inv.store, name), data, 0600); err != nil {
+		return err
+	}
+	fmt.Fprintln(inv.out, "Updates applied to configuration.")
+	return nil
+}
+
+// planChanges renders the kops update cluster preview of the changes from
+// have to want, empty when there are none
+func planChanges(name string, have, want *cloud) string {
+	if have == nil {
+		have = &cloud{}
+	}
+	sections := map[string]*bytes.Buffer{"create": {}, "modify": {}, "delete": {}}
+
+	if have.KubernetesVersion == "" {
+		fmt.Fprintf(sections["create"], "  Cluster/%s\n  \tKubernetesVersion   \t %s\n\n", name, want.KubernetesVersion)
+	} else if have.KubernetesVersion != want.KubernetesVersion {
+		fmt.Fprintf(sections["modify"], "  Cluster/%s\n  \tKubernetesVersion   \t %s -> %s\n\n", name, have.KubernetesVersion, want.KubernetesVersion)
+	}
+
+	var groups []string
+	for group := range want.InstanceGroups {
+		groups = append(groups, group)
+	}
+	for group := range have.InstanceGroups {
+		if _, ok := want.InstanceGroups[group]; !ok {
+			groups = append(groups, group)
+		}
+	}
+	sort.Strings(groups)
+	for _, group := range groups {
+		resource := fmt.Sprintf("  AutoscalingGroup/%s.%s\n", group, name)
+		was, hadGroup := have.InstanceGroups[group]
+		now, wantGroup := want.InstanceGroups[group]
+		switch {
+		case !wantGroup:
+			fmt.Fprintf(sections["delete"], "%s\n", resource)
+		case !hadGroup:
+			fmt.Fprintf(sections["create"], "%s  \tMinSize             \t %d\n  \tMaxSize             \t %d\n  \tInstanceType        \t %s\n\n", resource, now.MinSize, now.MaxSize, now.MachineType)
+		default:
+			var fields string
+			for _, f := range []struct{ name, old, new string }{
+				{"MinSize", fmt.Sprint(was.MinSize), fmt.Sprint(now.MinSize)},
+				{"MaxSize", fmt.Sprint(was.MaxSize), fmt.Sprint(now.MaxSize)},
+				{"InstanceType", was.MachineType, now.MachineType},
+			} {
+				if f.old != f.new {
+					fields += fmt.Sprintf("  \t%-20s\t %s -> %s\n", f.name, f.old, f.new)
+				}
+			}
+			if fields != "" {
+				fmt.Fprintf(sections["modify"], "%s%s\n", resource, fields)
+			}
+		}
+	}
+
+	var plan string
+	for _, s := range []struct{ action, header string }{
+		{"create", "Will create resources:"},
+		{"modify", "Will modify resources:"},
+		{"delete", "Will delete resources:"},
+	} {
+		if sections[s.action].Len() > 0 {
+			plan += s.header + "\n" + sections[s.action].String()
+		}
+	}
+	return plan
+}
+
+// getClusters prints the named Cluster, or all of them
+func getClusters(inv invocation) error {
+	var names []string
+	if name := inv.clusterName(); name != "" {
+		names = []string{name}
+	} else {
+		files, err := ioutil.ReadDir(inv.store.Dir)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		for _, f := range files {
+			if _, err := os.Stat(configPath(inv.store, f.Name())); f.IsDir() && err == nil {
+				names = append(names, f.Name())
+			}
+		}
+		if len(names) == 0 {
+			return fmt.Errorf("No clusters found")
+		}
+	}
+
+	var docs [][]byte
+	for _, name := range names {
+		doc, err := readConfig(inv.store, name)
+		if err != nil {
+			return err
+		}
+		docs = append(docs, doc)
+	}
+	return printDocs(inv, docs)
+}
+
+// getInstanceGroups prints the InstanceGroups of the cluster
+func getInstanceGroups(inv invocation) error {
+	name := inv.clusterName()
+	if _, err := readConfig(inv.store, name); err != nil {
+		return err
+	}
+	docs, err := readGroups(inv.store, name)
+	if err != nil {
+		return err
+	}
+	if len(docs) == 0 {
+		return fmt.Errorf("no InstanceGroup objects found")
+	}
+	return printDocs(inv, docs)
+}
+
+// printDocs prints docs in the -o format, a table of names without one. kops
+// prints a single object as is and several as a list.
+func printDocs(inv invocation, docs [][]byte) error {
+	switch inv.flag("o", "output") {
+	case "yaml":
+		fmt.Fprint(inv.out, string(bytes.Join(docs, []byte("---\n"))))
+	case "json":
+		var objs []json.RawMessage
+		for _, doc := range docs {
+			obj, err := yaml.YAMLToJSON(doc)
+			if err != nil {
+				return err
+			}
+			objs = append(objs, obj)
+		}
+		var data []byte
+		var err error
+		if len(objs) == 1 {
+			data, err = json.Marshal(objs[0])
+		} else {
+			data, err = json.Marshal(objs)
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(inv.out, string(data))
+	default:
+		fmt.Fprintln(inv.out, "NAME")
+		for _, doc := range docs {
+			var obj cluster
+			if err := yaml.Unmarshal(doc, &obj); err != nil {
+				return err
+			}
+			fmt.Fprintln(inv.out, obj.Metadata.Name)
+		}
+	}
+	return nil
+}
+
+// validateCluster reports a node per instance of the cloud, a cluster that
+// was never applied has no API server to reach
+func validateCluster(inv invocation) error {
+	name := inv.clusterName()
+	if _, err := readConfig(inv.store, name); err != nil {
+		return err
+	}
+	have, err := applied(inv.store, name)
+	if err != nil {
+		return err
+	}
+	if have == nil {
+		return fmt.Errorf("unexpected error during validation: error listing nodes: Get https://api.%s/api/v1/nodes: dial tcp: lookup api.%s: no such host", name, name)
+	}
+
+	type node struct {
+		Name     string `json:"name"`
+		Zone     string `json:"zone"`
+		Role     string `json:"role"`
+		Hostname string `json:"hostname"`
+		Status   string `json:"status"`
+	}
+	var groups []string
+	for group := range have.InstanceGroups {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+	nodes := []node{}
+	for i, group := range groups {
+		ig := have.InstanceGroups[group]
+		zone := ""
+		if len(ig.Subnets) > 0 {
+			zone = ig.Subnets[0]
+		}
+		for j := 0; j < ig.MinSize; j++ {
+			hostname := fmt.Sprintf("ip-10-0-%d-%d.compute.internal", i, j+10)
+			nodes = append(nodes, node{Name: hostname, Zone: zone, Role: strings.ToLower(ig.Role), Hostname: hostname, Status: "True"})
+		}
+	}
+
+	data, err := json.Marshal(map[string]interface{}{"nodes": nodes})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(inv.out, string(data))
+	return nil
+}
+
+// kubeconfig is what the fake kops exports, {{name}} is the cluster name
+const kubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- cluster:
+    server: https://api.{{name}}
+    insecure-skip-tls-verify: true
+  name: {{name}}
+contexts:
+- context:
+    cluster: {{name}}
+    user: {{name}}
+  name: {{name}}
+current-context: {{name}}
+preferences: {}
+users:
+- name: {{name}}
+  user:
+    token: fake
+`
+
+// exportKubecfg writes the kubeconfig of the cluster to --kubeconfig, or
+// $KUBECONFIG
+func exportKubecfg(inv invocation) error {
+	name := inv.clusterName()
+	if _, err := readConfig(inv.store, name); err != nil {
+		return err
+	}
+	path := inv.flag("kubeconfig")
+	if path == "" {
+		path = os.Getenv("KUBECONFIG")
+	}
+	if path == "" {
+		return fmt.Errorf("no kubeconfig path, set --kubeconfig or KUBECONFIG")
+	}
+	if err := ioutil.WriteFile(path, []byte(strings.Replace(kubeconfig, "{{name}}", name, -1)), 0600); err != nil {
+		return err
+	}
+	fmt.Fprintf(inv.out, "kops has set your kubectl context to %s\n", name)
+	return nil
+}
+
+// rollingUpdateCluster has nothing to replace, the cloud is always up to date
+// with what update cluster applied
+func rollingUpdateCluster(inv invocation) error {
+	name := inv.clusterName()
+	if _, err := readConfig(inv.store, name); err != nil {
+		return err
+	}
+	if have, err := applied(inv.store, name); err != nil || have == nil {
+		if err == nil {
+			err = fmt.Errorf("cluster %s has not been created, run update cluster first", name)
+		}
+		return err
+	}
+	fmt.Fprintln(inv.out, "No rolling-update required.")
+	return nil
+}
+
+// deleteCluster removes the cluster and its cloud with --yes
+func deleteCluster(inv invocation) error {
+	name := inv.clusterName()
+	if _, err := readConfig(inv.store, name); err != nil {
+		return err
+	}
+	if inv.flag("yes") == "" {
+		fmt.Fprintln(inv.out, "Must specify --yes to delete cluster")
+		return nil
+	}
+	if err := os.RemoveAll(clusterDir(inv.store, name)); err != nil {
+		return err
+	}
+	fmt.Fprintf(inv.out, "Deleted cluster: %q\n", name)
+	return nil
+}
@@ -0,0 +1,68 @@
+package kopstest
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseArgs(t *testing.T) {
+	inv := parseArgs([]string{"replace", "cluster", "-f", "manifest.yaml", "--state=file:///state", "--force", "-o", "json"})
+	if !reflect.DeepEqual(inv.words, []string{"replace", "cluster"}) {
+		t.Errorf("unexpected words %v", inv.words)
+	}
+	expected := map[string]string{"f": "manifest.yaml", "state": "file:///state", "force": "true", "o": "json"}
+	if !reflect.DeepEqual(inv.flags, expected) {
+		t.Errorf("expected flags %v got %v", expected, inv.flags)
+	}
+}
+
+func TestFakeMain(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kopstest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	store, err := NewStateStore(filepath.Join(dir, "state"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = store.Script(Step{Command: "get cluster", ExitCode: 1, Stderr: "error: Throttling: Rate exceeded\n", Times: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		args     []string
+		exitCode int
+		stderr   string
+	}{
+		{[]string{"get", "cluster", "--name=test.example.com"}, 1, "KOPS_STATE_STORE"},
+		{[]string{"get", "cluster", "--name=test.example.com", "--state=s3://state"}, 1, "file://"},
+		{[]string{"get", "cluster", "--name=test.example.com", "--state=" + store.URL()}, 1, "Throttling"},
+		{[]string{"get", "cluster", "--name=test.example.com", "--state=" + store.URL()}, 1, `cluster not found "test.example.com"`},
+		{[]string{"get", "clusters", "--state=" + store.URL()}, 1, "No clusters found"},
+		{[]string{"frobnicate", "cluster", "--state=" + store.URL()}, 1, "unknown command"},
+		{[]string{"version"}, 0, ""},
+	}
+
+	for _, test := range tests {
+		var stdout, stderr bytes.Buffer
+		exitCode := Main(test.args, &stdout, &stderr)
+		if exitCode != test.exitCode || !strings.Contains(stderr.String(), test.stderr) {
+			t.Errorf("%v: expected exit code %d and %q got %d and %q", test.args, test.exitCode, test.stderr, exitCode, stderr.String())
+		}
+	}
+
+	calls, err := store.Calls()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(calls) != 4 || calls[0] != "get cluster --name=test.example.com --state="+store.URL() {
+		t.Errorf("expected the commands on the state store to be recorded got %q", calls)
+	}
+}
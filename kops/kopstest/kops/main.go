@@ -0,0 +1,12 @@
+// The fake kops of kopstest, see kopstest.Build
+package main
+
+import (
+	"os"
+
+	"github.com/infobloxopen/cluster-operator/kops/kopstest"
+)
+
+func main() {
+	os.Exit(kopstest.Main(os.Args[1:], os.Stdout, os.Stderr))
+}
@@ -0,0 +1,163 @@
+package kopstest
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Files of the fake next to the clusters in the state store, kops never
+// looks at files in the root of a state store
+const (
+	scriptFile = ".fakekops-script.json"
+	callsFile  = ".fakekops-calls"
+	lockFile   = ".fakekops.lock"
+)
+
+// Step scripts the kops commands it matches. A step with neither ExitCode,
+// Stdout nor Stderr only delays the command, the others replace it.
+type Step struct {
+	// Command is matched against the start of the command line, e.g.
+	// "update cluster" or "get cluster --state"
+	Command string `json:"command"`
+	// Delay is waited before the command runs, a delay past the kops
+	// timeout of the operator gets the command killed
+	Delay time.Duration `json:"delay,omitempty"`
+	// ExitCode, Stdout and Stderr are the outcome of the command
+	ExitCode int    `json:"exitCode,omitempty"`
+	Stdout   string `json:"stdout,omitempty"`
+	Stderr   string `json:"stderr,omitempty"`
+	// Times is how often the step applies, zero applies it to every
+	// matching command
+	Times int `json:"times,omitempty"`
+	// Used counts the commands the step applied to
+	Used int `json:"used,omitempty"`
+}
+
+// scripted is true for steps that replace the command
+func (s *Step) scripted() bool {
+	return s.ExitCode != 0 || s.Stdout != "" || s.Stderr != ""
+}
+
+// StateStore is a file state store the fake kops keeps clusters in
+type StateStore struct {
+	Dir string
+}
+
+// NewStateStore creates the state store directory dir
+func NewStateStore(dir string) (*StateStore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &StateStore{Dir: dir}, nil
+}
+
+// URL is the state store for kops --state and the Cluster spec
+func (s *StateStore) URL() string {
+	return "file://" + s.Dir
+}
+
+// Script replaces the steps the fake kops follows, the first step matching a
+// command that has not been used up applies to it
+func (s *StateStore) Script(steps ...Step) error {
+	return s.locked(func() error {
+		return s.writeScript(steps)
+	})
+}
+
+// Calls returns the command lines the fake kops ran in order, without the
+// program name
+func (s *StateStore) Calls() ([]string, error) {
+	var calls []string
+	err := s.locked(func() error {
+		data, err := ioutil.ReadFile(filepath.Join(s.Dir, callsFile))
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		for _, line := range strings.Split(strings.TrimSuffix(string(data), "\n"), "\n") {
+			calls = append(calls, line)
+		}
+		return nil
+	})
+	return calls, err
+}
+
+// record adds line to the calls and returns the step that applies to it,
+// nothing is recorded in a state store that does not exist
+func (s *StateStore) record(line string) (*Step, error) {
+	if _, err := os.Stat(s.Dir); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	var step *Step
+	err := s.locked(func() error {
+		calls, err := os.OpenFile(filepath.Join(s.Dir, callsFile), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+		if err != nil {
+			return err
+		}
+		_, err = calls.WriteString(line + "\n")
+		if closeErr := calls.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+
+		steps, err := s.readScript()
+		if err != nil {
+			return err
+		}
+		for i := range steps {
+			if !strings.HasPrefix(line, steps[i].Command) || (steps[i].Times > 0 && steps[i].Used >= steps[i].Times) {
+				continue
+			}
+			steps[i].Used++
+			step = &steps[i]
+			return s.writeScript(steps)
+		}
+		return nil
+	})
+	return step, err
+}
+
+func (s *StateStore) readScript() ([]Step, error) {
+	data, err := ioutil.ReadFile(filepath.Join(s.Dir, scriptFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var steps []Step
+	return steps, json.Unmarshal(data, &steps)
+}
+
+func (s *StateStore) writeScript(steps []Step) error {
+	data, err := json.Marshal(steps)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(s.Dir, scriptFile), data, 0600)
+}
+
+// locked runs f holding the lock of the state store, the fake kops processes
+// of concurrent reconciles share the script and calls
+func (s *StateStore) locked(f func() error) error {
+	lock, err := os.OpenFile(filepath.Join(s.Dir, lockFile), os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return err
+	}
+	defer lock.Close()
+	if err := syscall.Flock(int(lock.Fd()), syscall.LOCK_EX); err != nil {
+		return err
+	}
+	defer syscall.Flock(int(lock.Fd()), syscall.LOCK_UN)
+	return f()
+}
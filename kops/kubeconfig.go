@@ -0,0 +1,124 @@
+package kops
+
+import (
+	"gopkg.in/yaml.v2"
+)
+
+// KubeConfig is the kubeconfig written by kops export kubecfg. It models the
+// complete kubeconfig v1 schema, including exec plugins, auth providers and
+// extensions, so that nothing kops writes is lost on the way to the Secret.
+type KubeConfig struct {
+	Kind           string           `yaml:"kind,omitempty"`
+	APIVersion     string           `yaml:"apiVersion,omitempty"`
+	Preferences    Preferences      `yaml:"preferences"`
+	Clusters       []NamedCluster   `yaml:"clusters,omitempty"`
+	Users          []NamedAuthInfo  `yaml:"users,omitempty"`
+	Contexts       []NamedContext   `yaml:"contexts,omitempty"`
+	CurrentContext string           `yaml:"current-context,omitempty"`
+	Extensions     []NamedExtension `yaml:"extensions,omitempty"`
+}
+
+// Preferences holds the kubectl preferences of a kubeconfig
+type Preferences struct {
+	Colors     *bool            `yaml:"colors,omitempty"`
+	Extensions []NamedExtension `yaml:"extensions,omitempty"`
+}
+
+// NamedCluster is a cluster entry of a kubeconfig
+type NamedCluster struct {
+	Name    string  `yaml:"name"`
+	Cluster Cluster `yaml:"cluster"`
+}
+
+// Cluster holds how to reach and trust an API server
+type Cluster struct {
+	Server                   string           `yaml:"server,omitempty"`
+	TLSServerName            string           `yaml:"tls-server-name,omitempty"`
+	InsecureSkipTLSVerify    *bool            `yaml:"insecure-skip-tls-verify,omitempty"`
+	CertificateAuthority     string           `yaml:"certificate-authority,omitempty"`
+	CertificateAuthorityData string           `yaml:"certificate-authority-data,omitempty"`
+	ProxyURL                 string           `yaml:"proxy-url,omitempty"`
+	DisableCompression       *bool            `yaml:"disable-compression,omitempty"`
+	Extensions               []NamedExtension `yaml:"extensions,omitempty"`
+}
+
+// NamedAuthInfo is a user entry of a kubeconfig
+type NamedAuthInfo struct {
+	Name     string   `yaml:"name"`
+	AuthInfo AuthInfo `yaml:"user"`
+}
+
+// AuthInfo holds the credentials of a user
+type AuthInfo struct {
+	ClientCertificate     string              `yaml:"client-certificate,omitempty"`
+	ClientCertificateData string              `yaml:"client-certificate-data,omitempty"`
+	ClientKey             string              `yaml:"client-key,omitempty"`
+	ClientKeyData         string              `yaml:"client-key-data,omitempty"`
+	Token                 string              `yaml:"token,omitempty"`
+	TokenFile             string              `yaml:"tokenFile,omitempty"`
+	Impersonate           string              `yaml:"as,omitempty"`
+	ImpersonateUID        string              `yaml:"as-uid,omitempty"`
+	ImpersonateGroups     []string            `yaml:"as-groups,omitempty"`
+	ImpersonateUserExtra  map[string][]string `yaml:"as-user-extra,omitempty"`
+	Username              string              `yaml:"username,omitempty"`
+	Password              string              `yaml:"password,omitempty"`
+	AuthProvider          *AuthProviderConfig `yaml:"auth-provider,omitempty"`
+	Exec                  *ExecConfig         `yaml:"exec,omitempty"`
+	Extensions            []NamedExtension    `yaml:"extensions,omitempty"`
+}
+
+// AuthProviderConfig configures an auth provider plugin, e.g. oidc
+type AuthProviderConfig struct {
+	Name   string            `yaml:"name"`
+	Config map[string]string `yaml:"config,omitempty"`
+}
+
+// ExecConfig runs a command to get credentials, e.g. aws-iam-authenticator
+type ExecConfig struct {
+	Command            string       `yaml:"command"`
+	Args               []string     `yaml:"args,omitempty"`
+	Env                []ExecEnvVar `yaml:"env,omitempty"`
+	APIVersion         string       `yaml:"apiVersion,omitempty"`
+	InstallHint        string       `yaml:"installHint,omitempty"`
+	ProvideClusterInfo *bool        `yaml:"provideClusterInfo,omitempty"`
+	InteractiveMode    string       `yaml:"interactiveMode,omitempty"`
+}
+
+// ExecEnvVar is an environment variable set for an exec plugin
+type ExecEnvVar struct {
+	Name  string `yaml:"name"`
+	Value string `yaml:"value"`
+}
+
+// NamedContext is a context entry of a kubeconfig
+type NamedContext struct {
+	Name    string  `yaml:"name"`
+	Context Context `yaml:"context"`
+}
+
+// Context ties a cluster to a user and default namespace
+type Context struct {
+	Cluster    string           `yaml:"cluster"`
+	AuthInfo   string           `yaml:"user"`
+	Namespace  string           `yaml:"namespace,omitempty"`
+	Extensions []NamedExtension `yaml:"extensions,omitempty"`
+}
+
+// NamedExtension carries extension data, it is kept as is
+type NamedExtension struct {
+	Name      string      `yaml:"name"`
+	Extension interface{} `yaml:"extension"`
+}
+
+// ParseKubeConfig parses a kubeconfig. Fields that are not part of the model
+// are an error rather than being dropped.
+func ParseKubeConfig(data []byte) (KubeConfig, error) {
+	config := KubeConfig{}
+	err := yaml.UnmarshalStrict(data, &config)
+	return config, err
+}
+
+// Marshal returns the kubeconfig as YAML
+func (c KubeConfig) Marshal() ([]byte, error) {
+	return yaml.Marshal(c)
+}
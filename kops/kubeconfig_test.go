@@ -0,0 +1,62 @@
+package kops
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+// TestKubeConfigRoundTrip parses every kubeconfig in testdata/kubeconfig and
+// checks that marshalling it again gives the same document
+func TestKubeConfigRoundTrip(t *testing.T) {
+	samples, err := filepath.Glob(filepath.Join("testdata", "kubeconfig", "*.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(samples) == 0 {
+		t.Fatal("Expected samples in testdata/kubeconfig")
+	}
+
+	for _, sample := range samples {
+		in, err := ioutil.ReadFile(sample)
+		if err != nil {
+			t.Fatal(err)
+		}
+		config, err := ParseKubeConfig(in)
+		if err != nil {
+			t.Errorf("%s: expected no error got %v", sample, err)
+			continue
+		}
+		out, err := config.Marshal()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var expected, got interface{}
+		if err := yaml.Unmarshal(in, &expected); err != nil {
+			t.Fatal(err)
+		}
+		if err := yaml.Unmarshal(out, &got); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(expected, got) {
+			t.Errorf("%s: kubeconfig changed on round trip\ninput:\n%s\noutput:\n%s", sample, in, out)
+		}
+	}
+}
+
+func TestParseKubeConfigUnknownField(t *testing.T) {
+	in := []byte(`apiVersion: v1
+kind: Config
+users:
+- name: admin
+  user:
+    not-a-kubeconfig-field: secret
+`)
+	if _, err := ParseKubeConfig(in); err == nil {
+		t.Error("Expected error for unknown field")
+	}
+}
@@ -0,0 +1,190 @@
+package kops
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	clusteroperatorv1alpha1 "github.com/infobloxopen/cluster-operator/pkg/apis/clusteroperator/v1alpha1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// manifestAPIVersion is the kops API version of the rendered manifests
+	manifestAPIVersion = "kops.k8s.io/v1alpha2"
+	// clusterLabel ties InstanceGroups and SSHCredentials to their Cluster
+	clusterLabel = "kops.k8s.io/cluster"
+)
+
+type manifestMeta struct {
+	Name   string            `json:"name,omitempty"`
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+type manifestObject struct {
+	APIVersion string       `json:"apiVersion"`
+	Kind       string       `json:"kind"`
+	Metadata   manifestMeta `json:"metadata"`
+	Spec       interface{}  `json:"spec"`
+}
+
+// manifestClusterSpec is the kops Cluster spec with the settings the operator
+// keeps outside of spec.kops
+type manifestClusterSpec struct {
+	clusteroperatorv1alpha1.KopsClusterSpec
+	RollingUpdate *manifestRollingUpdate `json:"rollingUpdate,omitempty"`
+}
+
+// manifestRollingUpdate is the kops RollingUpdate spec
+type manifestRollingUpdate struct {
+	MaxSurge       *intstr.IntOrString `json:"maxSurge,omitempty"`
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+}
+
+// Manifest returns the kops manifest for spec. It is rendered from spec.Kops
+// when set, otherwise the deprecated spec.Config is used as is.
+//
+// spec.KubernetesVersion, when set, replaces the version of the kops Cluster
+// and labels are added to the labels of the kops Cluster.
+func Manifest(cluster clusteroperatorv1alpha1.KopsConfig, spec clusteroperatorv1alpha1.ClusterSpec, labels map[string]string) ([]byte, error) {
+	if spec.Kops == nil {
+		if spec.KubernetesVersion == "" && len(labels) == 0 {
+			return []byte(spec.Config), nil
+		}
+		return setConfigCluster(spec.Config, spec.KubernetesVersion, labels)
+	}
+	kopsSpec := *spec.Kops
+	if spec.KubernetesVersion != "" {
+		kopsSpec.Cluster.KubernetesVersion = spec.KubernetesVersion
+	}
+	return RenderManifest(cluster, kopsSpec, spec.RollingUpdate, labels)
+}
+
+// setConfigCluster sets the kubernetesVersion, when not empty, and adds labels
+// to the Cluster in the multi document manifest config, the other documents
+// are kept as they are
+func setConfigCluster(config, version string, labels map[string]string) ([]byte, error) {
+	docs := strings.Split(config, "\n---")
+	for i, doc := range docs {
+		obj := map[string]interface{}{}
+		if err := yaml.Unmarshal([]byte(doc), &obj); err != nil {
+			return nil, fmt.Errorf("kops: document %d of config: %v", i, err)
+		}
+		if obj["kind"] != "Cluster" {
+			continue
+		}
+		if version != "" {
+			spec, ok := obj["spec"].(map[string]interface{})
+			if !ok {
+				spec = map[string]interface{}{}
+				obj["spec"] = spec
+			}
+			spec["kubernetesVersion"] = version
+		}
+		if len(labels) > 0 {
+			metadata, ok := obj["metadata"].(map[string]interface{})
+			if !ok {
+				metadata = map[string]interface{}{}
+				obj["metadata"] = metadata
+			}
+			merged, ok := metadata["labels"].(map[string]interface{})
+			if !ok {
+				merged = map[string]interface{}{}
+				metadata["labels"] = merged
+			}
+			for key, value := range labels {
+				merged[key] = value
+			}
+		}
+		data, err := yaml.Marshal(obj)
+		if err != nil {
+			return nil, err
+		}
+		doc = string(data)
+		if i > 0 {
+			// the newline in front of the document went with the separator
+			doc = "\n" + doc
+		}
+		if i < len(docs)-1 {
+			// and so does the one at its end
+			doc = strings.TrimSuffix(doc, "\n")
+		}
+		docs[i] = doc
+	}
+	return []byte(strings.Join(docs, "\n---")), nil
+}
+
+// RenderManifest renders the kops Cluster, InstanceGroup and SSHCredential
+// documents for spec as a multi document YAML manifest. The surge settings of
+// rollingUpdate go into the kops Cluster, and so do clusterLabels.
+func RenderManifest(cluster clusteroperatorv1alpha1.KopsConfig, spec clusteroperatorv1alpha1.KopsSpec, rollingUpdate *clusteroperatorv1alpha1.RollingUpdateSpec, clusterLabels map[string]string) ([]byte, error) {
+	clusterSpec := manifestClusterSpec{KopsClusterSpec: spec.Cluster}
+	if clusterSpec.ConfigBase == "" {
+		clusterSpec.ConfigBase = clusteroperatorv1alpha1.ConfigBase(cluster.StateStore, cluster.Name)
+	}
+	if rollingUpdate != nil && (rollingUpdate.MaxSurge != nil || rollingUpdate.MaxUnavailable != nil) {
+		clusterSpec.RollingUpdate = &manifestRollingUpdate{
+			MaxSurge:       rollingUpdate.MaxSurge,
+			MaxUnavailable: rollingUpdate.MaxUnavailable,
+		}
+	}
+	labels := map[string]string{clusterLabel: cluster.Name}
+
+	docs := []manifestObject{{
+		APIVersion: manifestAPIVersion,
+		Kind:       "Cluster",
+		Metadata:   manifestMeta{Name: cluster.Name, Labels: clusterLabels},
+		Spec:       clusterSpec,
+	}}
+
+	for _, ig := range spec.InstanceGroups {
+		igSpec, err := instanceGroupSpec(ig)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, manifestObject{
+			APIVersion: manifestAPIVersion,
+			Kind:       "InstanceGroup",
+			Metadata:   manifestMeta{Name: ig.Name, Labels: labels},
+			Spec:       igSpec,
+		})
+	}
+
+	if spec.SSHPublicKey != "" {
+		docs = append(docs, manifestObject{
+			APIVersion: manifestAPIVersion,
+			Kind:       "SSHCredential",
+			Metadata:   manifestMeta{Labels: labels},
+			Spec:       map[string]string{"publicKey": spec.SSHPublicKey},
+		})
+	}
+
+	var manifest bytes.Buffer
+	for i, doc := range docs {
+		data, err := yaml.Marshal(doc)
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			manifest.WriteString("---\n")
+		}
+		manifest.Write(data)
+	}
+	return manifest.Bytes(), nil
+}
+
+// instanceGroupSpec returns the spec of ig, the name goes into the metadata
+func instanceGroupSpec(ig clusteroperatorv1alpha1.KopsInstanceGroupSpec) (map[string]interface{}, error) {
+	data, err := json.Marshal(ig)
+	if err != nil {
+		return nil, err
+	}
+	spec := map[string]interface{}{}
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return nil, err
+	}
+	delete(spec, "name")
+	return spec, nil
+}
@@ -0,0 +1,230 @@
+package kops
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	clusteroperatorv1alpha1 "github.com/infobloxopen/cluster-operator/pkg/apis/clusteroperator/v1alpha1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func int32Ptr(i int32) *int32 { return &i }
+
+func boolPtr(b bool) *bool { return &b }
+
+var exampleKopsSpec = clusteroperatorv1alpha1.KopsSpec{
+	Cluster: clusteroperatorv1alpha1.KopsClusterSpec{
+		API:           &clusteroperatorv1alpha1.KopsAPISpec{DNS: &clusteroperatorv1alpha1.KopsEmptySpec{}},
+		Authorization: &clusteroperatorv1alpha1.KopsAuthorizationSpec{RBAC: &clusteroperatorv1alpha1.KopsEmptySpec{}},
+		Channel:       "stable",
+		CloudLabels:   map[string]string{"Protected": "FALSE"},
+		CloudProvider: "aws",
+		EtcdClusters: []clusteroperatorv1alpha1.KopsEtcdClusterSpec{
+			{Name: "main", CPURequest: "200m", MemoryRequest: "100Mi", Members: []clusteroperatorv1alpha1.KopsEtcdMemberSpec{{Name: "a", InstanceGroup: "master-us-east-2a"}}},
+			{Name: "events", CPURequest: "100m", MemoryRequest: "100Mi", Members: []clusteroperatorv1alpha1.KopsEtcdMemberSpec{{Name: "a", InstanceGroup: "master-us-east-2a"}}},
+		},
+		IAM:                 &clusteroperatorv1alpha1.KopsIAMSpec{AllowContainerRegistry: true},
+		Kubelet:             &clusteroperatorv1alpha1.KopsKubeletSpec{AnonymousAuth: boolPtr(false)},
+		KubernetesAPIAccess: []string{"0.0.0.0/0"},
+		KubernetesVersion:   "1.16.7",
+		MasterPublicName:    "api.test.soheil.belamaric.com",
+		NetworkCIDR:         "172.17.16.0/21",
+		NetworkID:           "vpc-0a75b33895655b46a",
+		Networking:          &clusteroperatorv1alpha1.KopsNetworkingSpec{Kubenet: &clusteroperatorv1alpha1.KopsEmptySpec{}},
+		NonMasqueradeCIDR:   "100.64.0.0/10",
+		SSHAccess:           []string{"0.0.0.0/0"},
+		Subnets: []clusteroperatorv1alpha1.KopsSubnetSpec{
+			{Name: "us-east-2a", CIDR: "172.17.17.0/24", Type: "Public", Zone: "us-east-2a"},
+			{Name: "us-east-2b", CIDR: "172.17.18.0/24", Type: "Public", Zone: "us-east-2b"},
+		},
+		Topology: &clusteroperatorv1alpha1.KopsTopologySpec{
+			DNS:     &clusteroperatorv1alpha1.KopsDNSSpec{Type: "Public"},
+			Masters: "public",
+			Nodes:   "public",
+		},
+	},
+	InstanceGroups: []clusteroperatorv1alpha1.KopsInstanceGroupSpec{
+		{
+			Name:        "master-us-east-2a",
+			Role:        "Master",
+			Image:       "kope.io/k8s-1.16-debian-stretch-amd64-hvm-ebs-2020-01-17",
+			MachineType: "t2.micro",
+			MinSize:     int32Ptr(1),
+			MaxSize:     int32Ptr(1),
+			NodeLabels:  map[string]string{"kops.k8s.io/instancegroup": "master-us-east-2a"},
+			Subnets:     []string{"us-east-2a"},
+		},
+		{
+			Name:        "nodes",
+			Role:        "Node",
+			Image:       "kope.io/k8s-1.16-debian-stretch-amd64-hvm-ebs-2020-01-17",
+			MachineType: "t2.micro",
+			MinSize:     int32Ptr(2),
+			MaxSize:     int32Ptr(2),
+			NodeLabels:  map[string]string{"kops.k8s.io/instancegroup": "nodes"},
+			Subnets:     []string{"us-east-2a", "us-east-2b"},
+		},
+	},
+	SSHPublicKey: "ssh-rsa AAAAB3NzaC1yc2EAAAADAQABAAABAQC test@example.com",
+}
+
+func TestRenderManifest(t *testing.T) {
+	kc := clusteroperatorv1alpha1.KopsConfig{Name: "test.soheil.belamaric.com", StateStore: "s3://kops.state.seizadi.infoblox.com/"}
+	got, err := RenderManifest(kc, exampleKopsSpec, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	golden := filepath.Join("testdata", "manifest", "example.golden")
+	if *update {
+		if err := ioutil.WriteFile(golden, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	expected, err := ioutil.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, expected) {
+		t.Errorf("rendered manifest does not match %s\ngot:\n%s\nexpected:\n%s", golden, got, expected)
+	}
+}
+
+func TestManifestLegacyConfig(t *testing.T) {
+	spec := clusteroperatorv1alpha1.ClusterSpec{Config: "apiVersion: kops.k8s.io/v1alpha2\nkind: Cluster\n"}
+	got, err := Manifest(kopsConfig, spec, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != spec.Config {
+		t.Errorf("Expected config %q got %q", spec.Config, got)
+	}
+}
+
+func TestManifestRollingUpdate(t *testing.T) {
+	surge := intstr.FromInt(1)
+	unavailable := intstr.FromString("25%")
+	spec := clusteroperatorv1alpha1.ClusterSpec{
+		Kops:          &exampleKopsSpec,
+		RollingUpdate: &clusteroperatorv1alpha1.RollingUpdateSpec{MaxSurge: &surge, MaxUnavailable: &unavailable},
+	}
+	got, err := Manifest(kopsConfig, spec, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "  rollingUpdate:\n    maxSurge: 1\n    maxUnavailable: 25%\n"
+	if !strings.Contains(string(got), expected) {
+		t.Errorf("Expected %q in manifest got:\n%s", expected, got)
+	}
+
+	spec.RollingUpdate = &clusteroperatorv1alpha1.RollingUpdateSpec{Strategy: clusteroperatorv1alpha1.RollingUpdateAuto}
+	got, err = Manifest(kopsConfig, spec, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(got), "rollingUpdate") {
+		t.Errorf("Expected no rollingUpdate in manifest got:\n%s", got)
+	}
+}
+
+func TestManifestKubernetesVersion(t *testing.T) {
+	spec := clusteroperatorv1alpha1.ClusterSpec{Kops: &exampleKopsSpec, KubernetesVersion: "1.17.3"}
+	got, err := Manifest(kopsConfig, spec, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(got), "  kubernetesVersion: 1.17.3\n") {
+		t.Errorf("Expected kubernetesVersion 1.17.3 in manifest got:\n%s", got)
+	}
+	if exampleKopsSpec.Cluster.KubernetesVersion == "1.17.3" {
+		t.Error("Expected spec.kops to be left alone")
+	}
+
+	spec = clusteroperatorv1alpha1.ClusterSpec{
+		Config: `apiVersion: kops.k8s.io/v1alpha2
+kind: Cluster
+metadata:
+  name: test.soheil.belamaric.com
+spec:
+  kubernetesVersion: 1.16.7
+---
+apiVersion: kops.k8s.io/v1alpha2
+kind: InstanceGroup
+metadata:
+  name: nodes
+`,
+		KubernetesVersion: "1.17.3",
+	}
+	got, err = Manifest(kopsConfig, spec, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `apiVersion: kops.k8s.io/v1alpha2
+kind: Cluster
+metadata:
+  name: test.soheil.belamaric.com
+spec:
+  kubernetesVersion: 1.17.3
+---
+apiVersion: kops.k8s.io/v1alpha2
+kind: InstanceGroup
+metadata:
+  name: nodes
+`
+	if string(got) != expected {
+		t.Errorf("Expected config\n%s\ngot:\n%s", expected, got)
+	}
+}
+
+func TestManifestLabels(t *testing.T) {
+	labels := map[string]string{"cluster-operator.infobloxopen.github.com/owner-uid": "1234"}
+
+	spec := clusteroperatorv1alpha1.ClusterSpec{Kops: &exampleKopsSpec}
+	got, err := Manifest(kopsConfig, spec, labels)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "kind: Cluster\nmetadata:\n  labels:\n    cluster-operator.infobloxopen.github.com/owner-uid: \"1234\"\n  name: test.soheil.belamaric.com\n"
+	if !strings.Contains(string(got), expected) {
+		t.Errorf("Expected %q in manifest got:\n%s", expected, got)
+	}
+
+	spec = clusteroperatorv1alpha1.ClusterSpec{
+		Config: `apiVersion: kops.k8s.io/v1alpha2
+kind: Cluster
+metadata:
+  labels:
+    team: platform
+  name: test.soheil.belamaric.com
+---
+apiVersion: kops.k8s.io/v1alpha2
+kind: InstanceGroup
+metadata:
+  name: nodes
+`,
+	}
+	got, err = Manifest(kopsConfig, spec, labels)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = `apiVersion: kops.k8s.io/v1alpha2
+kind: Cluster
+metadata:
+  labels:
+    cluster-operator.infobloxopen.github.com/owner-uid: "1234"
+    team: platform
+  name: test.soheil.belamaric.com
+---
+apiVersion: kops.k8s.io/v1alpha2
+kind: InstanceGroup
+metadata:
+  name: nodes
+`
+	if string(got) != expected {
+		t.Errorf("Expected config\n%s\ngot:\n%s", expected, got)
+	}
+}
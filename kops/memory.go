@@ -0,0 +1,193 @@
+package kops
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	clusteroperatorv1alpha1 "github.com/infobloxopen/cluster-operator/pkg/apis/clusteroperator/v1alpha1"
+	"sigs.k8s.io/yaml"
+)
+
+// memoryStore keeps the manifests of the clusters in memory:// state stores.
+// It stands in for kops and the cloud in development mode, so the complete
+// reconcile runs without either, and is lost when the operator restarts.
+type memoryStore struct {
+	mu sync.Mutex
+	// clusters are keyed by state store and cluster name
+	clusters map[string]map[string]memoryCluster
+}
+
+// memoryCluster is a cluster in a memory state store
+type memoryCluster struct {
+	manifest []byte
+	metadata ClusterMetadata
+	summary  ClusterSummary
+	groups   []memoryGroup
+	// status is what kops validate cluster reports, set from the
+	// InstanceGroups the last apply found in the manifest
+	status clusteroperatorv1alpha1.KopsStatus
+}
+
+// memoryGroup is an InstanceGroup of a memory cluster
+type memoryGroup struct {
+	name    string
+	role    string
+	minSize int
+	zone    string
+}
+
+// memory is the memory state store shared by all KopsCmds of the operator
+var memory = newMemoryStore()
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{clusters: map[string]map[string]memoryCluster{}}
+}
+
+// put stores manifest as the cluster name, like kops replace cluster --force
+// it creates the cluster or replaces its spec
+func (m *memoryStore) put(store stateStore, name string, manifest []byte) error {
+	cluster, err := parseMemoryCluster(name, manifest)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	clusters := m.clusters[store.String()]
+	if clusters == nil {
+		clusters = map[string]memoryCluster{}
+		m.clusters[store.String()] = clusters
+	}
+	if old, ok := clusters[name]; ok {
+		cluster.summary.CreationTimestamp = old.summary.CreationTimestamp
+		cluster.status = old.status
+	}
+	clusters[name] = cluster
+	return nil
+}
+
+// apply brings up the nodes of the InstanceGroups of the cluster name, like
+// kops update cluster --yes, every node is ready at once
+func (m *memoryStore) apply(store stateStore, name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cluster, ok := m.clusters[store.String()][name]
+	if !ok {
+		return fmt.Errorf("kops: cluster %q not found", name)
+	}
+
+	var nodes []validationNode
+	for i, group := range cluster.groups {
+		for j := 0; j < group.minSize; j++ {
+			hostname := fmt.Sprintf("ip-10-0-%d-%d.compute.internal", i, j+10)
+			nodes = append(nodes, validationNode{Name: hostname, Zone: group.zone, Role: strings.ToLower(group.role), Hostname: hostname, Status: "True"})
+		}
+	}
+	data, err := json.Marshal(validationCluster{Nodes: nodes})
+	if err != nil {
+		return err
+	}
+	status, err := ParseValidation(data)
+	if err != nil {
+		return err
+	}
+	cluster.status = status
+	m.clusters[store.String()][name] = cluster
+	return nil
+}
+
+func (m *memoryStore) get(store stateStore, name string) (memoryCluster, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cluster, ok := m.clusters[store.String()][name]
+	return cluster, ok
+}
+
+func (m *memoryStore) delete(store stateStore, name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.clusters[store.String()], name)
+}
+
+// list returns the clusters in store ordered by name
+func (m *memoryStore) list(store stateStore) []ClusterSummary {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var summaries []ClusterSummary
+	for _, cluster := range m.clusters[store.String()] {
+		summaries = append(summaries, cluster.summary)
+	}
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].Name < summaries[j].Name })
+	return summaries
+}
+
+// parseMemoryCluster reads what kops would report about the cluster name from
+// its manifest, which must hold the kops Cluster. Its InstanceGroups are
+// ordered by name.
+func parseMemoryCluster(name string, manifest []byte) (memoryCluster, error) {
+	var cluster *memoryCluster
+	var groups []memoryGroup
+	for i, doc := range strings.Split("\n"+string(manifest), "\n---") {
+		var obj struct {
+			Kind     string          `json:"kind"`
+			Metadata ClusterMetadata `json:"metadata"`
+		}
+		if err := yaml.Unmarshal([]byte(doc), &obj); err != nil {
+			return memoryCluster{}, fmt.Errorf("kops: document %d of manifest: %v", i, err)
+		}
+		switch obj.Kind {
+		case "Cluster":
+			var c struct {
+				Spec struct {
+					CloudProvider     string `json:"cloudProvider"`
+					KubernetesVersion string `json:"kubernetesVersion"`
+				} `json:"spec"`
+			}
+			if err := yaml.Unmarshal([]byte(doc), &c); err != nil {
+				return memoryCluster{}, fmt.Errorf("kops: document %d of manifest: %v", i, err)
+			}
+			if obj.Metadata.Name != name {
+				return memoryCluster{}, fmt.Errorf("kops: manifest is for cluster %q, not %q", obj.Metadata.Name, name)
+			}
+			cluster = &memoryCluster{
+				manifest: manifest,
+				metadata: obj.Metadata,
+				summary: ClusterSummary{
+					Name:              name,
+					CreationTimestamp: time.Now().UTC().Truncate(time.Second),
+					CloudProvider:     c.Spec.CloudProvider,
+					KubernetesVersion: c.Spec.KubernetesVersion,
+				},
+			}
+		case "InstanceGroup":
+			var ig struct {
+				Spec struct {
+					Role    string   `json:"role"`
+					MinSize *int     `json:"minSize"`
+					Subnets []string `json:"subnets"`
+				} `json:"spec"`
+			}
+			if err := yaml.Unmarshal([]byte(doc), &ig); err != nil {
+				return memoryCluster{}, fmt.Errorf("kops: document %d of manifest: %v", i, err)
+			}
+			group := memoryGroup{name: obj.Metadata.Name, role: ig.Spec.Role}
+			if ig.Spec.MinSize != nil {
+				group.minSize = *ig.Spec.MinSize
+			}
+			if len(ig.Spec.Subnets) > 0 {
+				group.zone = ig.Spec.Subnets[0]
+			}
+			groups = append(groups, group)
+		}
+	}
+	if cluster == nil {
+		return memoryCluster{}, fmt.Errorf("kops: manifest of cluster %q has no Cluster", name)
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].name < groups[j].name })
+	cluster.groups = groups
+	return *cluster, nil
+}
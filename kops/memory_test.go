@@ -0,0 +1,114 @@
+package kops
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	clusteroperatorv1alpha1 "github.com/infobloxopen/cluster-operator/pkg/apis/clusteroperator/v1alpha1"
+	"github.com/infobloxopen/cluster-operator/utils"
+)
+
+func TestMemoryStateStore(t *testing.T) {
+	k, cleanup := newTestKops(t)
+	defer cleanup()
+
+	m := &mockExecutor{}
+	k.executor = m
+	k.memory = newMemoryStore()
+	kc := clusteroperatorv1alpha1.KopsConfig{Name: "test.example.com", StateStore: "memory://dev/"}
+	spec := fakeClusterSpec(2)
+	labels := map[string]string{"owner": "test"}
+
+	if err := k.ReplaceCluster(context.TODO(), kc, spec, labels); err == nil || !strings.Contains(err.Error(), "development mode") {
+		t.Fatalf("expected the memory state store to need development mode got %v", err)
+	}
+	k.devMode = true
+
+	if exists, err := k.GetCluster(context.TODO(), kc); err != nil || exists {
+		t.Errorf("expected a missing cluster got %t, %v", exists, err)
+	}
+	if _, err := k.GetClusterMetadata(context.TODO(), kc); !utils.IsNotFound(err) {
+		t.Errorf("expected NotFound got %v", err)
+	}
+
+	if err := k.ReplaceCluster(context.TODO(), kc, spec, labels); err != nil {
+		t.Fatal(err)
+	}
+	if exists, err := k.GetCluster(context.TODO(), kc); err != nil || !exists {
+		t.Errorf("expected the cluster got %t, %v", exists, err)
+	}
+	metadata, err := k.GetClusterMetadata(context.TODO(), kc)
+	if err != nil || metadata.Name != kc.Name || metadata.Labels["owner"] != "test" {
+		t.Errorf("expected the metadata of the cluster got %+v, %v", metadata, err)
+	}
+
+	// the manifest in the state store is the one written, there is no drift
+	desired, err := Manifest(kc, spec, labels)
+	if err != nil {
+		t.Fatal(err)
+	}
+	actual, err := k.GetManifest(context.TODO(), kc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if paths, err := DiffManifests(desired, actual); err != nil || len(paths) != 0 {
+		t.Errorf("expected no drift got %v, %v", paths, err)
+	}
+
+	// the nodes of the instance groups come up once the cluster is updated
+	if status, err := k.ValidateCluster(context.TODO(), kc); err != nil || len(status.Nodes) != 0 {
+		t.Errorf("expected no nodes before the update got %+v, %v", status, err)
+	}
+	if plan, err := k.PlanCluster(context.TODO(), kc); err != nil || len(plan.Changes) != 0 {
+		t.Errorf("expected an empty plan got %+v, %v", plan, err)
+	}
+	if err := k.UpdateCluster(context.TODO(), kc); err != nil {
+		t.Fatal(err)
+	}
+	status, err := k.ValidateCluster(context.TODO(), kc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(status.Nodes) != 3 || status.Nodes[0].Zone != "us-east-2a" || status.Nodes[0].Role != "master" {
+		t.Errorf("expected 1 master and 2 nodes got %+v", status.Nodes)
+	}
+	if len(status.NodeCounts) != 2 || status.NodeCounts[1].Role != "node" || status.NodeCounts[1].Ready != 2 {
+		t.Errorf("unexpected node counts %+v", status.NodeCounts)
+	}
+	if err := k.RollingUpdateCluster(context.TODO(), kc, nil); err != nil {
+		t.Error(err)
+	}
+	if config, err := k.GetKubeConfig(context.TODO(), kc); err != nil || len(config.Clusters) != 0 {
+		t.Errorf("expected no kubeconfig got %+v, %v", config, err)
+	}
+
+	// replacing the spec keeps the nodes until the next update
+	if err := k.ReplaceCluster(context.TODO(), kc, fakeClusterSpec(3), labels); err != nil {
+		t.Fatal(err)
+	}
+	if status, _ := k.ValidateCluster(context.TODO(), kc); len(status.Nodes) != 3 {
+		t.Errorf("expected the nodes to be kept got %+v", status.Nodes)
+	}
+
+	summaries, err := k.ListClusters(context.TODO(), "memory://dev")
+	if err != nil || len(summaries) != 1 {
+		t.Fatalf("expected 1 cluster got %v, %v", summaries, err)
+	}
+	if s := summaries[0]; s.Name != kc.Name || s.CloudProvider != "aws" || s.KubernetesVersion != "1.15.7" || s.CreationTimestamp.IsZero() {
+		t.Errorf("unexpected summary %+v", s)
+	}
+	if summaries, _ := k.ListClusters(context.TODO(), "memory://other"); len(summaries) != 0 {
+		t.Errorf("expected no clusters in another state store got %v", summaries)
+	}
+
+	if err := k.DeleteCluster(context.TODO(), kc); err != nil {
+		t.Fatal(err)
+	}
+	if exists, err := k.GetCluster(context.TODO(), kc); err != nil || exists {
+		t.Errorf("expected the cluster to be deleted got %t, %v", exists, err)
+	}
+	if len(m.cmds) != 0 {
+		t.Errorf("expected kops not to run got %v", m.cmds)
+	}
+}
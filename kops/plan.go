@@ -0,0 +1,134 @@
+package kops
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	clusteroperatorv1alpha1 "github.com/infobloxopen/cluster-operator/pkg/apis/clusteroperator/v1alpha1"
+)
+
+const (
+	// planNoChanges is printed by kops update cluster when the cloud is in sync
+	planNoChanges = "No changes need to be applied"
+	// planNeedsYes closes the preview of kops update cluster run without --yes
+	planNeedsYes = "Must specify --yes to apply changes"
+	// planHashLength is the number of hex digits of the plan hash
+	planHashLength = 16
+)
+
+// planSections maps the section headers of the kops update cluster preview
+// to the action of the resources listed in them
+var planSections = map[string]string{
+	"Will create resources:": clusteroperatorv1alpha1.KopsChangeCreate,
+	"Will modify resources:": clusteroperatorv1alpha1.KopsChangeModify,
+	"Will delete resources:": clusteroperatorv1alpha1.KopsChangeDelete,
+}
+
+// ParsePlan converts the preview printed by kops update cluster without --yes
+// into a KopsPlan. Resources are listed as "  Type/Name" under the section of
+// their action, followed by one "  \tField\tValue" line per field, modified
+// fields have a value of "old -> new". Log lines are ignored.
+func ParsePlan(data []byte) (clusteroperatorv1alpha1.KopsPlan, error) {
+	plan := clusteroperatorv1alpha1.KopsPlan{}
+
+	var (
+		action string
+		change *clusteroperatorv1alpha1.KopsChange
+		seen   bool
+	)
+	flush := func() {
+		if change != nil {
+			plan.Changes = append(plan.Changes, *change)
+			change = nil
+		}
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == "":
+			flush()
+		case line == trimmed:
+			// unindented lines are section headers, log lines or the summary
+			flush()
+			action = planSections[trimmed]
+			if action != "" || strings.Contains(trimmed, planNoChanges) || strings.Contains(trimmed, planNeedsYes) {
+				seen = true
+			}
+		case action == "":
+			// indented lines outside of a section, e.g. the upgrade banner
+		case strings.HasPrefix(strings.TrimLeft(line, " "), "\t"):
+			if change == nil {
+				return plan, errors.New("kops: field without resource in plan: " + trimmed)
+			}
+			change.Fields = append(change.Fields, parsePlanField(action, line))
+		default:
+			flush()
+			change = &clusteroperatorv1alpha1.KopsChange{Action: action}
+			change.Type, change.Name = parsePlanResource(trimmed)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return plan, err
+	}
+	flush()
+
+	if !seen {
+		return plan, errors.New("kops: no plan in update cluster output")
+	}
+	if len(plan.Changes) > 0 {
+		hash, err := planHash(plan.Changes)
+		if err != nil {
+			return plan, err
+		}
+		plan.Hash = hash
+	}
+	return plan, nil
+}
+
+// parsePlanResource splits "Type/Name", resources without a type are
+// returned with the whole line as name
+func parsePlanResource(resource string) (string, string) {
+	if i := strings.Index(resource, "/"); i > 0 {
+		return resource[:i], resource[i+1:]
+	}
+	return "", resource
+}
+
+// parsePlanField parses a "  \tField\tValue" line
+func parsePlanField(action, line string) clusteroperatorv1alpha1.KopsFieldChange {
+	parts := strings.SplitN(strings.TrimLeft(line, " \t"), "\t", 2)
+	field := clusteroperatorv1alpha1.KopsFieldChange{Name: strings.TrimSpace(parts[0])}
+	if len(parts) < 2 {
+		return field
+	}
+	value := strings.TrimSpace(parts[1])
+	if action == clusteroperatorv1alpha1.KopsChangeModify {
+		if i := strings.Index(value, " -> "); i >= 0 {
+			field.Old = strings.TrimSpace(value[:i])
+			field.New = strings.TrimSpace(value[i+len(" -> "):])
+			return field
+		}
+	}
+	field.New = value
+	return field
+}
+
+// planHash identifies the changes of a plan, it is what approvals refer to
+func planHash(changes []clusteroperatorv1alpha1.KopsChange) (string, error) {
+	data, err := json.Marshal(changes)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])[:planHashLength], nil
+}
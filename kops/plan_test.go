@@ -0,0 +1,77 @@
+package kops
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestParsePlan compares the parsed output of every kops update cluster
+// sample in testdata/plan with its .golden file, run with -update to rewrite
+// the golden files after a change to the parser.
+func TestParsePlan(t *testing.T) {
+	samples, err := filepath.Glob(filepath.Join("testdata", "plan", "*.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(samples) == 0 {
+		t.Fatal("Expected samples in testdata/plan")
+	}
+
+	for _, sample := range samples {
+		data, err := ioutil.ReadFile(sample)
+		if err != nil {
+			t.Fatal(err)
+		}
+		plan, err := ParsePlan(data)
+		if err != nil {
+			t.Errorf("%s: expected no error got %v", sample, err)
+			continue
+		}
+		got, err := json.MarshalIndent(plan, "", "  ")
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, '\n')
+
+		golden := strings.TrimSuffix(sample, ".txt") + ".golden"
+		if *update {
+			if err := ioutil.WriteFile(golden, got, 0644); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		expected, err := ioutil.ReadFile(golden)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, expected) {
+			t.Errorf("%s: parsed plan does not match %s\ngot:\n%s\nexpected:\n%s", sample, golden, got, expected)
+		}
+	}
+}
+
+func TestParsePlanInvalid(t *testing.T) {
+	for _, data := range []string{"", "error reading cluster configuration: cluster not found", "Will modify resources:\n  \tMinSize\t 2 -> 3\n"} {
+		if _, err := ParsePlan([]byte(data)); err == nil {
+			t.Errorf("Expected error for %q", data)
+		}
+	}
+}
+
+func TestParsePlanHash(t *testing.T) {
+	a, err := ParsePlan([]byte("Will modify resources:\n  AutoscalingGroup/nodes\n  \tMinSize\t 2 -> 3\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ParsePlan([]byte("Will modify resources:\n  AutoscalingGroup/nodes\n  \tMinSize\t 2 -> 4\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Hash == "" || a.Hash == b.Hash {
+		t.Errorf("Expected different hashes for different plans got %q and %q", a.Hash, b.Hash)
+	}
+}
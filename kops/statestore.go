@@ -0,0 +1,128 @@
+package kops
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/infobloxopen/cluster-operator/utils"
+)
+
+// Schemes of the state stores the operator treats specially, the others are
+// passed to kops as they are
+const (
+	SchemeS3     = "s3"
+	SchemeGCS    = "gs"
+	SchemeFile   = "file"
+	SchemeMemory = "memory"
+)
+
+// stateStore is a parsed kops state store URL
+type stateStore struct {
+	scheme string
+	// path is everything after the scheme, the bucket and prefix or the
+	// directory of a file state store
+	path string
+}
+
+// parseStateStore parses and normalizes the state store URL s. Trailing
+// slashes are dropped and the directories of file state stores are cleaned,
+// they must be absolute.
+func parseStateStore(s string) (stateStore, error) {
+	parts := strings.SplitN(s, "://", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return stateStore{}, fmt.Errorf("kops: state store %q is not a URL like s3://bucket or file:///path", s)
+	}
+	store := stateStore{scheme: parts[0], path: parts[1]}
+
+	switch store.scheme {
+	case SchemeFile:
+		if !filepath.IsAbs(store.path) {
+			return stateStore{}, fmt.Errorf("kops: file state store %q must be an absolute path", s)
+		}
+		store.path = filepath.Clean(store.path)
+	default:
+		store.path = strings.TrimPrefix(path.Clean("/"+store.path), "/")
+		if store.path == "" {
+			return stateStore{}, fmt.Errorf("kops: state store %q has no bucket or name", s)
+		}
+	}
+	return store, nil
+}
+
+// String is the normalized URL of the state store
+func (s stateStore) String() string {
+	return s.scheme + "://" + s.path
+}
+
+// flag is the kops --state flag for the state store
+func (s stateStore) flag() string {
+	return "--state=" + s.String()
+}
+
+// NormalizeStateStore returns the normalized URL of the state store s, two
+// URLs of the same state store normalize to the same string
+func NormalizeStateStore(s string) (string, error) {
+	store, err := parseStateStore(s)
+	if err != nil {
+		return "", err
+	}
+	return store.String(), nil
+}
+
+// stateStore parses the state store of a kops command, the memory state store
+// is only available in development mode
+func (k *KopsCmd) stateStore(s string) (stateStore, error) {
+	store, err := parseStateStore(s)
+	if err != nil {
+		return store, err
+	}
+	if store.scheme == SchemeMemory && !k.devMode {
+		return store, fmt.Errorf("kops: memory state store %s is only available in development mode", store)
+	}
+	return store, nil
+}
+
+// clusterExists reports whether the cluster name is in store without running
+// kops. It is known for the file and memory state stores only, ok is false
+// for the others.
+func (k *KopsCmd) clusterExists(store stateStore, name string) (exists, ok bool, err error) {
+	switch store.scheme {
+	case SchemeMemory:
+		_, exists := k.memory.get(store, name)
+		return exists, true, nil
+	case SchemeFile:
+		// kops keeps the cluster spec in <state store>/<cluster>/config
+		_, err := os.Stat(filepath.Join(store.path, name, "config"))
+		if os.IsNotExist(err) {
+			return false, true, nil
+		}
+		if err != nil {
+			return false, true, err
+		}
+		return true, true, nil
+	}
+	return false, false, nil
+}
+
+// checkCluster returns a NotFound error, like the one kops fails with, when
+// store is known not to hold the cluster name
+func (k *KopsCmd) checkCluster(store stateStore, name string) error {
+	exists, ok, err := k.clusterExists(store, name)
+	if err != nil || !ok || exists {
+		return err
+	}
+	return notFound(store, name)
+}
+
+// notFound is the error kops fails with for the missing cluster name
+func notFound(store stateStore, name string) error {
+	return &utils.CommandError{
+		ExitCode: -1,
+		Stderr:   fmt.Sprintf("cluster not found %q", name),
+		Reason:   utils.ReasonNotFound,
+		Err:      fmt.Errorf("cluster %s is not in state store %s", name, store),
+	}
+}
@@ -0,0 +1,104 @@
+package kops
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	clusteroperatorv1alpha1 "github.com/infobloxopen/cluster-operator/pkg/apis/clusteroperator/v1alpha1"
+	"github.com/infobloxopen/cluster-operator/utils"
+)
+
+func TestNormalizeStateStore(t *testing.T) {
+	tests := []struct {
+		store    string
+		expected string
+		err      bool
+	}{
+		{"s3://state", "s3://state", false},
+		{"s3://state/", "s3://state", false},
+		{"s3://state/prefix//", "s3://state/prefix", false},
+		{"gs://state", "gs://state", false},
+		{"file:///var/lib/kops/", "file:///var/lib/kops", false},
+		{"file:///var/lib/../lib/kops", "file:///var/lib/kops", false},
+		{"memory://dev", "memory://dev", false},
+		{"file://kops", "", true},
+		{"file://", "", true},
+		{"s3://", "", true},
+		{"s3:///", "", true},
+		{"/var/lib/kops", "", true},
+		{"", "", true},
+	}
+
+	for _, test := range tests {
+		store, err := NormalizeStateStore(test.store)
+		if (err != nil) != test.err {
+			t.Errorf("%q: expected error %t got %v", test.store, test.err, err)
+			continue
+		}
+		if store != test.expected {
+			t.Errorf("%q: expected %q got %q", test.store, test.expected, store)
+		}
+	}
+}
+
+func TestFileStateStore(t *testing.T) {
+	k, cleanup := newTestKops(t)
+	defer cleanup()
+	dir, err := ioutil.TempDir("", "state")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	m := &mockExecutor{}
+	k.executor = m
+	kc := clusteroperatorv1alpha1.KopsConfig{Name: "test.example.com", StateStore: "file://" + dir + "/store/"}
+
+	// nothing runs kops against a state store that does not exist yet
+	if summaries, err := k.ListClusters(context.TODO(), kc.StateStore); err != nil || len(summaries) != 0 {
+		t.Errorf("expected no clusters got %v, %v", summaries, err)
+	}
+	if exists, err := k.GetCluster(context.TODO(), kc); err != nil || exists {
+		t.Errorf("expected a missing cluster got %t, %v", exists, err)
+	}
+	if _, err := k.GetManifest(context.TODO(), kc); !utils.IsNotFound(err) {
+		t.Errorf("expected NotFound got %v", err)
+	}
+	if err := k.DeleteCluster(context.TODO(), kc); err != nil {
+		t.Errorf("expected no error got %v", err)
+	}
+	if len(m.cmds) != 0 {
+		t.Fatalf("expected no commands got %v", m.cmds)
+	}
+
+	spec := clusteroperatorv1alpha1.ClusterSpec{Config: "kind: Cluster\nmetadata:\n  name: test.example.com\n"}
+	if err := k.ReplaceCluster(context.TODO(), kc, spec, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "store")); err != nil {
+		t.Errorf("expected the state store to be created: %v", err)
+	}
+	if argv := m.cmds[0].Args; argv[4] != "--state=file://"+dir+"/store" {
+		t.Errorf("expected the normalized state store got %v", argv)
+	}
+
+	// what kops would have written
+	if err := os.MkdirAll(filepath.Join(dir, "store", kc.Name), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "store", kc.Name, "config"), nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if exists, err := k.GetCluster(context.TODO(), kc); err != nil || !exists {
+		t.Errorf("expected the cluster got %t, %v", exists, err)
+	}
+	if err := k.DeleteCluster(context.TODO(), kc); err != nil {
+		t.Errorf("expected no error got %v", err)
+	}
+	if len(m.cmds) != 2 || m.cmds[1].Args[0] != "delete" {
+		t.Errorf("expected kops delete cluster got %v", m.cmds)
+	}
+}
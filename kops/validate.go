@@ -0,0 +1,111 @@
+package kops
+
+import (
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strconv"
+
+	clusteroperatorv1alpha1 "github.com/infobloxopen/cluster-operator/pkg/apis/clusteroperator/v1alpha1"
+)
+
+// validationCluster is the document printed by kops validate cluster -o json
+type validationCluster struct {
+	Failures []validationError `json:"failures"`
+	Nodes    []validationNode  `json:"nodes"`
+}
+
+type validationError struct {
+	Kind    string `json:"type"`
+	Name    string `json:"name"`
+	Message string `json:"message"`
+	// InstanceGroup is only set by newer kops versions for failures of
+	// type InstanceGroup
+	InstanceGroup *struct {
+		Spec struct {
+			Role    string `json:"role"`
+			MinSize *int   `json:"minSize"`
+		} `json:"spec"`
+	} `json:"instanceGroup"`
+}
+
+type validationNode struct {
+	Name     string `json:"name"`
+	Zone     string `json:"zone"`
+	Role     string `json:"role"`
+	Hostname string `json:"hostname"`
+	Status   string `json:"status"`
+}
+
+// failureInstanceGroup is the Type of failures about instance groups
+const failureInstanceGroup = "InstanceGroup"
+
+// notEnoughNodes matches the message kops uses for instance groups that are
+// short of ready nodes, e.g. InstanceGroup "nodes" did not have enough nodes 1 vs 2
+var notEnoughNodes = regexp.MustCompile(`did not have enough nodes (\d+) vs (\d+)`)
+
+// ParseValidation converts the output of kops validate cluster -o json into
+// a KopsStatus. Nodes are counted per role and instance groups reported as
+// short of nodes are listed with their ready and expected node counts.
+func ParseValidation(data []byte) (clusteroperatorv1alpha1.KopsStatus, error) {
+	status := clusteroperatorv1alpha1.KopsStatus{}
+
+	var v validationCluster
+	if err := json.Unmarshal(data, &v); err != nil {
+		return status, err
+	}
+
+	counts := map[string]*clusteroperatorv1alpha1.KopsNodeCount{}
+	for _, n := range v.Nodes {
+		status.Nodes = append(status.Nodes, clusteroperatorv1alpha1.KopsNode{
+			Name:     n.Name,
+			Zone:     n.Zone,
+			Role:     n.Role,
+			Hostname: n.Hostname,
+			Status:   n.Status,
+		})
+		c, ok := counts[n.Role]
+		if !ok {
+			c = &clusteroperatorv1alpha1.KopsNodeCount{Role: n.Role}
+			counts[n.Role] = c
+		}
+		c.Total++
+		if n.Status == "True" {
+			c.Ready++
+		}
+	}
+	for _, c := range counts {
+		status.NodeCounts = append(status.NodeCounts, *c)
+	}
+	sort.Slice(status.NodeCounts, func(i, j int) bool {
+		return status.NodeCounts[i].Role < status.NodeCounts[j].Role
+	})
+
+	for _, f := range v.Failures {
+		status.Failures = append(status.Failures, clusteroperatorv1alpha1.KopsFailure{
+			Type:    f.Kind,
+			Name:    f.Name,
+			Message: f.Message,
+		})
+		if f.Kind == failureInstanceGroup {
+			status.InstanceGroups = append(status.InstanceGroups, instanceGroup(f))
+		}
+	}
+
+	return status, nil
+}
+
+func instanceGroup(f validationError) clusteroperatorv1alpha1.KopsInstanceGroup {
+	ig := clusteroperatorv1alpha1.KopsInstanceGroup{Name: f.Name}
+	if m := notEnoughNodes.FindStringSubmatch(f.Message); m != nil {
+		ig.Ready, _ = strconv.Atoi(m[1])
+		ig.Expected, _ = strconv.Atoi(m[2])
+	}
+	if f.InstanceGroup != nil {
+		ig.Role = f.InstanceGroup.Spec.Role
+		if f.InstanceGroup.Spec.MinSize != nil {
+			ig.Expected = *f.InstanceGroup.Spec.MinSize
+		}
+	}
+	return ig
+}
@@ -0,0 +1,66 @@
+package kops
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+// TestParseValidation compares the parsed output of every kops validate
+// sample in testdata/validate with its .golden file, run with -update to
+// rewrite the golden files after a change to the parser.
+func TestParseValidation(t *testing.T) {
+	samples, err := filepath.Glob(filepath.Join("testdata", "validate", "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(samples) == 0 {
+		t.Fatal("Expected samples in testdata/validate")
+	}
+
+	for _, sample := range samples {
+		data, err := ioutil.ReadFile(sample)
+		if err != nil {
+			t.Fatal(err)
+		}
+		status, err := ParseValidation(data)
+		if err != nil {
+			t.Errorf("%s: expected no error got %v", sample, err)
+			continue
+		}
+		got, err := json.MarshalIndent(status, "", "  ")
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, '\n')
+
+		golden := strings.TrimSuffix(sample, ".json") + ".golden"
+		if *update {
+			if err := ioutil.WriteFile(golden, got, 0644); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		expected, err := ioutil.ReadFile(golden)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, expected) {
+			t.Errorf("%s: parsed status does not match %s\ngot:\n%s\nexpected:\n%s", sample, golden, got, expected)
+		}
+	}
+}
+
+func TestParseValidationInvalid(t *testing.T) {
+	for _, data := range []string{"", "Validation failed: unexpected error during validation", "{"} {
+		if _, err := ParseValidation([]byte(data)); err == nil {
+			t.Errorf("Expected error for %q", data)
+		}
+	}
+}
@@ -0,0 +1,10 @@
+package apis
+
+import (
+	"github.com/infobloxopen/cluster-operator/pkg/apis/clusteroperator/v1alpha2"
+)
+
+func init() {
+	// Register the types with the Scheme so the components can map objects to GroupVersionKinds and back
+	AddToSchemes = append(AddToSchemes, v1alpha2.SchemeBuilder.AddToScheme)
+}
@@ -1,7 +1,9 @@
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // KopsCluster defines the settings passed to Kops
@@ -25,6 +27,23 @@ type KopsFailure struct {
 	Message string `json:"message,omitempty"`
 }
 
+// KopsInstanceGroup reports an instance group that kops found short of ready nodes
+// +k8s:openapi-gen=true
+type KopsInstanceGroup struct {
+	Name     string `json:"name,omitempty"`
+	Role     string `json:"role,omitempty"`
+	Ready    int    `json:"ready"`
+	Expected int    `json:"expected"`
+}
+
+// KopsNodeCount counts the nodes of one role and how many of them are ready
+// +k8s:openapi-gen=true
+type KopsNodeCount struct {
+	Role  string `json:"role,omitempty"`
+	Ready int    `json:"ready"`
+	Total int    `json:"total"`
+}
+
 // KopsNodes resports about the cluster nodes when ready
 // +k8s:openapi-gen=true
 type KopsNode struct {
@@ -35,68 +54,220 @@ type KopsNode struct {
 	Status   string `json:"status,omitempty"`
 }
 
-// KubeConfig holds the config to access the cluster
+// KopsStatus defines the status of the Kops Cluster
+// +k8s:openapi-gen=true
+type KopsStatus struct {
+	Failures []KopsFailure `json:"failures,omitempty"`
+	Nodes    []KopsNode    `json:"nodes,omitempty"`
+	// NodeCounts sums up Nodes per role
+	NodeCounts []KopsNodeCount `json:"node_counts,omitempty"`
+	// InstanceGroups lists the instance groups without enough ready nodes
+	InstanceGroups []KopsInstanceGroup `json:"instance_groups,omitempty"`
+	// LastValidated is when kops validate last returned this result
+	LastValidated *metav1.Time `json:"last_validated,omitempty"`
+}
+
+// KopsPlan is the change set kops update cluster would apply to the cloud
 // +k8s:openapi-gen=true
-type KubeConfig struct {
-	APIVersion     string           `yaml:"apiVersion,omitempty" json:"apiVersion,omitempty"`
-	Clusters       []ClusterConfigs `yaml:"clusters,omitempty" json:"clusters,omitempty"`
-	ContextConfigs []ContextConfigs `yaml:"contexts,omitempty" json:"contexts,omitempty"`
-	CurrentContext string           `yaml:"current-context,omitempty" json:"current-context,omitempty"`
-	Kind           string           `yaml:"kind,omitempty" json:"kind,omitempty"`
-	Preferences    struct {
-	} `yaml:"preferences,omitempty" json:"preferences,omitempty"`
-	Users []Users `yaml:"users,omitempty" json:"users,omitempty"`
+type KopsPlan struct {
+	// Hash identifies the change set, the plan is applied once the
+	// ApprovedPlanAnnotation of the Cluster is set to it
+	Hash string `json:"hash"`
+	// Changes are the cloud resources kops would create, modify or delete
+	Changes []KopsChange `json:"changes,omitempty"`
 }
 
-// ClusterConfig defines attributes for kubeconfig cluster
+// These are the actions of a KopsChange
+const (
+	KopsChangeCreate = "Create"
+	KopsChangeModify = "Modify"
+	KopsChangeDelete = "Delete"
+)
+
+// KopsChange is a cloud resource kops would change
 // +k8s:openapi-gen=true
-type ClusterConfig struct {
-	CertificateAuthorityData string `yaml:"certificate-authority-data,omitempty" json:"certificate-authority-data,omitempty"`
-	Server                   string `yaml:"server,omitempty" json:"server,omitempty"`
+type KopsChange struct {
+	// Action is Create, Modify or Delete
+	Action string `json:"action"`
+	// Type is the kops task type, e.g. AutoscalingGroup
+	Type string `json:"type"`
+	Name string `json:"name"`
+	// Fields are the fields kops would set or change
+	Fields []KopsFieldChange `json:"fields,omitempty"`
 }
 
-// ClusterConfigs defines a collection of cluster configs
+// KopsFieldChange is a field of a planned resource, Old is only set when an
+// existing resource is modified
 // +k8s:openapi-gen=true
-type ClusterConfigs struct {
-	ClusterConfigs ClusterConfig `yaml:"cluster,omitempty" json:"cluster,omitempty"`
-	Name           string        `yaml:"name,omitempty" json:"name,omitempty"`
+type KopsFieldChange struct {
+	Name string `json:"name"`
+	Old  string `json:"old,omitempty"`
+	New  string `json:"new,omitempty"`
 }
 
-// ContextConfig defines attributes for kubeconfig
+// ApplyPolicy controls when changes written to the state store are applied
+// to the cloud
+type ApplyPolicy string
+
+const (
+	// ApplyAutomatic applies changes right away
+	ApplyAutomatic ApplyPolicy = "Automatic"
+	// ApplyOnApproval reports the planned changes in status.pendingPlan and
+	// applies them once ApprovedPlanAnnotation is set to the plan hash
+	ApplyOnApproval ApplyPolicy = "OnApproval"
+)
+
+// ApprovedPlanAnnotation approves the pending plan whose hash it is set to
+const ApprovedPlanAnnotation = "cluster-operator.infobloxopen.github.com/approved-plan"
+
+// SkipKopsDeleteAnnotation set to "true" on a Cluster being deleted removes it
+// without deleting its kops cluster, e.g. when its credentials are gone. The
+// kops cluster and its cloud resources are left behind.
+const SkipKopsDeleteAnnotation = "cluster-operator.infobloxopen.github.com/skip-kops-delete"
+
+// Labels on the kops Clusters the operator writes, the reaper only removes
+// kops clusters that carry them
+const (
+	// OwnerUIDLabel is the UID of the Cluster the kops cluster belongs to
+	OwnerUIDLabel = "cluster-operator.infobloxopen.github.com/owner-uid"
+	// OwnerNamespaceLabel is the namespace of that Cluster
+	OwnerNamespaceLabel = "cluster-operator.infobloxopen.github.com/owner-namespace"
+	// OwnerNameLabel is the name of that Cluster
+	OwnerNameLabel = "cluster-operator.infobloxopen.github.com/owner-name"
+)
+
+// Keys of the Secret spec.credentialsRef points at. A Secret holds static
+// keys, a role to assume, or both, the keys are then used to assume the role.
+const (
+	// CredentialsAccessKeyID is the AWS access key ID
+	CredentialsAccessKeyID = "accessKeyID"
+	// CredentialsSecretAccessKey is the AWS secret access key
+	CredentialsSecretAccessKey = "secretAccessKey"
+	// CredentialsSessionToken is the session token of temporary keys
+	CredentialsSessionToken = "sessionToken"
+	// CredentialsRoleARN is the ARN of the IAM role kops assumes
+	CredentialsRoleARN = "roleARN"
+	// CredentialsExternalID is the external ID the role is assumed with
+	CredentialsExternalID = "externalID"
+)
+
+// RollingUpdateStrategy controls when nodes are replaced to pick up changes
+type RollingUpdateStrategy string
+
+const (
+	// RollingUpdateAuto replaces nodes once changes are applied to the cloud
+	RollingUpdateAuto RollingUpdateStrategy = "Auto"
+	// RollingUpdateManual replaces nodes once ApprovedRollingUpdateAnnotation
+	// is set to the generation in status.observedGeneration
+	RollingUpdateManual RollingUpdateStrategy = "Manual"
+	// RollingUpdateNever leaves nodes alone, changes are only picked up by
+	// new instances
+	RollingUpdateNever RollingUpdateStrategy = "Never"
+)
+
+// ApprovedRollingUpdateAnnotation approves the rolling update of the
+// generation it is set to when the RollingUpdateStrategy is Manual
+const ApprovedRollingUpdateAnnotation = "cluster-operator.infobloxopen.github.com/approved-rolling-update"
+
+// RollingUpdateSpec controls how nodes are replaced (kops rolling-update).
+// Unset fields keep the kops defaults.
 // +k8s:openapi-gen=true
-type ContextConfig struct {
-	Cluster string `yaml:"cluster,omitempty" json:"cluster,omitempty"`
-	User    string `yaml:"user,omitempty" json:"user,omitempty"`
+type RollingUpdateSpec struct {
+	// Strategy defaults to Auto
+	Strategy RollingUpdateStrategy `json:"strategy,omitempty"`
+	// MasterInterval is the time to wait between restarting masters
+	MasterInterval *metav1.Duration `json:"masterInterval,omitempty"`
+	// NodeInterval is the time to wait between restarting nodes
+	NodeInterval *metav1.Duration `json:"nodeInterval,omitempty"`
+	// InstanceGroups limits the update to the named instance groups
+	InstanceGroups []string `json:"instanceGroups,omitempty"`
+	// InstanceGroupRoles limits the update to instance groups of the roles,
+	// e.g. Master or Node
+	InstanceGroupRoles []string `json:"instanceGroupRoles,omitempty"`
+	// CloudOnly replaces instances without draining or validating them, for
+	// clusters whose API cannot be reached
+	CloudOnly bool `json:"cloudOnly,omitempty"`
+	// DrainTimeout is the maximum time to wait for a node to drain, it
+	// needs kops 1.19 or later
+	DrainTimeout *metav1.Duration `json:"drainTimeout,omitempty"`
+	// FailOnValidateError stops the update when the cluster does not
+	// validate between instances
+	FailOnValidateError bool `json:"failOnValidateError,omitempty"`
+	// ValidationTimeout is the maximum time to wait for the cluster to
+	// validate after an instance is replaced
+	ValidationTimeout *metav1.Duration `json:"validationTimeout,omitempty"`
+	// ValidateCount is the number of times the cluster must validate in a
+	// row after an instance is replaced, it needs kops 1.18 or later
+	ValidateCount *int32 `json:"validateCount,omitempty"`
+	// MaxSurge is the number of extra instances created while an instance
+	// group is replaced, it needs kops 1.18 or later
+	MaxSurge *intstr.IntOrString `json:"maxSurge,omitempty"`
+	// MaxUnavailable is the number of instances of an instance group that
+	// may be replaced at the same time, it needs kops 1.18 or later
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
 }
 
-// ContextConfigs defines a list of contexts for a kubeconfig
+// MaintenanceWindow is a weekly time range disruptive operations, like
+// replacing nodes, may run in
 // +k8s:openapi-gen=true
-type ContextConfigs struct {
-	ContextConfigs ContextConfig `yaml:"context,omitempty" json:"context,omitempty"`
-	Name           string        `yaml:"name" json:"name"`
+type MaintenanceWindow struct {
+	// Days are the weekdays the window opens on, e.g. Saturday, it opens
+	// every day when empty
+	Days []string `json:"days,omitempty"`
+	// Start is the time of day the window opens, HH:MM in 24 hour format
+	Start string `json:"start"`
+	// Duration is how long the window stays open
+	Duration metav1.Duration `json:"duration"`
+	// TimeZone is the IANA time zone of Start, e.g. America/Los_Angeles,
+	// it defaults to UTC
+	TimeZone string `json:"timeZone,omitempty"`
 }
 
-// User defines a user for a cluster in a kubeconfig
+// KubernetesUpgrade is an upgrade of the Kubernetes version of a cluster
 // +k8s:openapi-gen=true
-type User struct {
-	ClientCertificateData string `yaml:"client-certificate-data,omitempty" json:"client-certificate-data,omitempty"`
-	ClientKeyData         string `yaml:"client-key-data,omitempty" json:"client-key-data,omitempty"`
-	Password              string `yaml:"password,omitempty" json:"password,omitempty"`
-	Username              string `yaml:"username,omitempty" json:"username,omitempty"`
+type KubernetesUpgrade struct {
+	// From is the version the cluster ran before the upgrade
+	From string `json:"from"`
+	// To is the version the cluster is upgraded to
+	To string `json:"to"`
+	// StartTime is when the new version was written to the state store
+	StartTime metav1.Time `json:"startTime"`
+	// CompletionTime is when the new version was verified on the API
+	// server and all nodes
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
 }
 
-// Users is a list of 'User' that defines acces through a kubeconfig
+// DriftPolicy controls what is done about drift found while the cluster is
+// Ready
+type DriftPolicy string
+
+const (
+	// DriftReport reports drift on the Drifted condition and in status.drift
+	DriftReport DriftPolicy = "Report"
+	// DriftCorrect reports drift and applies the spec again to correct it
+	DriftCorrect DriftPolicy = "Correct"
+)
+
+// Drift is how the kops state store and the cloud differ from the spec
 // +k8s:openapi-gen=true
-type Users struct {
-	Users User   `yaml:"user,omitempty" json:"user,omitempty"`
-	Name  string `yaml:"name,omitempty" json:"name,omitempty"`
+type Drift struct {
+	// LastChecked is when the cluster was last checked for drift
+	LastChecked metav1.Time `json:"lastChecked"`
+	// StateStore are the paths where the kops manifest in the state store
+	// differs from the spec, e.g. InstanceGroup/nodes.spec.maxSize
+	StateStore []string `json:"stateStore,omitempty"`
+	// Cloud are the cloud resources kops update cluster would change
+	Cloud []string `json:"cloud,omitempty"`
 }
 
-// KopsStatus defines the status of the Kops Cluster
+// CredentialsReference points at the Secret with the cloud credentials of a
+// cluster
 // +k8s:openapi-gen=true
-type KopsStatus struct {
-	Failures []KopsFailure `json:"failures,omitempty"`
-	Nodes    []KopsNode    `json:"nodes,omitempty"`
+type CredentialsReference struct {
+	// Name of the Secret
+	Name string `json:"name"`
+	// Namespace of the Secret, the namespace of the Cluster when empty
+	Namespace string `json:"namespace,omitempty"`
 }
 
 // ClusterSpec defines the desired state of Cluster
@@ -109,28 +280,77 @@ type ClusterSpec struct {
 	// definition.
 	// Cannot be updated.
 	Name string `json:"name,omitempty"`
-	// Kops Cluster Config
+	// Kops Cluster Config as a multi document kops manifest
+	// Deprecated: use Kops, Config is only used when Kops is not set
 	Config string `json:"config,omitempty"`
+	// Kops is the kops Cluster and InstanceGroups, the operator renders the
+	// kops manifest from it
+	Kops *KopsSpec `json:"kops,omitempty"`
 	// Kops Cluster Config
 	KopsConfig KopsConfig `json:"kops_config,omitempty"`
+	// ApplyPolicy controls when changes are applied to the cloud, it
+	// defaults to Automatic
+	ApplyPolicy ApplyPolicy `json:"applyPolicy,omitempty"`
+	// RollingUpdate controls how nodes are replaced to pick up changes
+	RollingUpdate *RollingUpdateSpec `json:"rollingUpdate,omitempty"`
+	// MaintenanceWindows limit when nodes are replaced and changes are
+	// applied to a running cluster, they are not limited when empty
+	MaintenanceWindows []MaintenanceWindow `json:"maintenanceWindows,omitempty"`
+	// KubernetesVersion is the Kubernetes version of the cluster, it can
+	// only be raised one minor version at a time. It takes precedence over
+	// the version in Kops and Config.
+	KubernetesVersion string `json:"kubernetesVersion,omitempty"`
+	// DriftPolicy controls whether drift from the spec is only reported or
+	// also corrected, it defaults to Report
+	DriftPolicy DriftPolicy `json:"driftPolicy,omitempty"`
+	// CredentialsRef is the Secret with the cloud credentials of the
+	// cluster, the operator credentials are used when it is not set
+	CredentialsRef *CredentialsReference `json:"credentialsRef,omitempty"`
+	// ProviderRef is the ClusterProvider with the state store, DNS zone
+	// and cloud defaults of the cluster, the operator settings are used
+	// when it is not set. Cannot be updated.
+	ProviderRef *corev1.LocalObjectReference `json:"providerRef,omitempty"`
 }
 
-// PodPhase is a label for the condition of a pod at the current time.
+// ClusterPhase is a label for the step of the cluster life cycle the operator is in.
 type ClusterPhase string
 
-// These are the valid statuses of pods.
+// These are the valid phases of a cluster. Each phase is handled by its own
+// step in the controller, which persists the next phase before moving on.
 const (
-	// PodPending means the Cluster has not been configured yet. We configure
-	// desired state in state store and transistion to ClusterUpdate
+	// ClusterPending means the Cluster has not been picked up yet. Defaults
+	// and the finalizer are added and it transitions to ClusterConfiguring
 	ClusterPending ClusterPhase = "Pending"
-	// ClusterUpdate means the Cluster has been configured by the system, but cluster
-	// has not been completely been configured
+	// ClusterConfiguring means the desired state is being written to the
+	// state store (kops replace)
+	ClusterConfiguring ClusterPhase = "Configuring"
+	// ClusterApplying means the state store config is being applied to the
+	// cloud (kops update)
+	ClusterApplying ClusterPhase = "Applying"
+	// ClusterRollingUpdate means nodes are being replaced to pick up changes
+	// that need new instances (kops rolling-update)
+	ClusterRollingUpdate ClusterPhase = "RollingUpdate"
+	// ClusterValidating is set when we are waiting for the Cluster to come up
+	// so that it can be used (kops validate)
+	ClusterValidating ClusterPhase = "Validating"
+	// ClusterReady means that Cluster has been provisioned and can be used
+	ClusterReady ClusterPhase = "Ready"
+	// ClusterDeleting means the Cluster is being removed from the cloud
+	ClusterDeleting ClusterPhase = "Deleting"
+	// ClusterFailed means the last step failed with an error that is not
+	// expected to go away on retry. The step is retried periodically and
+	// immediately on a spec change
+	ClusterFailed ClusterPhase = "Failed"
+)
+
+// Phases written by earlier versions of the operator, they are mapped to the
+// phases above when a Cluster is reconciled.
+const (
+	// Deprecated: replaced by ClusterApplying
 	ClusterUpdate ClusterPhase = "Update"
-	// Setup is set when we are waiting for  the Cluster to come up
-	// so that it can be used.
+	// Deprecated: replaced by ClusterValidating
 	ClusterSetup ClusterPhase = "Setup"
-	// ClusterDone means that Cluster has been provisioned
-	// and can be used
+	// Deprecated: replaced by ClusterReady
 	ClusterDone ClusterPhase = "Done"
 )
 
@@ -138,12 +358,36 @@ const (
 // +k8s:openapi-gen=true
 type ClusterStatus struct {
 	// Phase represents the state of the cluster provisioning
-	// It transitions from PENDING to DONE, we might add more states for infrastructure provisioning
+	// It transitions from Pending to Ready, see ClusterPhase
 	Phase ClusterPhase `json:"phase,omitempty"`
+	// ObservedGeneration is the spec generation the operator last started to apply
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// FailedPhase is the phase that failed when Phase is Failed, it is retried from there
+	FailedPhase ClusterPhase `json:"failedPhase,omitempty"`
+	// Message is a human readable description of the last failure
+	Message string `json:"message,omitempty"`
+	// Conditions report on the aspects of the cluster, see ConditionType
+	Conditions []Condition `json:"conditions,omitempty"`
 	// Kops Cluster Status
 	KopsStatus KopsStatus `json:"kops_status,omitempty"`
 	Validated  bool       `json:"validated,omitempty"`
-	KubeConfig KubeConfig `json:"kubeconfig,omitempty"`
+	// KubeconfigSecretRef names the Secret in the namespace of the Cluster
+	// holding the kubeconfig of the cluster
+	KubeconfigSecretRef *corev1.LocalObjectReference `json:"kubeconfigSecretRef,omitempty"`
+	// PendingPlan is the change set waiting for approval when the
+	// ApplyPolicy is OnApproval
+	PendingPlan *KopsPlan `json:"pendingPlan,omitempty"`
+	// NextMaintenanceWindow is when the next maintenance window opens
+	NextMaintenanceWindow *metav1.Time `json:"nextMaintenanceWindow,omitempty"`
+	// KubernetesVersion is the Kubernetes version last verified on the API
+	// server and all nodes
+	KubernetesVersion string `json:"kubernetesVersion,omitempty"`
+	// Upgrade is the Kubernetes upgrade in progress
+	Upgrade *KubernetesUpgrade `json:"upgrade,omitempty"`
+	// UpgradeHistory are the last completed Kubernetes upgrades, oldest first
+	UpgradeHistory []KubernetesUpgrade `json:"upgradeHistory,omitempty"`
+	// Drift is the result of the last drift check
+	Drift *Drift `json:"drift,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
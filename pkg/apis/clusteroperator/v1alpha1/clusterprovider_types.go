@@ -0,0 +1,56 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ClusterProviderSpec defines where and how the Clusters referencing the
+// ClusterProvider are built
+// +k8s:openapi-gen=true
+type ClusterProviderSpec struct {
+	// StateStore is the kops state store, e.g. s3://bucket
+	StateStore string `json:"stateStore"`
+	// DNSZone is the zone the kops cluster names are in
+	DNSZone string `json:"dnsZone"`
+	// Region is the cloud region kops works in
+	Region string `json:"region,omitempty"`
+	// CredentialsRef is the Secret with the cloud credentials of the
+	// Clusters that do not reference one themselves, its namespace is
+	// required
+	CredentialsRef *CredentialsReference `json:"credentialsRef,omitempty"`
+	// AllowedNamespaces are the namespaces whose Clusters may reference the
+	// ClusterProvider, * allows all. Referencing it grants the use of its
+	// credentials Secret. Without them only Clusters that may reference
+	// the credentials Secret themselves may use it, a ClusterProvider
+	// without credentialsRef is open to all namespaces.
+	AllowedNamespaces []string `json:"allowedNamespaces,omitempty"`
+	// InstanceGroups are the instance groups of the Clusters whose
+	// spec.kops has none
+	InstanceGroups []KopsInstanceGroupSpec `json:"instanceGroups,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ClusterProvider holds the state store, DNS zone and cloud defaults of the
+// Clusters of an environment or team
+// +kubebuilder:resource:path=clusterproviders,scope=Cluster
+// +k8s:openapi-gen=true
+type ClusterProvider struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ClusterProviderSpec `json:"spec,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ClusterProviderList contains a list of ClusterProvider
+type ClusterProviderList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterProvider `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ClusterProvider{}, &ClusterProviderList{})
+}
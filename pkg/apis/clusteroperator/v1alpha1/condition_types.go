@@ -0,0 +1,105 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ConditionType is the name of an aspect of the cluster the operator reports on
+type ConditionType string
+
+// These are the conditions maintained on a Cluster. Ready sums up the others,
+// it is what `kubectl wait --for=condition=Ready` should be used with.
+const (
+	// ConditionConfigApplied is True when the spec was written to the state store
+	ConditionConfigApplied ConditionType = "ConfigApplied"
+	// ConditionCloudResourcesReady is True when the state store config was
+	// applied to the cloud
+	ConditionCloudResourcesReady ConditionType = "CloudResourcesReady"
+	// ConditionValidated is True when kops validate reports a healthy cluster
+	ConditionValidated ConditionType = "Validated"
+	// ConditionRollingUpdateComplete is True when no node is left to replace
+	ConditionRollingUpdateComplete ConditionType = "RollingUpdateComplete"
+	// ConditionReady is True when the cluster matches the spec and can be used
+	ConditionReady ConditionType = "Ready"
+	// ConditionDeleting is True while the cluster is removed from the cloud
+	ConditionDeleting ConditionType = "Deleting"
+	// ConditionPlanReady is True while a plan waits for approval, only
+	// maintained when the ApplyPolicy is OnApproval
+	ConditionPlanReady ConditionType = "PlanReady"
+	// ConditionUpgraded is False while the cluster is upgraded to a new
+	// Kubernetes version and True once the version is verified
+	ConditionUpgraded ConditionType = "Upgraded"
+	// ConditionDrifted is True when the state store or the cloud differ
+	// from the spec, it is checked while the cluster is Ready
+	ConditionDrifted ConditionType = "Drifted"
+)
+
+// ConditionStatus is the status of a condition, one of True, False or Unknown
+type ConditionStatus string
+
+// These are the valid condition statuses
+const (
+	ConditionTrue    ConditionStatus = "True"
+	ConditionFalse   ConditionStatus = "False"
+	ConditionUnknown ConditionStatus = "Unknown"
+)
+
+// Condition describes one aspect of the observed state of a Cluster. It
+// follows the layout of the upstream metav1.Condition.
+// +k8s:openapi-gen=true
+type Condition struct {
+	// Type of the condition, see ConditionType
+	Type ConditionType `json:"type"`
+	// Status of the condition, one of True, False or Unknown
+	Status ConditionStatus `json:"status"`
+	// ObservedGeneration is the spec generation the condition was set for
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// LastTransitionTime is when the status last changed
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+	// Reason is a CamelCase code for the last transition, for failures it
+	// is derived from the kops error, e.g. Unauthorized or Throttled
+	Reason string `json:"reason,omitempty"`
+	// Message is a human readable description of the last transition
+	Message string `json:"message,omitempty"`
+}
+
+// FindCondition returns the condition of type t or nil if there is none
+func FindCondition(conditions []Condition, t ConditionType) *Condition {
+	for i := range conditions {
+		if conditions[i].Type == t {
+			return &conditions[i]
+		}
+	}
+	return nil
+}
+
+// IsConditionTrue reports whether the condition of type t is present and True
+func IsConditionTrue(conditions []Condition, t ConditionType) bool {
+	c := FindCondition(conditions, t)
+	return c != nil && c.Status == ConditionTrue
+}
+
+// SetCondition adds c to conditions or replaces the condition of the same
+// type. LastTransitionTime is only moved when the status changes, it is set to
+// now if c does not carry one.
+func SetCondition(conditions *[]Condition, c Condition) {
+	existing := FindCondition(*conditions, c.Type)
+	if existing == nil {
+		if c.LastTransitionTime.IsZero() {
+			c.LastTransitionTime = metav1.Now()
+		}
+		*conditions = append(*conditions, c)
+		return
+	}
+
+	if existing.Status != c.Status {
+		existing.Status = c.Status
+		existing.LastTransitionTime = c.LastTransitionTime
+		if existing.LastTransitionTime.IsZero() {
+			existing.LastTransitionTime = metav1.Now()
+		}
+	}
+	existing.ObservedGeneration = c.ObservedGeneration
+	existing.Reason = c.Reason
+	existing.Message = c.Message
+}
@@ -0,0 +1,234 @@
+package v1alpha1
+
+import (
+	"encoding/json"
+
+	"github.com/infobloxopen/cluster-operator/pkg/apis/clusteroperator/v1alpha2"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+)
+
+// ConvertTo converts this Cluster to the Hub version (v1alpha2)
+func (src *Cluster) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1alpha2.Cluster)
+	dst.ObjectMeta = src.ObjectMeta
+
+	dst.Spec.Name = src.Spec.Name
+	dst.Spec.Config = src.Spec.Config
+	dst.Spec.Kops = nil
+	if src.Spec.Kops != nil {
+		dst.Spec.Kops = &v1alpha2.KopsSpec{}
+		if err := convertJSON(src.Spec.Kops, dst.Spec.Kops); err != nil {
+			return err
+		}
+	}
+	dst.Spec.KopsConfig = v1alpha2.KopsConfig{
+		Name:       src.Spec.KopsConfig.Name,
+		StateStore: src.Spec.KopsConfig.StateStore,
+		Masters: v1alpha2.NodePool{
+			Count:       src.Spec.KopsConfig.MasterCount,
+			MachineType: src.Spec.KopsConfig.MasterEc2,
+		},
+		Workers: v1alpha2.NodePool{
+			Count:       src.Spec.KopsConfig.WorkerCount,
+			MachineType: src.Spec.KopsConfig.WorkerEc2,
+		},
+		VPC:   src.Spec.KopsConfig.Vpc,
+		Zones: src.Spec.KopsConfig.Zones,
+	}
+	dst.Spec.ApplyPolicy = v1alpha2.ApplyPolicy(src.Spec.ApplyPolicy)
+	dst.Spec.KubernetesVersion = src.Spec.KubernetesVersion
+	dst.Spec.DriftPolicy = v1alpha2.DriftPolicy(src.Spec.DriftPolicy)
+	dst.Spec.CredentialsRef = nil
+	if src.Spec.CredentialsRef != nil {
+		ref := v1alpha2.CredentialsReference(*src.Spec.CredentialsRef)
+		dst.Spec.CredentialsRef = &ref
+	}
+	dst.Spec.ProviderRef = nil
+	if src.Spec.ProviderRef != nil {
+		ref := *src.Spec.ProviderRef
+		dst.Spec.ProviderRef = &ref
+	}
+	dst.Spec.MaintenanceWindows = nil
+	for _, w := range src.Spec.MaintenanceWindows {
+		dst.Spec.MaintenanceWindows = append(dst.Spec.MaintenanceWindows, v1alpha2.MaintenanceWindow(w))
+	}
+	dst.Spec.RollingUpdate = nil
+	if src.Spec.RollingUpdate != nil {
+		dst.Spec.RollingUpdate = &v1alpha2.RollingUpdateSpec{}
+		if err := convertJSON(src.Spec.RollingUpdate, dst.Spec.RollingUpdate); err != nil {
+			return err
+		}
+	}
+
+	dst.Status.Phase = v1alpha2.ClusterPhase(src.Status.Phase)
+	dst.Status.ObservedGeneration = src.Status.ObservedGeneration
+	dst.Status.FailedPhase = v1alpha2.ClusterPhase(src.Status.FailedPhase)
+	dst.Status.Message = src.Status.Message
+	dst.Status.Conditions = nil
+	for _, c := range src.Status.Conditions {
+		dst.Status.Conditions = append(dst.Status.Conditions, v1alpha2.Condition{
+			Type:               v1alpha2.ConditionType(c.Type),
+			Status:             v1alpha2.ConditionStatus(c.Status),
+			ObservedGeneration: c.ObservedGeneration,
+			LastTransitionTime: c.LastTransitionTime,
+			Reason:             c.Reason,
+			Message:            c.Message,
+		})
+	}
+	dst.Status.KopsStatus = v1alpha2.KopsStatus{LastValidated: src.Status.KopsStatus.LastValidated}
+	for _, f := range src.Status.KopsStatus.Failures {
+		dst.Status.KopsStatus.Failures = append(dst.Status.KopsStatus.Failures, v1alpha2.KopsFailure(f))
+	}
+	for _, n := range src.Status.KopsStatus.Nodes {
+		dst.Status.KopsStatus.Nodes = append(dst.Status.KopsStatus.Nodes, v1alpha2.KopsNode(n))
+	}
+	for _, n := range src.Status.KopsStatus.NodeCounts {
+		dst.Status.KopsStatus.NodeCounts = append(dst.Status.KopsStatus.NodeCounts, v1alpha2.KopsNodeCount(n))
+	}
+	for _, ig := range src.Status.KopsStatus.InstanceGroups {
+		dst.Status.KopsStatus.InstanceGroups = append(dst.Status.KopsStatus.InstanceGroups, v1alpha2.KopsInstanceGroup(ig))
+	}
+	dst.Status.Validated = src.Status.Validated
+	dst.Status.KubeconfigSecretRef = src.Status.KubeconfigSecretRef
+	dst.Status.NextMaintenanceWindow = src.Status.NextMaintenanceWindow
+	dst.Status.KubernetesVersion = src.Status.KubernetesVersion
+	dst.Status.Upgrade = nil
+	if src.Status.Upgrade != nil {
+		upgrade := v1alpha2.KubernetesUpgrade(*src.Status.Upgrade)
+		dst.Status.Upgrade = &upgrade
+	}
+	dst.Status.UpgradeHistory = nil
+	for _, u := range src.Status.UpgradeHistory {
+		dst.Status.UpgradeHistory = append(dst.Status.UpgradeHistory, v1alpha2.KubernetesUpgrade(u))
+	}
+	dst.Status.Drift = nil
+	if src.Status.Drift != nil {
+		drift := v1alpha2.Drift(*src.Status.Drift)
+		dst.Status.Drift = &drift
+	}
+	dst.Status.PendingPlan = nil
+	if src.Status.PendingPlan != nil {
+		dst.Status.PendingPlan = &v1alpha2.KopsPlan{}
+		if err := convertJSON(src.Status.PendingPlan, dst.Status.PendingPlan); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ConvertFrom converts from the Hub version (v1alpha2) to this version
+func (dst *Cluster) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1alpha2.Cluster)
+	dst.ObjectMeta = src.ObjectMeta
+
+	dst.Spec.Name = src.Spec.Name
+	dst.Spec.Config = src.Spec.Config
+	dst.Spec.Kops = nil
+	if src.Spec.Kops != nil {
+		dst.Spec.Kops = &KopsSpec{}
+		if err := convertJSON(src.Spec.Kops, dst.Spec.Kops); err != nil {
+			return err
+		}
+	}
+	dst.Spec.KopsConfig = KopsConfig{
+		Name:        src.Spec.KopsConfig.Name,
+		MasterCount: src.Spec.KopsConfig.Masters.Count,
+		MasterEc2:   src.Spec.KopsConfig.Masters.MachineType,
+		WorkerCount: src.Spec.KopsConfig.Workers.Count,
+		WorkerEc2:   src.Spec.KopsConfig.Workers.MachineType,
+		StateStore:  src.Spec.KopsConfig.StateStore,
+		Vpc:         src.Spec.KopsConfig.VPC,
+		Zones:       src.Spec.KopsConfig.Zones,
+	}
+	dst.Spec.ApplyPolicy = ApplyPolicy(src.Spec.ApplyPolicy)
+	dst.Spec.KubernetesVersion = src.Spec.KubernetesVersion
+	dst.Spec.DriftPolicy = DriftPolicy(src.Spec.DriftPolicy)
+	dst.Spec.CredentialsRef = nil
+	if src.Spec.CredentialsRef != nil {
+		ref := CredentialsReference(*src.Spec.CredentialsRef)
+		dst.Spec.CredentialsRef = &ref
+	}
+	dst.Spec.ProviderRef = nil
+	if src.Spec.ProviderRef != nil {
+		ref := *src.Spec.ProviderRef
+		dst.Spec.ProviderRef = &ref
+	}
+	dst.Spec.MaintenanceWindows = nil
+	for _, w := range src.Spec.MaintenanceWindows {
+		dst.Spec.MaintenanceWindows = append(dst.Spec.MaintenanceWindows, MaintenanceWindow(w))
+	}
+	dst.Spec.RollingUpdate = nil
+	if src.Spec.RollingUpdate != nil {
+		dst.Spec.RollingUpdate = &RollingUpdateSpec{}
+		if err := convertJSON(src.Spec.RollingUpdate, dst.Spec.RollingUpdate); err != nil {
+			return err
+		}
+	}
+
+	dst.Status.Phase = ClusterPhase(src.Status.Phase)
+	dst.Status.ObservedGeneration = src.Status.ObservedGeneration
+	dst.Status.FailedPhase = ClusterPhase(src.Status.FailedPhase)
+	dst.Status.Message = src.Status.Message
+	dst.Status.Conditions = nil
+	for _, c := range src.Status.Conditions {
+		dst.Status.Conditions = append(dst.Status.Conditions, Condition{
+			Type:               ConditionType(c.Type),
+			Status:             ConditionStatus(c.Status),
+			ObservedGeneration: c.ObservedGeneration,
+			LastTransitionTime: c.LastTransitionTime,
+			Reason:             c.Reason,
+			Message:            c.Message,
+		})
+	}
+	dst.Status.KopsStatus = KopsStatus{LastValidated: src.Status.KopsStatus.LastValidated}
+	for _, f := range src.Status.KopsStatus.Failures {
+		dst.Status.KopsStatus.Failures = append(dst.Status.KopsStatus.Failures, KopsFailure(f))
+	}
+	for _, n := range src.Status.KopsStatus.Nodes {
+		dst.Status.KopsStatus.Nodes = append(dst.Status.KopsStatus.Nodes, KopsNode(n))
+	}
+	for _, n := range src.Status.KopsStatus.NodeCounts {
+		dst.Status.KopsStatus.NodeCounts = append(dst.Status.KopsStatus.NodeCounts, KopsNodeCount(n))
+	}
+	for _, ig := range src.Status.KopsStatus.InstanceGroups {
+		dst.Status.KopsStatus.InstanceGroups = append(dst.Status.KopsStatus.InstanceGroups, KopsInstanceGroup(ig))
+	}
+	dst.Status.Validated = src.Status.Validated
+	dst.Status.KubeconfigSecretRef = src.Status.KubeconfigSecretRef
+	dst.Status.NextMaintenanceWindow = src.Status.NextMaintenanceWindow
+	dst.Status.KubernetesVersion = src.Status.KubernetesVersion
+	dst.Status.Upgrade = nil
+	if src.Status.Upgrade != nil {
+		upgrade := KubernetesUpgrade(*src.Status.Upgrade)
+		dst.Status.Upgrade = &upgrade
+	}
+	dst.Status.UpgradeHistory = nil
+	for _, u := range src.Status.UpgradeHistory {
+		dst.Status.UpgradeHistory = append(dst.Status.UpgradeHistory, KubernetesUpgrade(u))
+	}
+	dst.Status.Drift = nil
+	if src.Status.Drift != nil {
+		drift := Drift(*src.Status.Drift)
+		dst.Status.Drift = &drift
+	}
+	dst.Status.PendingPlan = nil
+	if src.Status.PendingPlan != nil {
+		dst.Status.PendingPlan = &KopsPlan{}
+		if err := convertJSON(src.Status.PendingPlan, dst.Status.PendingPlan); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// convertJSON copies types that are the same in both versions, like the kops
+// types mirroring the kops API, through their shared JSON representation
+func convertJSON(src, dst interface{}) error {
+	data, err := json.Marshal(src)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, dst)
+}
@@ -0,0 +1,63 @@
+package v1alpha1
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/gofuzz"
+	"github.com/infobloxopen/cluster-operator/pkg/apis/clusteroperator/v1alpha2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const fuzzIterations = 1000
+
+func newFuzzer() *fuzz.Fuzzer {
+	return fuzz.New().NilChance(0.2).NumElements(1, 3)
+}
+
+// TestConversionRoundTrip converts fuzzed v1alpha1 Clusters to v1alpha2 and
+// back, nothing may get lost on the way
+func TestConversionRoundTrip(t *testing.T) {
+	f := newFuzzer()
+	for i := 0; i < fuzzIterations; i++ {
+		in := &Cluster{}
+		f.Fuzz(in)
+		// the type is set by the conversion webhook, not converted
+		in.TypeMeta = metav1.TypeMeta{}
+
+		hub := &v1alpha2.Cluster{}
+		if err := in.DeepCopy().ConvertTo(hub); err != nil {
+			t.Fatalf("ConvertTo failed: %v", err)
+		}
+		out := &Cluster{}
+		if err := out.ConvertFrom(hub); err != nil {
+			t.Fatalf("ConvertFrom failed: %v", err)
+		}
+		if !reflect.DeepEqual(in, out) {
+			t.Fatalf("v1alpha1 changed on round trip\nin:  %#v\nout: %#v", in, out)
+		}
+	}
+}
+
+// TestHubConversionRoundTrip converts fuzzed v1alpha2 Clusters to v1alpha1
+// and back, nothing may get lost on the way
+func TestHubConversionRoundTrip(t *testing.T) {
+	f := newFuzzer()
+	for i := 0; i < fuzzIterations; i++ {
+		in := &v1alpha2.Cluster{}
+		f.Fuzz(in)
+		in.TypeMeta = metav1.TypeMeta{}
+
+		spoke := &Cluster{}
+		if err := spoke.ConvertFrom(in.DeepCopy()); err != nil {
+			t.Fatalf("ConvertFrom failed: %v", err)
+		}
+		out := &v1alpha2.Cluster{}
+		if err := spoke.ConvertTo(out); err != nil {
+			t.Fatalf("ConvertTo failed: %v", err)
+		}
+		if !reflect.DeepEqual(in, out) {
+			t.Fatalf("v1alpha2 changed on round trip\nin:  %#v\nout: %#v", in, out)
+		}
+	}
+}
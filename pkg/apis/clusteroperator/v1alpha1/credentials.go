@@ -0,0 +1,62 @@
+package v1alpha1
+
+import (
+	"fmt"
+)
+
+// AllNamespaces in the allowed credentials namespaces lets Clusters use
+// Secrets in any namespace
+const AllNamespaces = "*"
+
+// CredentialsNamespace is the namespace of the Secret spec.credentialsRef
+// points at
+func (c *Cluster) CredentialsNamespace() string {
+	if ref := c.Spec.CredentialsRef; ref != nil && ref.Namespace != "" {
+		return ref.Namespace
+	}
+	return c.Namespace
+}
+
+// ValidateCredentialsRef checks that the credentials Secret of the Cluster is
+// named and lives in the namespace of the Cluster or one of allowed
+func (c *Cluster) ValidateCredentialsRef(allowed []string) error {
+	ref := c.Spec.CredentialsRef
+	if ref == nil {
+		return nil
+	}
+	if ref.Name == "" {
+		return fmt.Errorf("the name of the credentials Secret is required")
+	}
+
+	namespace := c.CredentialsNamespace()
+	if namespace == c.Namespace {
+		return nil
+	}
+	for _, a := range allowed {
+		if a == namespace || a == AllNamespaces {
+			return nil
+		}
+	}
+	return fmt.Errorf("credentials Secrets may not be read from namespace %q", namespace)
+}
+
+// ValidateAccess checks that Clusters in namespace may reference the
+// ClusterProvider. Its allowed namespaces decide when it has any, otherwise
+// Clusters in namespace must be allowed to reference the credentials Secret
+// of the ClusterProvider themselves: it is in namespace or one of allowed.
+func (p *ClusterProvider) ValidateAccess(namespace string, allowed []string) error {
+	want := namespace
+	if len(p.Spec.AllowedNamespaces) > 0 {
+		allowed = p.Spec.AllowedNamespaces
+	} else if ref := p.Spec.CredentialsRef; ref == nil || ref.Namespace == namespace {
+		return nil
+	} else {
+		want = ref.Namespace
+	}
+	for _, a := range allowed {
+		if a == want || a == AllNamespaces {
+			return nil
+		}
+	}
+	return fmt.Errorf("ClusterProvider %s may not be used from namespace %q", p.Name, namespace)
+}
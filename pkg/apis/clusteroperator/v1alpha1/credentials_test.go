@@ -0,0 +1,64 @@
+package v1alpha1
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestValidateCredentialsRef(t *testing.T) {
+	tests := []struct {
+		name    string
+		ref     *CredentialsReference
+		allowed []string
+		valid   bool
+	}{
+		{"no reference", nil, nil, true},
+		{"same namespace", &CredentialsReference{Name: "aws"}, nil, true},
+		{"explicit same namespace", &CredentialsReference{Name: "aws", Namespace: "team"}, nil, true},
+		{"missing name", &CredentialsReference{Namespace: "team"}, nil, false},
+		{"other namespace", &CredentialsReference{Name: "aws", Namespace: "accounts"}, nil, false},
+		{"allowed namespace", &CredentialsReference{Name: "aws", Namespace: "accounts"}, []string{"shared", "accounts"}, true},
+		{"all namespaces", &CredentialsReference{Name: "aws", Namespace: "accounts"}, []string{AllNamespaces}, true},
+	}
+
+	for _, test := range tests {
+		c := &Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "team"},
+			Spec:       ClusterSpec{CredentialsRef: test.ref},
+		}
+		if err := c.ValidateCredentialsRef(test.allowed); (err == nil) != test.valid {
+			t.Errorf("%s: expected valid %v got %v", test.name, test.valid, err)
+		}
+	}
+}
+
+func TestValidateAccess(t *testing.T) {
+	secret := &CredentialsReference{Name: "aws", Namespace: "accounts"}
+	tests := []struct {
+		name       string
+		ref        *CredentialsReference
+		namespaces []string
+		allowed    []string
+		valid      bool
+	}{
+		{"no credentials", nil, nil, nil, true},
+		{"credentials in another namespace", secret, nil, nil, false},
+		{"credentials in an allowed namespace", secret, nil, []string{"accounts"}, true},
+		{"credentials in all namespaces", secret, nil, []string{AllNamespaces}, true},
+		{"allowed by the provider", secret, []string{"other", "team"}, nil, true},
+		{"not allowed by the provider", secret, []string{"other"}, []string{AllNamespaces}, false},
+		{"provider allows all", nil, []string{AllNamespaces}, nil, true},
+		{"provider without credentials allows some", nil, []string{"other"}, nil, false},
+	}
+
+	for _, test := range tests {
+		p := &ClusterProvider{
+			ObjectMeta: metav1.ObjectMeta{Name: "provider"},
+			Spec:       ClusterProviderSpec{CredentialsRef: test.ref, AllowedNamespaces: test.namespaces},
+		}
+		if err := p.ValidateAccess("team", test.allowed); (err == nil) != test.valid {
+			t.Errorf("%s: expected valid %v got %v", test.name, test.valid, err)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package v1alpha1
+
+import (
+	"strings"
+)
+
+// Default sizes of instance groups that do not set MinSize
+const (
+	DefaultMasterSize  int32 = 1
+	DefaultNodeSize    int32 = 2
+	DefaultBastionSize int32 = 1
+)
+
+// ClusterName is the kops cluster name for a Cluster named name in dnsZone
+func ClusterName(name, dnsZone string) string {
+	return name + "." + dnsZone
+}
+
+// ConfigBase is the path of the kops cluster clusterName in stateStore
+func ConfigBase(stateStore, clusterName string) string {
+	return strings.TrimSuffix(stateStore, "/") + "/" + clusterName
+}
+
+// Default fills in the fields of the Cluster the operator computes, dnsZone
+// and stateStore are the operator settings. Fields that are already set are
+// left alone, the kops cluster name is left empty without a DNS zone.
+func (c *Cluster) Default(dnsZone, stateStore string) {
+	kc := &c.Spec.KopsConfig
+	if kc.Name == "" && c.Spec.Name != "" && dnsZone != "" {
+		kc.Name = ClusterName(c.Spec.Name, dnsZone)
+	}
+	if kc.StateStore == "" {
+		kc.StateStore = stateStore
+	}
+	if c.Spec.ApplyPolicy == "" {
+		c.Spec.ApplyPolicy = ApplyAutomatic
+	}
+	if c.Spec.RollingUpdate == nil {
+		c.Spec.RollingUpdate = &RollingUpdateSpec{}
+	}
+	if c.Spec.RollingUpdate.Strategy == "" {
+		c.Spec.RollingUpdate.Strategy = RollingUpdateAuto
+	}
+	if c.Spec.DriftPolicy == "" {
+		c.Spec.DriftPolicy = DriftReport
+	}
+
+	spec := c.Spec.Kops
+	if spec == nil {
+		return
+	}
+
+	if spec.Cluster.ConfigBase == "" && kc.Name != "" {
+		spec.Cluster.ConfigBase = ConfigBase(kc.StateStore, kc.Name)
+	}
+
+	var subnets []string
+	for _, s := range spec.Cluster.Subnets {
+		subnets = append(subnets, s.Name)
+	}
+
+	var masters, nodes int32
+	for i := range spec.InstanceGroups {
+		ig := &spec.InstanceGroups[i]
+		defaultInstanceGroupSize(ig)
+		if len(ig.Subnets) == 0 && len(subnets) > 0 {
+			ig.Subnets = append([]string(nil), subnets...)
+		}
+		switch ig.Role {
+		case "Master":
+			masters += *ig.MinSize
+		case "Node":
+			nodes += *ig.MinSize
+		}
+	}
+
+	if kc.MasterCount == 0 {
+		kc.MasterCount = int(masters)
+	}
+	if kc.WorkerCount == 0 {
+		kc.WorkerCount = int(nodes)
+	}
+	if len(kc.Zones) == 0 {
+		kc.Zones = subnetZones(spec.Cluster.Subnets)
+	}
+}
+
+// DefaultWithProvider defaults the Cluster like Default, the DNS zone and state
+// store of provider replace the operator settings and its instance groups are
+// used when spec.kops has none. A nil provider defaults like Default.
+func (c *Cluster) DefaultWithProvider(provider *ClusterProvider, dnsZone, stateStore string) {
+	if provider != nil {
+		if provider.Spec.DNSZone != "" {
+			dnsZone = provider.Spec.DNSZone
+		}
+		if provider.Spec.StateStore != "" {
+			stateStore = provider.Spec.StateStore
+		}
+		if spec := c.Spec.Kops; spec != nil && len(spec.InstanceGroups) == 0 {
+			for _, ig := range provider.Spec.InstanceGroups {
+				spec.InstanceGroups = append(spec.InstanceGroups, *ig.DeepCopy())
+			}
+		}
+	}
+	c.Default(dnsZone, stateStore)
+}
+
+// defaultInstanceGroupSize sets MinSize by role and MaxSize to MinSize
+func defaultInstanceGroupSize(ig *KopsInstanceGroupSpec) {
+	if ig.MinSize == nil {
+		size := DefaultNodeSize
+		switch ig.Role {
+		case "Master":
+			size = DefaultMasterSize
+		case "Bastion":
+			size = DefaultBastionSize
+		}
+		if ig.MaxSize != nil && *ig.MaxSize < size {
+			size = *ig.MaxSize
+		}
+		ig.MinSize = &size
+	}
+	if ig.MaxSize == nil {
+		size := *ig.MinSize
+		ig.MaxSize = &size
+	}
+}
+
+// subnetZones lists the zones of subnets in order, without duplicates
+func subnetZones(subnets []KopsSubnetSpec) []string {
+	var zones []string
+	seen := map[string]bool{}
+	for _, s := range subnets {
+		if s.Zone == "" || seen[s.Zone] {
+			continue
+		}
+		seen[s.Zone] = true
+		zones = append(zones, s.Zone)
+	}
+	return zones
+}
@@ -0,0 +1,173 @@
+package v1alpha1
+
+import (
+	"reflect"
+	"testing"
+)
+
+func int32Ptr(i int32) *int32 {
+	return &i
+}
+
+func TestDefault(t *testing.T) {
+	tests := []struct {
+		name     string
+		spec     ClusterSpec
+		expected ClusterSpec
+	}{
+		{
+			name: "legacy config",
+			spec: ClusterSpec{Name: "test", Config: "kind: Cluster\n"},
+			expected: ClusterSpec{
+				Name:          "test",
+				Config:        "kind: Cluster\n",
+				ApplyPolicy:   ApplyAutomatic,
+				RollingUpdate: &RollingUpdateSpec{Strategy: RollingUpdateAuto},
+				DriftPolicy:   DriftReport,
+				KopsConfig: KopsConfig{
+					Name:       "test.example.com",
+					StateStore: "s3://state",
+				},
+			},
+		},
+		{
+			name: "set fields are kept",
+			spec: ClusterSpec{
+				Name:          "test",
+				ApplyPolicy:   ApplyOnApproval,
+				RollingUpdate: &RollingUpdateSpec{Strategy: RollingUpdateNever},
+				DriftPolicy:   DriftCorrect,
+				KopsConfig: KopsConfig{
+					Name:       "other.example.com",
+					StateStore: "s3://other",
+					Zones:      []string{"us-east-2c"},
+				},
+			},
+			expected: ClusterSpec{
+				Name:          "test",
+				ApplyPolicy:   ApplyOnApproval,
+				RollingUpdate: &RollingUpdateSpec{Strategy: RollingUpdateNever},
+				DriftPolicy:   DriftCorrect,
+				KopsConfig: KopsConfig{
+					Name:       "other.example.com",
+					StateStore: "s3://other",
+					Zones:      []string{"us-east-2c"},
+				},
+			},
+		},
+		{
+			name: "kops",
+			spec: ClusterSpec{
+				Name: "test",
+				Kops: &KopsSpec{
+					Cluster: KopsClusterSpec{
+						Subnets: []KopsSubnetSpec{
+							{Name: "a", Zone: "us-east-2a"},
+							{Name: "b", Zone: "us-east-2b"},
+							{Name: "b-utility", Zone: "us-east-2b"},
+						},
+					},
+					InstanceGroups: []KopsInstanceGroupSpec{
+						{Name: "master", Role: "Master", Subnets: []string{"a"}},
+						{Name: "nodes", Role: "Node"},
+						{Name: "small", Role: "Node", MaxSize: int32Ptr(1)},
+						{Name: "large", Role: "Node", MinSize: int32Ptr(3)},
+					},
+				},
+			},
+			expected: ClusterSpec{
+				Name:          "test",
+				ApplyPolicy:   ApplyAutomatic,
+				RollingUpdate: &RollingUpdateSpec{Strategy: RollingUpdateAuto},
+				DriftPolicy:   DriftReport,
+				Kops: &KopsSpec{
+					Cluster: KopsClusterSpec{
+						ConfigBase: "s3://state/test.example.com",
+						Subnets: []KopsSubnetSpec{
+							{Name: "a", Zone: "us-east-2a"},
+							{Name: "b", Zone: "us-east-2b"},
+							{Name: "b-utility", Zone: "us-east-2b"},
+						},
+					},
+					InstanceGroups: []KopsInstanceGroupSpec{
+						{Name: "master", Role: "Master", Subnets: []string{"a"}, MinSize: int32Ptr(1), MaxSize: int32Ptr(1)},
+						{Name: "nodes", Role: "Node", Subnets: []string{"a", "b", "b-utility"}, MinSize: int32Ptr(2), MaxSize: int32Ptr(2)},
+						{Name: "small", Role: "Node", Subnets: []string{"a", "b", "b-utility"}, MinSize: int32Ptr(1), MaxSize: int32Ptr(1)},
+						{Name: "large", Role: "Node", Subnets: []string{"a", "b", "b-utility"}, MinSize: int32Ptr(3), MaxSize: int32Ptr(3)},
+					},
+				},
+				KopsConfig: KopsConfig{
+					Name:        "test.example.com",
+					StateStore:  "s3://state",
+					MasterCount: 1,
+					WorkerCount: 6,
+					Zones:       []string{"us-east-2a", "us-east-2b"},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		c := &Cluster{Spec: test.spec}
+		c.Default("example.com", "s3://state")
+		if !reflect.DeepEqual(c.Spec, test.expected) {
+			t.Errorf("%s: expected %+v got %+v", test.name, test.expected, c.Spec)
+		}
+
+		// defaulting again changes nothing
+		again := c.DeepCopy()
+		again.Default("example.com", "s3://state")
+		if !reflect.DeepEqual(again.Spec, c.Spec) {
+			t.Errorf("%s: defaulting is not idempotent", test.name)
+		}
+	}
+}
+
+func TestDefaultNoDNSZone(t *testing.T) {
+	c := &Cluster{Spec: ClusterSpec{Name: "test", Kops: &KopsSpec{}}}
+	c.Default("", "s3://state")
+	if kc := c.Spec.KopsConfig; kc.Name != "" || kc.StateStore != "s3://state" {
+		t.Errorf("expected no kops cluster name without a DNS zone got %+v", kc)
+	}
+	if c.Spec.Kops.Cluster.ConfigBase != "" {
+		t.Errorf("expected no configBase got %q", c.Spec.Kops.Cluster.ConfigBase)
+	}
+}
+
+func TestDefaultWithProvider(t *testing.T) {
+	provider := &ClusterProvider{
+		Spec: ClusterProviderSpec{
+			StateStore: "s3://team",
+			DNSZone:    "team.example.com",
+			InstanceGroups: []KopsInstanceGroupSpec{
+				{Name: "nodes", Role: "Node", MinSize: int32Ptr(3)},
+			},
+		},
+	}
+
+	c := &Cluster{Spec: ClusterSpec{Name: "test", Kops: &KopsSpec{}}}
+	c.DefaultWithProvider(provider, "example.com", "s3://state")
+	if kc := c.Spec.KopsConfig; kc.Name != "test.team.example.com" || kc.StateStore != "s3://team" {
+		t.Errorf("expected the provider DNS zone and state store got %+v", kc)
+	}
+	if igs := c.Spec.Kops.InstanceGroups; len(igs) != 1 || igs[0].Name != "nodes" || *igs[0].MaxSize != 3 {
+		t.Errorf("expected the provider instance groups got %+v", igs)
+	}
+	if provider.Spec.InstanceGroups[0].MaxSize != nil {
+		t.Error("expected the provider to be left alone")
+	}
+
+	own := &Cluster{Spec: ClusterSpec{Name: "test", Kops: &KopsSpec{
+		InstanceGroups: []KopsInstanceGroupSpec{{Name: "workers", Role: "Node"}},
+	}}}
+	own.DefaultWithProvider(provider, "example.com", "s3://state")
+	if igs := own.Spec.Kops.InstanceGroups; len(igs) != 1 || igs[0].Name != "workers" {
+		t.Errorf("expected the instance groups of the Cluster got %+v", igs)
+	}
+
+	operator := &Cluster{Spec: ClusterSpec{Name: "test"}}
+	operator.DefaultWithProvider(nil, "example.com", "s3://state")
+	if kc := operator.Spec.KopsConfig; kc.Name != "test.example.com" || kc.StateStore != "s3://state" {
+		t.Errorf("expected the operator DNS zone and state store got %+v", kc)
+	}
+}
@@ -0,0 +1,148 @@
+package v1alpha1
+
+// KopsSpec is the kops configuration of a cluster. The types mirror the
+// subset of the kops v1alpha2 Cluster and InstanceGroup API the operator
+// manages, the operator renders the kops manifest from them.
+// +k8s:openapi-gen=true
+type KopsSpec struct {
+	// Cluster is the spec of the kops Cluster
+	Cluster KopsClusterSpec `json:"cluster"`
+	// InstanceGroups are the kops InstanceGroups of the cluster
+	InstanceGroups []KopsInstanceGroupSpec `json:"instanceGroups,omitempty"`
+	// SSHPublicKey is the public key installed on the instances
+	SSHPublicKey string `json:"sshPublicKey,omitempty"`
+}
+
+// KopsClusterSpec mirrors the kops v1alpha2 ClusterSpec
+// +k8s:openapi-gen=true
+type KopsClusterSpec struct {
+	API           *KopsAPISpec           `json:"api,omitempty"`
+	Authorization *KopsAuthorizationSpec `json:"authorization,omitempty"`
+	Channel       string                 `json:"channel,omitempty"`
+	CloudLabels   map[string]string      `json:"cloudLabels,omitempty"`
+	CloudProvider string                 `json:"cloudProvider,omitempty"`
+	// ConfigBase defaults to the cluster path in the state store
+	ConfigBase          string                `json:"configBase,omitempty"`
+	EtcdClusters        []KopsEtcdClusterSpec `json:"etcdClusters,omitempty"`
+	IAM                 *KopsIAMSpec          `json:"iam,omitempty"`
+	Kubelet             *KopsKubeletSpec      `json:"kubelet,omitempty"`
+	KubernetesAPIAccess []string              `json:"kubernetesApiAccess,omitempty"`
+	KubernetesVersion   string                `json:"kubernetesVersion,omitempty"`
+	MasterPublicName    string                `json:"masterPublicName,omitempty"`
+	NetworkCIDR         string                `json:"networkCIDR,omitempty"`
+	NetworkID           string                `json:"networkID,omitempty"`
+	Networking          *KopsNetworkingSpec   `json:"networking,omitempty"`
+	NonMasqueradeCIDR   string                `json:"nonMasqueradeCIDR,omitempty"`
+	SSHAccess           []string              `json:"sshAccess,omitempty"`
+	Subnets             []KopsSubnetSpec      `json:"subnets,omitempty"`
+	Topology            *KopsTopologySpec     `json:"topology,omitempty"`
+}
+
+// KopsEmptySpec is a setting that is enabled by being present, e.g. rbac: {}
+// +k8s:openapi-gen=true
+type KopsEmptySpec struct {
+}
+
+// KopsAPISpec configures how the API server is reached
+// +k8s:openapi-gen=true
+type KopsAPISpec struct {
+	DNS          *KopsEmptySpec        `json:"dns,omitempty"`
+	LoadBalancer *KopsLoadBalancerSpec `json:"loadBalancer,omitempty"`
+}
+
+// KopsLoadBalancerSpec configures the API load balancer
+// +k8s:openapi-gen=true
+type KopsLoadBalancerSpec struct {
+	// Type is Public or Internal
+	Type string `json:"type,omitempty"`
+}
+
+// KopsAuthorizationSpec selects the API authorization mode
+// +k8s:openapi-gen=true
+type KopsAuthorizationSpec struct {
+	RBAC        *KopsEmptySpec `json:"rbac,omitempty"`
+	AlwaysAllow *KopsEmptySpec `json:"alwaysAllow,omitempty"`
+}
+
+// KopsEtcdClusterSpec configures one of the etcd clusters, main or events
+// +k8s:openapi-gen=true
+type KopsEtcdClusterSpec struct {
+	Name          string               `json:"name"`
+	CPURequest    string               `json:"cpuRequest,omitempty"`
+	MemoryRequest string               `json:"memoryRequest,omitempty"`
+	Members       []KopsEtcdMemberSpec `json:"etcdMembers,omitempty"`
+}
+
+// KopsEtcdMemberSpec places an etcd member on a master instance group
+// +k8s:openapi-gen=true
+type KopsEtcdMemberSpec struct {
+	Name          string `json:"name"`
+	InstanceGroup string `json:"instanceGroup"`
+}
+
+// KopsIAMSpec configures the IAM roles kops creates
+// +k8s:openapi-gen=true
+type KopsIAMSpec struct {
+	AllowContainerRegistry bool `json:"allowContainerRegistry,omitempty"`
+	Legacy                 bool `json:"legacy"`
+}
+
+// KopsKubeletSpec holds the kubelet settings the operator sets
+// +k8s:openapi-gen=true
+type KopsKubeletSpec struct {
+	AnonymousAuth *bool `json:"anonymousAuth,omitempty"`
+}
+
+// KopsNetworkingSpec selects the CNI, exactly one should be set
+// +k8s:openapi-gen=true
+type KopsNetworkingSpec struct {
+	Kubenet   *KopsEmptySpec `json:"kubenet,omitempty"`
+	Calico    *KopsEmptySpec `json:"calico,omitempty"`
+	Weave     *KopsEmptySpec `json:"weave,omitempty"`
+	Cilium    *KopsEmptySpec `json:"cilium,omitempty"`
+	AmazonVPC *KopsEmptySpec `json:"amazonvpc,omitempty"`
+}
+
+// KopsSubnetSpec is a subnet of the cluster network
+// +k8s:openapi-gen=true
+type KopsSubnetSpec struct {
+	Name string `json:"name"`
+	CIDR string `json:"cidr,omitempty"`
+	Zone string `json:"zone,omitempty"`
+	// Type is Public, Private or Utility
+	Type string `json:"type,omitempty"`
+	// ProviderID is the id of an existing subnet to use
+	ProviderID string `json:"id,omitempty"`
+}
+
+// KopsTopologySpec sets whether masters, nodes and DNS are public or private
+// +k8s:openapi-gen=true
+type KopsTopologySpec struct {
+	Masters string       `json:"masters,omitempty"`
+	Nodes   string       `json:"nodes,omitempty"`
+	DNS     *KopsDNSSpec `json:"dns,omitempty"`
+}
+
+// KopsDNSSpec sets whether the cluster DNS zone is public or private
+// +k8s:openapi-gen=true
+type KopsDNSSpec struct {
+	Type string `json:"type,omitempty"`
+}
+
+// KopsInstanceGroupSpec mirrors the kops v1alpha2 InstanceGroup, Name is the
+// name of the InstanceGroup and the rest its spec
+// +k8s:openapi-gen=true
+type KopsInstanceGroupSpec struct {
+	Name string `json:"name"`
+	// Role is Master, Node or Bastion
+	Role           string            `json:"role"`
+	Image          string            `json:"image,omitempty"`
+	MachineType    string            `json:"machineType,omitempty"`
+	MinSize        *int32            `json:"minSize,omitempty"`
+	MaxSize        *int32            `json:"maxSize,omitempty"`
+	RootVolumeSize *int32            `json:"rootVolumeSize,omitempty"`
+	Subnets        []string          `json:"subnets,omitempty"`
+	NodeLabels     map[string]string `json:"nodeLabels,omitempty"`
+	CloudLabels    map[string]string `json:"cloudLabels,omitempty"`
+	Taints         []string          `json:"taints,omitempty"`
+}
@@ -0,0 +1,76 @@
+package v1alpha1
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// KubernetesVersion is a parsed Kubernetes release version, e.g. 1.16.7
+type KubernetesVersion struct {
+	Major int
+	Minor int
+	Patch int
+}
+
+// ParseKubernetesVersion parses a release version like 1.16.7 or v1.16.7,
+// build metadata as in v1.16.7-eks.1 is ignored
+func ParseKubernetesVersion(s string) (KubernetesVersion, error) {
+	v := KubernetesVersion{}
+	version := strings.TrimPrefix(s, "v")
+	if i := strings.IndexAny(version, "-+"); i >= 0 {
+		version = version[:i]
+	}
+
+	parts := strings.Split(version, ".")
+	if len(parts) != 3 {
+		return v, fmt.Errorf("version %q is not MAJOR.MINOR.PATCH", s)
+	}
+	numbers := []*int{&v.Major, &v.Minor, &v.Patch}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return v, fmt.Errorf("version %q is not MAJOR.MINOR.PATCH", s)
+		}
+		*numbers[i] = n
+	}
+	return v, nil
+}
+
+// String returns the version without the v prefix, the way kops expects it
+func (v KubernetesVersion) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// Less reports whether v is an older release than o
+func (v KubernetesVersion) Less(o KubernetesVersion) bool {
+	if v.Major != o.Major {
+		return v.Major < o.Major
+	}
+	if v.Minor != o.Minor {
+		return v.Minor < o.Minor
+	}
+	return v.Patch < o.Patch
+}
+
+// ValidateUpgrade checks that a cluster running from can be moved to to.
+// Kubernetes supports upgrades of one minor version at a time and no
+// downgrades.
+func ValidateUpgrade(from, to string) error {
+	current, err := ParseKubernetesVersion(from)
+	if err != nil {
+		return err
+	}
+	target, err := ParseKubernetesVersion(to)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case target.Less(current):
+		return fmt.Errorf("cannot downgrade from %s to %s", current, target)
+	case target.Major != current.Major || target.Minor > current.Minor+1:
+		return fmt.Errorf("cannot upgrade from %s to %s, upgrade one minor version at a time (%d.%d.x)", current, target, current.Major, current.Minor+1)
+	}
+	return nil
+}
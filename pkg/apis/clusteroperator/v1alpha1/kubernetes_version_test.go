@@ -0,0 +1,51 @@
+package v1alpha1
+
+import "testing"
+
+func TestParseKubernetesVersion(t *testing.T) {
+	tests := []struct {
+		version  string
+		expected string
+		valid    bool
+	}{
+		{"1.16.7", "1.16.7", true},
+		{"v1.17.0", "1.17.0", true},
+		{"v1.16.7-eks.1", "1.16.7", true},
+		{"1.16", "", false},
+		{"1.x.0", "", false},
+		{"", "", false},
+	}
+
+	for _, test := range tests {
+		v, err := ParseKubernetesVersion(test.version)
+		if (err == nil) != test.valid {
+			t.Errorf("%q: expected valid %v got %v", test.version, test.valid, err)
+			continue
+		}
+		if test.valid && v.String() != test.expected {
+			t.Errorf("%q: expected %s got %s", test.version, test.expected, v)
+		}
+	}
+}
+
+func TestValidateUpgrade(t *testing.T) {
+	tests := []struct {
+		from, to string
+		valid    bool
+	}{
+		{"1.16.7", "1.16.7", true},
+		{"1.16.7", "1.16.8", true},
+		{"1.16.7", "v1.17.3", true},
+		{"1.16.7", "1.18.0", false},
+		{"1.16.7", "2.0.0", false},
+		{"1.16.7", "1.15.9", false},
+		{"1.16.7", "1.16.6", false},
+		{"1.16.7", "latest", false},
+	}
+
+	for _, test := range tests {
+		if err := ValidateUpgrade(test.from, test.to); (err == nil) != test.valid {
+			t.Errorf("%s to %s: expected valid %v got %v", test.from, test.to, test.valid, err)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package v1alpha1
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// maxMaintenanceWindow is the longest a window may stay open, a longer window
+// would overlap with its own opening a week later
+const maxMaintenanceWindow = 7 * 24 * time.Hour
+
+// window is a parsed MaintenanceWindow
+type window struct {
+	days     map[time.Weekday]bool
+	hour     int
+	minute   int
+	duration time.Duration
+	location *time.Location
+}
+
+// parse checks w and returns it in the form the window is computed from
+func (w MaintenanceWindow) parse() (window, error) {
+	parsed := window{duration: w.Duration.Duration, location: time.UTC}
+
+	start, err := time.Parse("15:04", w.Start)
+	if err != nil {
+		return parsed, fmt.Errorf("start %q is not HH:MM", w.Start)
+	}
+	parsed.hour, parsed.minute = start.Hour(), start.Minute()
+
+	if parsed.duration <= 0 || parsed.duration > maxMaintenanceWindow {
+		return parsed, fmt.Errorf("duration %v must be positive and at most %v", parsed.duration, maxMaintenanceWindow)
+	}
+
+	if w.TimeZone != "" {
+		if parsed.location, err = time.LoadLocation(w.TimeZone); err != nil {
+			return parsed, fmt.Errorf("unknown time zone %q", w.TimeZone)
+		}
+	}
+
+	if len(w.Days) > 0 {
+		parsed.days = map[time.Weekday]bool{}
+	}
+	for _, day := range w.Days {
+		d, ok := weekday(day)
+		if !ok {
+			return parsed, fmt.Errorf("unknown day %q", day)
+		}
+		parsed.days[d] = true
+	}
+	return parsed, nil
+}
+
+// weekday parses the English name of a day, Sat and Saturday are the same
+func weekday(name string) (time.Weekday, bool) {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if strings.EqualFold(name, d.String()) || strings.EqualFold(name, d.String()[:3]) {
+			return d, true
+		}
+	}
+	return 0, false
+}
+
+// opening returns when the window opens on the day offset days after now
+func (w window) opening(now time.Time, offset int) (time.Time, bool) {
+	local := now.In(w.location)
+	start := time.Date(local.Year(), local.Month(), local.Day()+offset, w.hour, w.minute, 0, 0, w.location)
+	if w.days != nil && !w.days[start.Weekday()] {
+		return start, false
+	}
+	return start, true
+}
+
+// Validate checks the days, start, duration and time zone of w
+func (w MaintenanceWindow) Validate() error {
+	_, err := w.parse()
+	return err
+}
+
+// Open reports whether the window is open at now
+func (w MaintenanceWindow) Open(now time.Time) (bool, error) {
+	parsed, err := w.parse()
+	if err != nil {
+		return false, err
+	}
+	// a window that opened up to a week ago can still be open
+	for offset := -7; offset <= 0; offset++ {
+		start, ok := parsed.opening(now, offset)
+		if ok && !now.Before(start) && now.Before(start.Add(parsed.duration)) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Next returns when the window opens next after now
+func (w MaintenanceWindow) Next(now time.Time) (time.Time, error) {
+	parsed, err := w.parse()
+	if err != nil {
+		return time.Time{}, err
+	}
+	for offset := 0; offset <= 7; offset++ {
+		if start, ok := parsed.opening(now, offset); ok && start.After(now) {
+			return start, nil
+		}
+	}
+	// every window opens at least once a week
+	return time.Time{}, fmt.Errorf("window %v does not open", w)
+}
+
+// InMaintenanceWindow reports whether disruptive operations may run at now
+// and when the next of windows opens. Operations may always run when there
+// are no windows.
+func InMaintenanceWindow(windows []MaintenanceWindow, now time.Time) (bool, time.Time, error) {
+	var (
+		open bool
+		next time.Time
+	)
+	if len(windows) == 0 {
+		return true, next, nil
+	}
+	for _, w := range windows {
+		o, err := w.Open(now)
+		if err != nil {
+			return false, next, err
+		}
+		open = open || o
+
+		n, err := w.Next(now)
+		if err != nil {
+			return false, next, err
+		}
+		if next.IsZero() || n.Before(next) {
+			next = n
+		}
+	}
+	return open, next, nil
+}
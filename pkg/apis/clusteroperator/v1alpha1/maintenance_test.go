@@ -0,0 +1,72 @@
+package v1alpha1
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestInMaintenanceWindow(t *testing.T) {
+	// Saturday 2020-02-01 is used as the reference week
+	saturdayNight := MaintenanceWindow{Days: []string{"Saturday"}, Start: "22:00", Duration: metav1.Duration{Duration: 4 * time.Hour}}
+	daily := MaintenanceWindow{Start: "03:00", Duration: metav1.Duration{Duration: time.Hour}, TimeZone: "America/New_York"}
+
+	tests := []struct {
+		name    string
+		windows []MaintenanceWindow
+		now     string
+		open    bool
+		next    string
+	}{
+		{"no windows", nil, "2020-02-01T12:00:00Z", true, ""},
+		{"before window", []MaintenanceWindow{saturdayNight}, "2020-02-01T12:00:00Z", false, "2020-02-01T22:00:00Z"},
+		{"window opens", []MaintenanceWindow{saturdayNight}, "2020-02-01T22:00:00Z", true, "2020-02-08T22:00:00Z"},
+		{"window past midnight", []MaintenanceWindow{saturdayNight}, "2020-02-02T01:30:00Z", true, "2020-02-08T22:00:00Z"},
+		{"window closed", []MaintenanceWindow{saturdayNight}, "2020-02-02T02:00:00Z", false, "2020-02-08T22:00:00Z"},
+		{"time zone", []MaintenanceWindow{daily}, "2020-02-01T08:30:00Z", true, "2020-02-02T08:00:00Z"},
+		{"time zone closed", []MaintenanceWindow{daily}, "2020-02-01T03:30:00Z", false, "2020-02-01T08:00:00Z"},
+		{"earliest window", []MaintenanceWindow{saturdayNight, daily}, "2020-02-01T12:00:00Z", false, "2020-02-01T22:00:00Z"},
+	}
+
+	for _, test := range tests {
+		now, _ := time.Parse(time.RFC3339, test.now)
+		open, next, err := InMaintenanceWindow(test.windows, now)
+		if err != nil {
+			t.Errorf("%s: expected no error got %v", test.name, err)
+			continue
+		}
+		if open != test.open {
+			t.Errorf("%s: expected open %v got %v", test.name, test.open, open)
+		}
+		expected := time.Time{}
+		if test.next != "" {
+			expected, _ = time.Parse(time.RFC3339, test.next)
+		}
+		if !next.Equal(expected) {
+			t.Errorf("%s: expected next %v got %v", test.name, expected, next.UTC())
+		}
+	}
+}
+
+func TestMaintenanceWindowValidate(t *testing.T) {
+	hour := metav1.Duration{Duration: time.Hour}
+	tests := []struct {
+		window MaintenanceWindow
+		valid  bool
+	}{
+		{MaintenanceWindow{Days: []string{"Sat", "sunday"}, Start: "23:30", Duration: hour, TimeZone: "Europe/Berlin"}, true},
+		{MaintenanceWindow{Start: "25:00", Duration: hour}, false},
+		{MaintenanceWindow{Start: "2am", Duration: hour}, false},
+		{MaintenanceWindow{Start: "02:00"}, false},
+		{MaintenanceWindow{Start: "02:00", Duration: metav1.Duration{Duration: 8 * 24 * time.Hour}}, false},
+		{MaintenanceWindow{Start: "02:00", Duration: hour, TimeZone: "Mars/Olympus"}, false},
+		{MaintenanceWindow{Days: []string{"Funday"}, Start: "02:00", Duration: hour}, false},
+	}
+
+	for _, test := range tests {
+		if err := test.window.Validate(); (err == nil) != test.valid {
+			t.Errorf("%+v: expected valid %v got %v", test.window, test.valid, err)
+		}
+	}
+}
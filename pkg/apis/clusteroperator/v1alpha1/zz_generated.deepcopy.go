@@ -5,7 +5,10 @@
 package v1alpha1
 
 import (
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
+	intstr "k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
@@ -37,46 +40,73 @@ func (in *Cluster) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ClusterConfig) DeepCopyInto(out *ClusterConfig) {
+func (in *ClusterList) DeepCopyInto(out *ClusterList) {
 	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]Cluster, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterConfig.
-func (in *ClusterConfig) DeepCopy() *ClusterConfig {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterList.
+func (in *ClusterList) DeepCopy() *ClusterList {
 	if in == nil {
 		return nil
 	}
-	out := new(ClusterConfig)
+	out := new(ClusterList)
 	in.DeepCopyInto(out)
 	return out
 }
 
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ClusterConfigs) DeepCopyInto(out *ClusterConfigs) {
+func (in *ClusterProvider) DeepCopyInto(out *ClusterProvider) {
 	*out = *in
-	out.ClusterConfigs = in.ClusterConfigs
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterConfigs.
-func (in *ClusterConfigs) DeepCopy() *ClusterConfigs {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterProvider.
+func (in *ClusterProvider) DeepCopy() *ClusterProvider {
 	if in == nil {
 		return nil
 	}
-	out := new(ClusterConfigs)
+	out := new(ClusterProvider)
 	in.DeepCopyInto(out)
 	return out
 }
 
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterProvider) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ClusterList) DeepCopyInto(out *ClusterList) {
+func (in *ClusterProviderList) DeepCopyInto(out *ClusterProviderList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]Cluster, len(*in))
+		*out = make([]ClusterProvider, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
@@ -84,28 +114,88 @@ func (in *ClusterList) DeepCopyInto(out *ClusterList) {
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterList.
-func (in *ClusterList) DeepCopy() *ClusterList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterProviderList.
+func (in *ClusterProviderList) DeepCopy() *ClusterProviderList {
 	if in == nil {
 		return nil
 	}
-	out := new(ClusterList)
+	out := new(ClusterProviderList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *ClusterList) DeepCopyObject() runtime.Object {
+func (in *ClusterProviderList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterProviderSpec) DeepCopyInto(out *ClusterProviderSpec) {
+	*out = *in
+	if in.CredentialsRef != nil {
+		in, out := &in.CredentialsRef, &out.CredentialsRef
+		*out = new(CredentialsReference)
+		**out = **in
+	}
+	if in.AllowedNamespaces != nil {
+		in, out := &in.AllowedNamespaces, &out.AllowedNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.InstanceGroups != nil {
+		in, out := &in.InstanceGroups, &out.InstanceGroups
+		*out = make([]KopsInstanceGroupSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterProviderSpec.
+func (in *ClusterProviderSpec) DeepCopy() *ClusterProviderSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterProviderSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterSpec) DeepCopyInto(out *ClusterSpec) {
 	*out = *in
+	if in.Kops != nil {
+		in, out := &in.Kops, &out.Kops
+		*out = new(KopsSpec)
+		(*in).DeepCopyInto(*out)
+	}
 	in.KopsConfig.DeepCopyInto(&out.KopsConfig)
+	if in.RollingUpdate != nil {
+		in, out := &in.RollingUpdate, &out.RollingUpdate
+		*out = new(RollingUpdateSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MaintenanceWindows != nil {
+		in, out := &in.MaintenanceWindows, &out.MaintenanceWindows
+		*out = make([]MaintenanceWindow, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.CredentialsRef != nil {
+		in, out := &in.CredentialsRef, &out.CredentialsRef
+		*out = new(CredentialsReference)
+		**out = **in
+	}
+	if in.ProviderRef != nil {
+		in, out := &in.ProviderRef, &out.ProviderRef
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
 	return
 }
 
@@ -122,8 +212,45 @@ func (in *ClusterSpec) DeepCopy() *ClusterSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterStatus) DeepCopyInto(out *ClusterStatus) {
 	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	in.KopsStatus.DeepCopyInto(&out.KopsStatus)
-	in.KubeConfig.DeepCopyInto(&out.KubeConfig)
+	if in.KubeconfigSecretRef != nil {
+		in, out := &in.KubeconfigSecretRef, &out.KubeconfigSecretRef
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+	if in.PendingPlan != nil {
+		in, out := &in.PendingPlan, &out.PendingPlan
+		*out = new(KopsPlan)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.NextMaintenanceWindow != nil {
+		in, out := &in.NextMaintenanceWindow, &out.NextMaintenanceWindow
+		*out = (*in).DeepCopy()
+	}
+	if in.Upgrade != nil {
+		in, out := &in.Upgrade, &out.Upgrade
+		*out = new(KubernetesUpgrade)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.UpgradeHistory != nil {
+		in, out := &in.UpgradeHistory, &out.UpgradeHistory
+		*out = make([]KubernetesUpgrade, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Drift != nil {
+		in, out := &in.Drift, &out.Drift
+		*out = new(Drift)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -138,34 +265,209 @@ func (in *ClusterStatus) DeepCopy() *ClusterStatus {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ContextConfig) DeepCopyInto(out *ContextConfig) {
+func (in *Condition) DeepCopyInto(out *Condition) {
 	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ContextConfig.
-func (in *ContextConfig) DeepCopy() *ContextConfig {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Condition.
+func (in *Condition) DeepCopy() *Condition {
 	if in == nil {
 		return nil
 	}
-	out := new(ContextConfig)
+	out := new(Condition)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ContextConfigs) DeepCopyInto(out *ContextConfigs) {
+func (in *CredentialsReference) DeepCopyInto(out *CredentialsReference) {
 	*out = *in
-	out.ContextConfigs = in.ContextConfigs
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ContextConfigs.
-func (in *ContextConfigs) DeepCopy() *ContextConfigs {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CredentialsReference.
+func (in *CredentialsReference) DeepCopy() *CredentialsReference {
 	if in == nil {
 		return nil
 	}
-	out := new(ContextConfigs)
+	out := new(CredentialsReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Drift) DeepCopyInto(out *Drift) {
+	*out = *in
+	in.LastChecked.DeepCopyInto(&out.LastChecked)
+	if in.StateStore != nil {
+		in, out := &in.StateStore, &out.StateStore
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Cloud != nil {
+		in, out := &in.Cloud, &out.Cloud
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Drift.
+func (in *Drift) DeepCopy() *Drift {
+	if in == nil {
+		return nil
+	}
+	out := new(Drift)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KopsAPISpec) DeepCopyInto(out *KopsAPISpec) {
+	*out = *in
+	if in.DNS != nil {
+		in, out := &in.DNS, &out.DNS
+		*out = new(KopsEmptySpec)
+		**out = **in
+	}
+	if in.LoadBalancer != nil {
+		in, out := &in.LoadBalancer, &out.LoadBalancer
+		*out = new(KopsLoadBalancerSpec)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KopsAPISpec.
+func (in *KopsAPISpec) DeepCopy() *KopsAPISpec {
+	if in == nil {
+		return nil
+	}
+	out := new(KopsAPISpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KopsAuthorizationSpec) DeepCopyInto(out *KopsAuthorizationSpec) {
+	*out = *in
+	if in.RBAC != nil {
+		in, out := &in.RBAC, &out.RBAC
+		*out = new(KopsEmptySpec)
+		**out = **in
+	}
+	if in.AlwaysAllow != nil {
+		in, out := &in.AlwaysAllow, &out.AlwaysAllow
+		*out = new(KopsEmptySpec)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KopsAuthorizationSpec.
+func (in *KopsAuthorizationSpec) DeepCopy() *KopsAuthorizationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(KopsAuthorizationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KopsChange) DeepCopyInto(out *KopsChange) {
+	*out = *in
+	if in.Fields != nil {
+		in, out := &in.Fields, &out.Fields
+		*out = make([]KopsFieldChange, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KopsChange.
+func (in *KopsChange) DeepCopy() *KopsChange {
+	if in == nil {
+		return nil
+	}
+	out := new(KopsChange)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KopsClusterSpec) DeepCopyInto(out *KopsClusterSpec) {
+	*out = *in
+	if in.API != nil {
+		in, out := &in.API, &out.API
+		*out = new(KopsAPISpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Authorization != nil {
+		in, out := &in.Authorization, &out.Authorization
+		*out = new(KopsAuthorizationSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CloudLabels != nil {
+		in, out := &in.CloudLabels, &out.CloudLabels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.EtcdClusters != nil {
+		in, out := &in.EtcdClusters, &out.EtcdClusters
+		*out = make([]KopsEtcdClusterSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.IAM != nil {
+		in, out := &in.IAM, &out.IAM
+		*out = new(KopsIAMSpec)
+		**out = **in
+	}
+	if in.Kubelet != nil {
+		in, out := &in.Kubelet, &out.Kubelet
+		*out = new(KopsKubeletSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.KubernetesAPIAccess != nil {
+		in, out := &in.KubernetesAPIAccess, &out.KubernetesAPIAccess
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Networking != nil {
+		in, out := &in.Networking, &out.Networking
+		*out = new(KopsNetworkingSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SSHAccess != nil {
+		in, out := &in.SSHAccess, &out.SSHAccess
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Subnets != nil {
+		in, out := &in.Subnets, &out.Subnets
+		*out = make([]KopsSubnetSpec, len(*in))
+		copy(*out, *in)
+	}
+	if in.Topology != nil {
+		in, out := &in.Topology, &out.Topology
+		*out = new(KopsTopologySpec)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KopsClusterSpec.
+func (in *KopsClusterSpec) DeepCopy() *KopsClusterSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(KopsClusterSpec)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -191,6 +493,75 @@ func (in *KopsConfig) DeepCopy() *KopsConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KopsDNSSpec) DeepCopyInto(out *KopsDNSSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KopsDNSSpec.
+func (in *KopsDNSSpec) DeepCopy() *KopsDNSSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(KopsDNSSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KopsEmptySpec) DeepCopyInto(out *KopsEmptySpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KopsEmptySpec.
+func (in *KopsEmptySpec) DeepCopy() *KopsEmptySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(KopsEmptySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KopsEtcdClusterSpec) DeepCopyInto(out *KopsEtcdClusterSpec) {
+	*out = *in
+	if in.Members != nil {
+		in, out := &in.Members, &out.Members
+		*out = make([]KopsEtcdMemberSpec, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KopsEtcdClusterSpec.
+func (in *KopsEtcdClusterSpec) DeepCopy() *KopsEtcdClusterSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(KopsEtcdClusterSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KopsEtcdMemberSpec) DeepCopyInto(out *KopsEtcdMemberSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KopsEtcdMemberSpec.
+func (in *KopsEtcdMemberSpec) DeepCopy() *KopsEtcdMemberSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(KopsEtcdMemberSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KopsFailure) DeepCopyInto(out *KopsFailure) {
 	*out = *in
@@ -207,6 +578,187 @@ func (in *KopsFailure) DeepCopy() *KopsFailure {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KopsFieldChange) DeepCopyInto(out *KopsFieldChange) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KopsFieldChange.
+func (in *KopsFieldChange) DeepCopy() *KopsFieldChange {
+	if in == nil {
+		return nil
+	}
+	out := new(KopsFieldChange)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KopsIAMSpec) DeepCopyInto(out *KopsIAMSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KopsIAMSpec.
+func (in *KopsIAMSpec) DeepCopy() *KopsIAMSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(KopsIAMSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KopsInstanceGroup) DeepCopyInto(out *KopsInstanceGroup) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KopsInstanceGroup.
+func (in *KopsInstanceGroup) DeepCopy() *KopsInstanceGroup {
+	if in == nil {
+		return nil
+	}
+	out := new(KopsInstanceGroup)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KopsInstanceGroupSpec) DeepCopyInto(out *KopsInstanceGroupSpec) {
+	*out = *in
+	if in.MinSize != nil {
+		in, out := &in.MinSize, &out.MinSize
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxSize != nil {
+		in, out := &in.MaxSize, &out.MaxSize
+		*out = new(int32)
+		**out = **in
+	}
+	if in.RootVolumeSize != nil {
+		in, out := &in.RootVolumeSize, &out.RootVolumeSize
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Subnets != nil {
+		in, out := &in.Subnets, &out.Subnets
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.NodeLabels != nil {
+		in, out := &in.NodeLabels, &out.NodeLabels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.CloudLabels != nil {
+		in, out := &in.CloudLabels, &out.CloudLabels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Taints != nil {
+		in, out := &in.Taints, &out.Taints
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KopsInstanceGroupSpec.
+func (in *KopsInstanceGroupSpec) DeepCopy() *KopsInstanceGroupSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(KopsInstanceGroupSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KopsKubeletSpec) DeepCopyInto(out *KopsKubeletSpec) {
+	*out = *in
+	if in.AnonymousAuth != nil {
+		in, out := &in.AnonymousAuth, &out.AnonymousAuth
+		*out = new(bool)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KopsKubeletSpec.
+func (in *KopsKubeletSpec) DeepCopy() *KopsKubeletSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(KopsKubeletSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KopsLoadBalancerSpec) DeepCopyInto(out *KopsLoadBalancerSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KopsLoadBalancerSpec.
+func (in *KopsLoadBalancerSpec) DeepCopy() *KopsLoadBalancerSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(KopsLoadBalancerSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KopsNetworkingSpec) DeepCopyInto(out *KopsNetworkingSpec) {
+	*out = *in
+	if in.Kubenet != nil {
+		in, out := &in.Kubenet, &out.Kubenet
+		*out = new(KopsEmptySpec)
+		**out = **in
+	}
+	if in.Calico != nil {
+		in, out := &in.Calico, &out.Calico
+		*out = new(KopsEmptySpec)
+		**out = **in
+	}
+	if in.Weave != nil {
+		in, out := &in.Weave, &out.Weave
+		*out = new(KopsEmptySpec)
+		**out = **in
+	}
+	if in.Cilium != nil {
+		in, out := &in.Cilium, &out.Cilium
+		*out = new(KopsEmptySpec)
+		**out = **in
+	}
+	if in.AmazonVPC != nil {
+		in, out := &in.AmazonVPC, &out.AmazonVPC
+		*out = new(KopsEmptySpec)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KopsNetworkingSpec.
+func (in *KopsNetworkingSpec) DeepCopy() *KopsNetworkingSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(KopsNetworkingSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KopsNode) DeepCopyInto(out *KopsNode) {
 	*out = *in
@@ -223,6 +775,69 @@ func (in *KopsNode) DeepCopy() *KopsNode {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KopsNodeCount) DeepCopyInto(out *KopsNodeCount) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KopsNodeCount.
+func (in *KopsNodeCount) DeepCopy() *KopsNodeCount {
+	if in == nil {
+		return nil
+	}
+	out := new(KopsNodeCount)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KopsPlan) DeepCopyInto(out *KopsPlan) {
+	*out = *in
+	if in.Changes != nil {
+		in, out := &in.Changes, &out.Changes
+		*out = make([]KopsChange, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KopsPlan.
+func (in *KopsPlan) DeepCopy() *KopsPlan {
+	if in == nil {
+		return nil
+	}
+	out := new(KopsPlan)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KopsSpec) DeepCopyInto(out *KopsSpec) {
+	*out = *in
+	in.Cluster.DeepCopyInto(&out.Cluster)
+	if in.InstanceGroups != nil {
+		in, out := &in.InstanceGroups, &out.InstanceGroups
+		*out = make([]KopsInstanceGroupSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KopsSpec.
+func (in *KopsSpec) DeepCopy() *KopsSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(KopsSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KopsStatus) DeepCopyInto(out *KopsStatus) {
 	*out = *in
@@ -236,6 +851,20 @@ func (in *KopsStatus) DeepCopyInto(out *KopsStatus) {
 		*out = make([]KopsNode, len(*in))
 		copy(*out, *in)
 	}
+	if in.NodeCounts != nil {
+		in, out := &in.NodeCounts, &out.NodeCounts
+		*out = make([]KopsNodeCount, len(*in))
+		copy(*out, *in)
+	}
+	if in.InstanceGroups != nil {
+		in, out := &in.InstanceGroups, &out.InstanceGroups
+		*out = make([]KopsInstanceGroup, len(*in))
+		copy(*out, *in)
+	}
+	if in.LastValidated != nil {
+		in, out := &in.LastValidated, &out.LastValidated
+		*out = (*in).DeepCopy()
+	}
 	return
 }
 
@@ -250,66 +879,141 @@ func (in *KopsStatus) DeepCopy() *KopsStatus {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *KubeConfig) DeepCopyInto(out *KubeConfig) {
+func (in *KopsSubnetSpec) DeepCopyInto(out *KopsSubnetSpec) {
 	*out = *in
-	if in.Clusters != nil {
-		in, out := &in.Clusters, &out.Clusters
-		*out = make([]ClusterConfigs, len(*in))
-		copy(*out, *in)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KopsSubnetSpec.
+func (in *KopsSubnetSpec) DeepCopy() *KopsSubnetSpec {
+	if in == nil {
+		return nil
 	}
-	if in.ContextConfigs != nil {
-		in, out := &in.ContextConfigs, &out.ContextConfigs
-		*out = make([]ContextConfigs, len(*in))
-		copy(*out, *in)
+	out := new(KopsSubnetSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KopsTopologySpec) DeepCopyInto(out *KopsTopologySpec) {
+	*out = *in
+	if in.DNS != nil {
+		in, out := &in.DNS, &out.DNS
+		*out = new(KopsDNSSpec)
+		**out = **in
 	}
-	out.Preferences = in.Preferences
-	if in.Users != nil {
-		in, out := &in.Users, &out.Users
-		*out = make([]Users, len(*in))
-		copy(*out, *in)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KopsTopologySpec.
+func (in *KopsTopologySpec) DeepCopy() *KopsTopologySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(KopsTopologySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KubernetesUpgrade) DeepCopyInto(out *KubernetesUpgrade) {
+	*out = *in
+	in.StartTime.DeepCopyInto(&out.StartTime)
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
 	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeConfig.
-func (in *KubeConfig) DeepCopy() *KubeConfig {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubernetesUpgrade.
+func (in *KubernetesUpgrade) DeepCopy() *KubernetesUpgrade {
 	if in == nil {
 		return nil
 	}
-	out := new(KubeConfig)
+	out := new(KubernetesUpgrade)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *User) DeepCopyInto(out *User) {
+func (in *MaintenanceWindow) DeepCopyInto(out *MaintenanceWindow) {
 	*out = *in
+	if in.Days != nil {
+		in, out := &in.Days, &out.Days
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new User.
-func (in *User) DeepCopy() *User {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MaintenanceWindow.
+func (in *MaintenanceWindow) DeepCopy() *MaintenanceWindow {
 	if in == nil {
 		return nil
 	}
-	out := new(User)
+	out := new(MaintenanceWindow)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Users) DeepCopyInto(out *Users) {
+func (in *RollingUpdateSpec) DeepCopyInto(out *RollingUpdateSpec) {
 	*out = *in
-	out.Users = in.Users
+	if in.MasterInterval != nil {
+		in, out := &in.MasterInterval, &out.MasterInterval
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.NodeInterval != nil {
+		in, out := &in.NodeInterval, &out.NodeInterval
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.InstanceGroups != nil {
+		in, out := &in.InstanceGroups, &out.InstanceGroups
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.InstanceGroupRoles != nil {
+		in, out := &in.InstanceGroupRoles, &out.InstanceGroupRoles
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.DrainTimeout != nil {
+		in, out := &in.DrainTimeout, &out.DrainTimeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.ValidationTimeout != nil {
+		in, out := &in.ValidationTimeout, &out.ValidationTimeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.ValidateCount != nil {
+		in, out := &in.ValidateCount, &out.ValidateCount
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxSurge != nil {
+		in, out := &in.MaxSurge, &out.MaxSurge
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+	if in.MaxUnavailable != nil {
+		in, out := &in.MaxUnavailable, &out.MaxUnavailable
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Users.
-func (in *Users) DeepCopy() *Users {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RollingUpdateSpec.
+func (in *RollingUpdateSpec) DeepCopy() *RollingUpdateSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(Users)
+	out := new(RollingUpdateSpec)
 	in.DeepCopyInto(out)
 	return out
 }
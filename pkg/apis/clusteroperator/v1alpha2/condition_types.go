@@ -0,0 +1,49 @@
+package v1alpha2
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ConditionType is the name of an aspect of the cluster the operator reports on
+type ConditionType string
+
+// These are the conditions maintained on a Cluster
+const (
+	ConditionConfigApplied         ConditionType = "ConfigApplied"
+	ConditionCloudResourcesReady   ConditionType = "CloudResourcesReady"
+	ConditionValidated             ConditionType = "Validated"
+	ConditionRollingUpdateComplete ConditionType = "RollingUpdateComplete"
+	ConditionReady                 ConditionType = "Ready"
+	ConditionDeleting              ConditionType = "Deleting"
+	ConditionPlanReady             ConditionType = "PlanReady"
+	ConditionUpgraded              ConditionType = "Upgraded"
+	ConditionDrifted               ConditionType = "Drifted"
+)
+
+// ConditionStatus is the status of a condition, one of True, False or Unknown
+type ConditionStatus string
+
+// These are the valid condition statuses
+const (
+	ConditionTrue    ConditionStatus = "True"
+	ConditionFalse   ConditionStatus = "False"
+	ConditionUnknown ConditionStatus = "Unknown"
+)
+
+// Condition describes one aspect of the observed state of a Cluster. It
+// follows the layout of the upstream metav1.Condition.
+// +k8s:openapi-gen=true
+type Condition struct {
+	// Type of the condition, see ConditionType
+	Type ConditionType `json:"type"`
+	// Status of the condition, one of True, False or Unknown
+	Status ConditionStatus `json:"status"`
+	// ObservedGeneration is the spec generation the condition was set for
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// LastTransitionTime is when the status last changed
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+	// Reason is a CamelCase code for the last transition
+	Reason string `json:"reason,omitempty"`
+	// Message is a human readable description of the last transition
+	Message string `json:"message,omitempty"`
+}
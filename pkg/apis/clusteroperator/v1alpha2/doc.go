@@ -0,0 +1,4 @@
+// Package v1alpha2 contains API Schema definitions for the cluster-operator v1alpha2 API group
+// +k8s:deepcopy-gen=package,register
+// +groupName=cluster-operator.infobloxopen.github.com
+package v1alpha2
@@ -2,15 +2,17 @@ package cluster
 
 import (
 	"context"
-	"github.com/spf13/viper"
-	"os"
+	"fmt"
+	"reflect"
 	"time"
 
+	"github.com/go-logr/logr"
+	"github.com/spf13/viper"
+
 	"github.com/infobloxopen/cluster-operator/kops"
 	clusteroperatorv1alpha1 "github.com/infobloxopen/cluster-operator/pkg/apis/clusteroperator/v1alpha1"
 
 	"github.com/infobloxopen/cluster-operator/utils"
-	corev1 "k8s.io/api/core/v1"
 
 	// "k8s.io/apimachinery/pkg/api/errors"
 
@@ -36,35 +38,64 @@ var log = logf.Log.WithName("controller_cluster")
 // Add creates a new Cluster Controller and adds it to the Manager. The Manager will set fields on the Controller
 // and Start it when the Manager is Started.
 func Add(cfg ReconcilerConfig) error {
-	return add(cfg.Mgr, newReconciler(cfg))
+	if cfg.Reaper.Enabled {
+		if err := cfg.Reaper.Validate(); err != nil {
+			return err
+		}
+	}
+	r := newReconciler(cfg)
+	if err := add(cfg.Mgr, r, cfg.MaxConcurrentReconciles); err != nil {
+		return err
+	}
+	if cfg.Reaper.Enabled {
+		// the reaper takes the same locks, it never deletes a kops cluster
+		// a reconcile is working on
+		return cfg.Mgr.Add(newReaper(cfg, r.locks))
+	}
+	return nil
 }
 
 type ReconcilerConfig struct {
-	Mgr  manager.Manager
-	Reap bool
+	Mgr manager.Manager
+	// Reaper controls the removal of orphaned kops clusters
+	Reaper ReaperConfig
+	// MaxConcurrentReconciles is the number of Clusters reconciled in parallel
+	MaxConcurrentReconciles int
+	// StateStoreConcurrency caps the kops mutations running against one state store
+	StateStoreConcurrency int
+	// KubeconfigSecret controls the Secret the kubeconfig of a cluster is written to
+	KubeconfigSecret KubeconfigSecretConfig
+	// Credentials controls the Secrets the cloud credentials of a cluster are read from
+	Credentials CredentialsConfig
 }
 
-func newReconciler(cfg ReconcilerConfig) reconcile.Reconciler {
-	return &ReconcileCluster{client: cfg.Mgr.GetClient(), scheme: cfg.Mgr.GetScheme(), reap: cfg.Reap}
+func newReconciler(cfg ReconcilerConfig) *ReconcileCluster {
+	return &ReconcileCluster{
+		client: cfg.Mgr.GetClient(),
+		reader: cfg.Mgr.GetAPIReader(),
+		scheme: cfg.Mgr.GetScheme(),
+		locks:  NewLockManager(cfg.StateStoreConcurrency),
+
+		versions: clusterVersions,
+
+		kubeconfigSecret: cfg.KubeconfigSecret,
+		credentials:      cfg.Credentials,
+	}
 }
 
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
-func add(mgr manager.Manager, r reconcile.Reconciler) error {
+func add(mgr manager.Manager, r reconcile.Reconciler, maxConcurrentReconciles int) error {
 	// Create a new controller
-	c, err := controller.New("cluster-controller", mgr, controller.Options{Reconciler: r})
+	c, err := controller.New("cluster-controller", mgr, controller.Options{
+		Reconciler:              r,
+		MaxConcurrentReconciles: maxConcurrentReconciles,
+	})
 	if err != nil {
 		return err
 	}
 
 	pred := predicate.Funcs{
-		UpdateFunc: func(e event.UpdateEvent) bool {
-
-			if e.MetaNew.GetGeneration() == e.MetaOld.GetGeneration() {
-				return false
-			}
-
-			return true
-		},
+		UpdateFunc: clusterChanged,
 		DeleteFunc: func(e event.DeleteEvent) bool {
 			// Evaluates to false if the object has been confirmed deleted.
 			return e.DeleteStateUnknown
@@ -77,17 +108,29 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 		return err
 	}
 
-	// TODO(user): Modify this to be the types you create that are owned by the primary resource
-	// Watch for changes to secondary resource Pods and requeue the owner Cluster
-	err = c.Watch(&source.Kind{Type: &corev1.Pod{}}, &handler.EnqueueRequestForOwner{
-		IsController: true,
-		OwnerType:    &clusteroperatorv1alpha1.Cluster{},
-	})
-	if err != nil {
-		return err
+	return nil
+}
+
+// approvalAnnotations are the annotations users approve pending changes with
+var approvalAnnotations = []string{
+	clusteroperatorv1alpha1.ApprovedPlanAnnotation,
+	clusteroperatorv1alpha1.ApprovedRollingUpdateAnnotation,
+	clusteroperatorv1alpha1.SkipKopsDeleteAnnotation,
+}
+
+// clusterChanged passes updates of the spec and approvals of pending
+// changes, which only change an annotation
+func clusterChanged(e event.UpdateEvent) bool {
+	if e.MetaNew.GetGeneration() != e.MetaOld.GetGeneration() {
+		return true
 	}
 
-	return nil
+	for _, approved := range approvalAnnotations {
+		if e.MetaNew.GetAnnotations()[approved] != e.MetaOld.GetAnnotations()[approved] {
+			return true
+		}
+	}
+	return false
 }
 
 // blank assignment to verify that ReconcileCluster implements reconcile.Reconciler
@@ -98,23 +141,38 @@ type ReconcileCluster struct {
 	// This client, initialized using mgr.Client() above, is a split client
 	// that reads objects from the cache and writes to the apiserver
 	client client.Client
+	// reader reads the credentials Secrets, past the namespaced cache
+	reader client.Reader
 	scheme *runtime.Scheme
-	reap   bool
+	locks  *LockManager
+
+	kubeconfigSecret KubeconfigSecretConfig
+	credentials      CredentialsConfig
+	// versions asks a workload cluster for its Kubernetes versions
+	versions versionFunc
 }
 
+// lockWaitTimeout bounds how long a reconcile waits for a kops mutation lock
+// before giving the worker back and trying again later
+const lockWaitTimeout = 30 * time.Second
+
 // Reconcile reads that state of the cluster for a Cluster object and makes changes based on the state read
 // and what is in the Cluster.Spec
 //
+// Every call runs a single phase of the cluster life cycle (see phases.go), persists the phase it
+// moved to and requeues, so an operator restart resumes at the phase recorded in status.
+//
 // Note:
 // The Controller will requeue the Request to be processed again if the returned error is non-nil or
 // Result.Requeue is true, otherwise upon completion it will remove the work from the queue.
 func (r *ReconcileCluster) Reconcile(request reconcile.Request) (reconcile.Result, error) {
 	reqLogger := log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
 	reqLogger.Info("Reconciling Cluster")
+	ctx := context.Background()
 
 	// Fetch the Cluster instance
 	instance := &clusteroperatorv1alpha1.Cluster{}
-	err := r.client.Get(context.TODO(), request.NamespacedName, instance)
+	err := r.client.Get(ctx, request.NamespacedName, instance)
 	if err != nil {
 		reqLogger.Error(err, "error requesting instance")
 		if errors.IsNotFound(err) {
@@ -126,265 +184,152 @@ func (r *ReconcileCluster) Reconcile(request reconcile.Request) (reconcile.Resul
 		// Error reading the object - requeue the request.
 		return reconcile.Result{}, err
 	}
-	//Finalizer name
-	clusterFinalizer := "cluster.finalizer.cluster-operator.infobloxopen.github.com"
+
+	// Clusters written by earlier versions are moved to the current phases
+	// once, the status is persisted with the result of the phase
+	migrated := migratePhase(instance)
+	phase := currentPhase(instance)
+	if phase == clusteroperatorv1alpha1.ClusterDeleting && !utils.Contains(instance.ObjectMeta.Finalizers, clusterFinalizer) {
+		// Stop reconciliation as the item is being deleted
+		return reconcile.Result{}, nil
+	}
+
+	// Every cluster gets its own workspace for manifests and kubeconfig, kops
+	// reads KUBECONFIG from the workspace environment instead of the process one
+	ws, err := utils.NewWorkspace(viper.GetString("tmp.dir"), string(instance.UID))
+	if err != nil {
+		reqLogger.Error(err, "utils.NewWorkspace Failed")
+		return reconcile.Result{}, err
+	}
+	defer func() {
+		if err := ws.Close(); err != nil {
+			reqLogger.Error(err, "cannot remove workspace", "dir", ws.Dir)
+		}
+	}()
+
+	// A Cluster being deleted goes on to the deleting phase when it cannot
+	// be set up, e.g. its ClusterProvider or credentials Secret went first,
+	// the phase reports why and can leave the kops cluster behind
+	unavailableReason, unavailable := r.setup(ctx, instance, ws)
+	if unavailable != nil && phase != clusteroperatorv1alpha1.ClusterDeleting {
+		return r.setupFailed(ctx, reqLogger, instance, unavailableReason, unavailable)
+	}
+
 	// TODO - We should maybe catch lack of kops configuration earlier in operator startup
-	k, err := kops.NewKops()
+	k, err := kops.NewKops(ws)
 	if err != nil {
 		reqLogger.Error(err, "kops.NewKops Failed")
 		return reconcile.Result{}, err
 	}
 
-	kc := CheckKopsDefaultConfig(instance.Spec)
-	// If the cluster is not waiting for deletion, handle it normally
-	if instance.ObjectMeta.DeletionTimestamp.IsZero() {
-
-		// If no phase set default to pending for the initial phase
-		if instance.Status.Phase == "" {
-			instance.Spec.KopsConfig = CheckKopsDefaultConfig(instance.Spec)
-			// The following routine will remove any clusters from the state store that are not in etcd
-			// This will run whenever a cluster is created on the state store its beeing created in
-			if r.reap == true {
-				//get all clusters in etcd (grabbing only from namespace operator is working in, see fix me)
-				etcdClusters := &clusteroperatorv1alpha1.ClusterList{}
-				err = r.client.List(context.Background(), etcdClusters)
-				if err != nil {
-					log.Error(err, "Error getting list of clusters")
-					os.Exit(1)
-				}
-
-				// List clusters in current state store
-				ssClusters, err := k.ListClusters(instance.Spec.KopsConfig.StateStore)
-				if err != nil {
-					reqLogger.Error(err, "Cannot list clusters")
-					return reconcile.Result{}, err
-				}
-
-				var badClusters []string
-
-				// FIXME This is banking off the fact that the operator only looks for clusters in one
-				// namespace. If that is changed, we need to take into account that the cluster we are looking for
-				// may exist in etcd, just in a different namespace. Need to look into if this will break or not
-				for _, s := range ssClusters {
-					found := false
-					for _, e := range etcdClusters.Items {
-						if s == e.Spec.KopsConfig.Name {
-							found = true
-						}
-					}
-					if !found {
-						badClusters = append(badClusters, s)
-					}
-				}
-
-				if badClusters != nil {
-					reqLogger.Info("Clusters found in state store (" + instance.Spec.KopsConfig.StateStore + ") that are not in etcd")
-					for _, cluster := range badClusters {
-						reqLogger.Info("Deleting cluster " + cluster)
-						tempKopsConfig := clusteroperatorv1alpha1.KopsConfig{StateStore: instance.Spec.KopsConfig.StateStore, Name: cluster}
-						err := k.DeleteCluster(tempKopsConfig)
-						if err != nil {
-							reqLogger.Error(err, "Cannot delete cluster from stat store")
-							return reconcile.Result{}, err
-						}
-					}
+	c := &clusterContext{
+		instance:          instance,
+		kops:              k,
+		kc:                instance.Spec.KopsConfig,
+		log:               reqLogger.WithValues("Phase", phase),
+		unavailable:       unavailable,
+		unavailableReason: unavailableReason,
+	}
 
+	c.log.Info("Running phase")
+	original := instance.Status.DeepCopy()
+	next, result, err := r.phases()[phase](ctx, c)
+	if err != nil {
+		setPhaseFailed(instance, phase, err)
+		if !permanent(err) || phase == clusteroperatorv1alpha1.ClusterDeleting {
+			// Throttling, network and API server errors are retried with the
+			// controller back-off, deletion is never given up on
+			c.log.Error(err, "phase failed, retrying", "reason", errorReason(err))
+			if !reflect.DeepEqual(original, &instance.Status) {
+				if err := r.client.Status().Update(ctx, instance); err != nil {
+					c.log.Error(err, "cannot record failure in status")
 				}
 			}
-
-			instance.Status.Phase = clusteroperatorv1alpha1.ClusterPending
-			instance.Spec.KopsConfig = kc
-		}
-		// Add the finalizer and update the object
-		if !utils.Contains(instance.ObjectMeta.Finalizers, clusterFinalizer) {
-			instance.ObjectMeta.Finalizers = append(instance.ObjectMeta.Finalizers, clusterFinalizer)
-			if err := r.client.Update(context.TODO(), instance); err != nil {
-				return reconcile.Result{}, err
-			}
-		}
-
-		//go through the cycle of phases
-		//PENDING: CREATING CLUSTER
-		reqLogger.Info("Phase: PENDING")
-		//creating cluster
-		err := k.ReplaceCluster(instance.Spec)
-
-		if err != nil {
-			reqLogger.Error(err, "error creating cluster")
-			return reconcile.Result{}, err
-		}
-		reqLogger.Info("Cluster Config Updated")
-
-		instance.Status.Phase = clusteroperatorv1alpha1.ClusterUpdate
-		if err := r.client.Status().Update(context.TODO(), instance); err != nil {
-			return reconcile.Result{}, err
-		}
-
-		//UPDATIG: UPDATING CLUSTER
-		reqLogger.Info("Phase: UPDATE")
-
-		err = k.UpdateCluster(kc)
-
-		if err != nil {
-			reqLogger.Error(err, "error updating cluster")
-			return reconcile.Result{}, err
-		}
-
-		reqLogger.Info("Cluster Updated")
-
-		//get kubeconfig
-		var mode os.FileMode = 509
-		err = os.MkdirAll("./tmp", mode)
-		if err != nil {
-			return reconcile.Result{}, err
-		}
-
-		_, err = os.Create("tmp/config-" + kc.Name)
-		if err != nil {
-			return reconcile.Result{}, err
-		}
-
-		var config clusteroperatorv1alpha1.KubeConfig
-		config, err = k.GetKubeConfig(kc)
-		if err != nil {
-			return reconcile.Result{}, err
-		}
-
-		instance.Status.KubeConfig = config
-		reqLogger.Info("KUBECONFIG Updated")
-
-		//rolling udpates
-		os.Setenv("KUBECONFIG", "tmp/config-"+kc.Name)
-
-		//TODO: Right now, using defaults for intervals. Need to make changable
-		// Some changes will require rebuilding the nodes (for example, resizing nodes or changing the AMI)
-		// We call rolling-update to apply these changes
-		if instance.Status.Validated {
-			err = k.RollingUpdateCluster(kc)
-			if err != nil {
-				reqLogger.Error(err, "error performing rolling update on cluster")
-				return reconcile.Result{}, err
-			}
-			reqLogger.Info("Rolling Update Complete")
-		} else {
-			reqLogger.Info("Cluster not validated yet... Skipping rolling update for now")
-		}
-
-		instance.Status.Phase = clusteroperatorv1alpha1.ClusterSetup
-		if err := r.client.Status().Update(context.TODO(), instance); err != nil {
 			return reconcile.Result{}, err
 		}
-		// SETUP: CLUSTER VALIDATION
-		reqLogger.Info("Phase: SETUP")
-
-		// Setenv required if not using default .kube/config,
-		// the --kubeconfig option does not currently work for kops validate (1.18.2-alpha2)
-		os.Setenv("KUBECONFIG", "tmp/config-"+kc.Name)
-		status, err := k.ValidateCluster(kc)
-
-		instance.Status.KopsStatus = clusteroperatorv1alpha1.KopsStatus{}
-		if err != nil {
-			reqLogger.Info("Cluster Not Ready")
-			instance.Status.Validated = false
-			if err := r.client.Status().Update(context.TODO(), instance); err != nil {
-				return reconcile.Result{}, err
-			}
-			// instance.Status.Phase = clusteroperatorv1alpha1.ClusterPending
-		} else if len(status.Nodes) > 0 {
-			instance.Status.KopsStatus.Nodes = status.Nodes
-			reqLogger.Info("Cluster Created")
-			instance.Status.Phase = clusteroperatorv1alpha1.ClusterDone
-			instance.Status.Validated = true
-			reqLogger.Info("Phase: DONE")
-			if err := r.client.Status().Update(context.TODO(), instance); err != nil {
-				return reconcile.Result{}, err
-			}
-			//requeues every ten minutes to make sure its synced if any manual changes were done
-			return reconcile.Result{RequeueAfter: time.Minute * 10}, nil
-		} else {
-			// FIXME - If we get this state try validate again!!!
-			reqLogger.Info("Validate Returned Unexpected Result")
-			// instance.Status.Phase = clusteroperatorv1alpha1.ClusterPending
-		}
-
-		//It did not finish validating, requeue in five minutes
-		return reconcile.Result{RequeueAfter: time.Minute * 5}, nil
-
-	} else if utils.Contains(instance.ObjectMeta.Finalizers, clusterFinalizer) {
-
-		//check if cluster still exists
-		exists, err := k.GetCluster(instance.Spec.KopsConfig)
-		if !exists {
-			reqLogger.WithValues("error", err).Info("Cluster is already deleted...")
-		} else if err != nil {
-			reqLogger.WithValues("error", err).Info("Error getting cluster")
+		c.log.Error(err, "phase failed", "reason", errorReason(err))
+		instance.Status.Phase = clusteroperatorv1alpha1.ClusterFailed
+		instance.Status.FailedPhase = phase
+		instance.Status.Message = err.Error()
+		setReady(instance)
+		if err := r.client.Status().Update(ctx, instance); err != nil {
 			return reconcile.Result{}, err
-		} else {
-			err = k.DeleteCluster(instance.Spec.KopsConfig)
-			if err != nil {
-				//error deleting cluster
-				return reconcile.Result{}, err
-			}
 		}
+		return reconcile.Result{RequeueAfter: failedRetryInterval}, nil
+	}
 
-		// our finalizer is present, so delete cluster first
-		// remove our finalizer from the list and update it.
-		instance.ObjectMeta.Finalizers = utils.Remove(instance.ObjectMeta.Finalizers, clusterFinalizer)
-
-		if err := r.client.Update(context.TODO(), instance); err != nil {
+	if next == "" {
+		// the handler is done with the object, e.g. the finalizer was removed
+		return result, nil
+	}
+	transition := next != instance.Status.Phase
+	if transition {
+		c.log.Info("Phase transition", "Next", next)
+		instance.Status.Phase = next
+		instance.Status.FailedPhase = ""
+		instance.Status.Message = ""
+	}
+	setReady(instance)
+	if transition || migrated || !reflect.DeepEqual(original, &instance.Status) {
+		if err := r.client.Status().Update(ctx, instance); err != nil {
 			return reconcile.Result{}, err
 		}
-
-		//TODO: error when resource edited and requeued, but already deleted. Do we want that?
-
 	}
-	// Stop reconciliation as the item is being deleted
-	return reconcile.Result{}, nil
-}
-
-// Get Kops Default Config Resource
-func CheckKopsDefaultConfig(c clusteroperatorv1alpha1.ClusterSpec) clusteroperatorv1alpha1.KopsConfig {
-	// If KopsConfig is not defined in CR, use default
-	// TODO: Right now this only checks if the values are there. Eventually
-	// we want to use a few inputs to pull information from the CMDB or
-	// another controller that would hold the config information based on
-	// the supplied infra info
-
-	// Due to changes to use Kops manifests, the only required fields are Name and StateStore
-	defaultConfig := clusteroperatorv1alpha1.KopsConfig{
-		Name:        c.Name + "." + viper.GetString("kops.cluster.dns.zone"),
-		StateStore:  viper.GetString("kops.state.store"),
-		// MasterCount: 1,
-		// MasterEc2:   "t2.micro",
-		// WorkerCount: 2,
-		// WorkerEc2:   "t2.micro",
-		// Vpc:         "vpc-0a75b33895655b46a",
-		// Zones:       []string{"us-east-2a", "us-east-2b"},
+	if transition && result.RequeueAfter == 0 {
+		// run the next phase right away
+		result.Requeue = true
 	}
+	return result, nil
+}
 
-	if c.KopsConfig.MasterCount > 0 {
-		defaultConfig.MasterCount = c.KopsConfig.MasterCount
+// setup reads the ClusterProvider of instance, passes the credentials of
+// instance to the kops processes run in ws and defaults its spec. It returns
+// the reason and error when kops cannot run for instance.
+func (r *ReconcileCluster) setup(ctx context.Context, instance *clusteroperatorv1alpha1.Cluster, ws *utils.Workspace) (string, error) {
+	provider, err := r.getProvider(ctx, instance)
+	if err != nil {
+		return reasonProviderUnavailable, err
 	}
 
-	if len(c.KopsConfig.MasterEc2) != 0 {
-		defaultConfig.MasterEc2 = c.KopsConfig.MasterEc2
+	// The credentials of the cluster only go to the kops processes run in
+	// its workspace
+	if err := r.setCredentials(ctx, instance, provider, ws); err != nil {
+		return reasonCredentialsUnavailable, err
 	}
 
-	if (c.KopsConfig.WorkerCount) > 0 {
-		defaultConfig.WorkerCount = c.KopsConfig.WorkerCount
+	// The defaulting webhook sets these on admission, Clusters admitted
+	// without it get the same defaults here
+	instance.DefaultWithProvider(provider, viper.GetString("kops.cluster.dns.zone"), viper.GetString("kops.state.store"))
+	if instance.Spec.KopsConfig.StateStore == "" {
+		return reasonProviderUnavailable, fmt.Errorf("no kops state store, set spec.providerRef or configure the operator with one")
 	}
-
-	if len(c.KopsConfig.WorkerEc2) > 0 {
-		defaultConfig.WorkerEc2 = c.KopsConfig.WorkerEc2
+	if instance.Spec.KopsConfig.Name == "" {
+		return reasonProviderUnavailable, fmt.Errorf("no kops cluster name, set spec.providerRef or configure the operator with a DNS zone")
 	}
-
-	if len(c.KopsConfig.Vpc) > 0 {
-		defaultConfig.Vpc = c.KopsConfig.Vpc
+	if _, err := kops.NormalizeStateStore(instance.Spec.KopsConfig.StateStore); err != nil {
+		return reasonInvalidStateStore, err
 	}
+	return "", nil
+}
 
-	if len(c.KopsConfig.Zones) > 0 {
-		c.KopsConfig.Zones = c.KopsConfig.Zones
+// setupFailed reports err, which keeps instance from being reconciled at all,
+// on the Ready condition and retries with the controller back-off
+func (r *ReconcileCluster) setupFailed(ctx context.Context, log logr.Logger, instance *clusteroperatorv1alpha1.Cluster, reason string, err error) (reconcile.Result, error) {
+	log.Error(err, "cannot reconcile", "reason", reason)
+	original := instance.Status.DeepCopy()
+	setCondition(instance, clusteroperatorv1alpha1.ConditionReady, clusteroperatorv1alpha1.ConditionFalse, reason, err.Error())
+	if !reflect.DeepEqual(original, &instance.Status) {
+		if err := r.client.Status().Update(ctx, instance); err != nil {
+			log.Error(err, "cannot record failure in status")
+		}
 	}
+	return reconcile.Result{}, err
+}
 
-	return defaultConfig
+// lock takes the kops mutation locks for the cluster, waiting at most
+// lockWaitTimeout for them
+func (r *ReconcileCluster) lock(ctx context.Context, kc clusteroperatorv1alpha1.KopsConfig) (func(), error) {
+	lockCtx, cancel := context.WithTimeout(ctx, lockWaitTimeout)
+	defer cancel()
+	return r.locks.Lock(lockCtx, kc.Name, kc.StateStore)
 }
@@ -0,0 +1,132 @@
+package cluster
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	clusteroperatorv1alpha1 "github.com/infobloxopen/cluster-operator/pkg/apis/clusteroperator/v1alpha1"
+	"github.com/infobloxopen/cluster-operator/utils"
+)
+
+// Reasons used on conditions besides the kops error reasons from utils
+const (
+	reasonSucceeded        = "Succeeded"
+	reasonInProgress       = "InProgress"
+	reasonValidationFailed = "ValidationFailed"
+	reasonNotValidated     = "NotValidated"
+	reasonDeletionStarted  = "DeletionStarted"
+	reasonNoChanges        = "NoChanges"
+	reasonApproved         = "Approved"
+	reasonAwaitingApproval = "AwaitingApproval"
+	reasonSkipped          = "Skipped"
+
+	reasonOutsideMaintenanceWindow = "OutsideMaintenanceWindow"
+	reasonInvalidMaintenanceWindow = "InvalidMaintenanceWindow"
+	reasonUnsupportedUpgrade       = "UnsupportedUpgrade"
+	reasonVersionMismatch          = "VersionMismatch"
+	reasonNoDrift                  = "NoDrift"
+	reasonDriftDetected            = "DriftDetected"
+	reasonCorrectingDrift          = "CorrectingDrift"
+	reasonCredentialsUnavailable   = "CredentialsUnavailable"
+	reasonProviderUnavailable      = "ProviderUnavailable"
+	reasonInvalidStateStore        = "InvalidStateStore"
+	reasonKubeconfigSecretConflict = "KubeconfigSecretConflict"
+)
+
+// phaseConditions is the condition the outcome of each phase is reported on
+var phaseConditions = map[clusteroperatorv1alpha1.ClusterPhase]clusteroperatorv1alpha1.ConditionType{
+	clusteroperatorv1alpha1.ClusterConfiguring:   clusteroperatorv1alpha1.ConditionConfigApplied,
+	clusteroperatorv1alpha1.ClusterApplying:      clusteroperatorv1alpha1.ConditionCloudResourcesReady,
+	clusteroperatorv1alpha1.ClusterRollingUpdate: clusteroperatorv1alpha1.ConditionRollingUpdateComplete,
+	clusteroperatorv1alpha1.ClusterValidating:    clusteroperatorv1alpha1.ConditionValidated,
+	clusteroperatorv1alpha1.ClusterReady:         clusteroperatorv1alpha1.ConditionValidated,
+	clusteroperatorv1alpha1.ClusterDeleting:      clusteroperatorv1alpha1.ConditionDeleting,
+}
+
+// setCondition sets condition t on the cluster for its current generation
+func setCondition(instance *clusteroperatorv1alpha1.Cluster, t clusteroperatorv1alpha1.ConditionType, status clusteroperatorv1alpha1.ConditionStatus, reason, message string) {
+	clusteroperatorv1alpha1.SetCondition(&instance.Status.Conditions, clusteroperatorv1alpha1.Condition{
+		Type:               t,
+		Status:             status,
+		ObservedGeneration: instance.Generation,
+		Reason:             reason,
+		Message:            message,
+	})
+}
+
+// setPhaseFailed reports err on the condition of phase. The reason is the
+// classified kops error or the reason of a spec error, other errors are
+// Unknown.
+func setPhaseFailed(instance *clusteroperatorv1alpha1.Cluster, phase clusteroperatorv1alpha1.ClusterPhase, err error) {
+	t, ok := phaseConditions[phase]
+	if !ok {
+		return
+	}
+	status := clusteroperatorv1alpha1.ConditionFalse
+	if t == clusteroperatorv1alpha1.ConditionDeleting {
+		// deletion is retried until it succeeds, it is still in progress
+		status = clusteroperatorv1alpha1.ConditionTrue
+	}
+	setCondition(instance, t, status, errorReason(err), err.Error())
+}
+
+// errorReason is the reason err is reported with on conditions
+func errorReason(err error) string {
+	var specErr *specError
+	if errors.As(err, &specErr) {
+		return specErr.reason
+	}
+	return string(utils.ErrorReasonFor(err))
+}
+
+// setReady derives the Ready condition from the phase the cluster moves to,
+// a Failed cluster carries the reason of the condition that failed
+func setReady(instance *clusteroperatorv1alpha1.Cluster) {
+	phase := instance.Status.Phase
+	switch {
+	case phase == clusteroperatorv1alpha1.ClusterReady:
+		setCondition(instance, clusteroperatorv1alpha1.ConditionReady, clusteroperatorv1alpha1.ConditionTrue, reasonSucceeded, "Cluster is validated and matches the spec")
+	case phase == clusteroperatorv1alpha1.ClusterFailed:
+		setCondition(instance, clusteroperatorv1alpha1.ConditionReady, clusteroperatorv1alpha1.ConditionFalse, failedReason(instance), instance.Status.Message)
+	default:
+		setCondition(instance, clusteroperatorv1alpha1.ConditionReady, clusteroperatorv1alpha1.ConditionFalse, string(phase), "Cluster is in phase "+string(phase))
+	}
+}
+
+// failedReason is the reason on the condition of the failed phase
+func failedReason(instance *clusteroperatorv1alpha1.Cluster) string {
+	if t, ok := phaseConditions[instance.Status.FailedPhase]; ok {
+		if c := clusteroperatorv1alpha1.FindCondition(instance.Status.Conditions, t); c != nil && c.Reason != "" {
+			return c.Reason
+		}
+	}
+	return string(utils.ReasonUnknown)
+}
+
+// maxMessageFailures is how many validation failures are spelled out in the
+// Validated condition, all of them are in status.kops_status.failures
+const maxMessageFailures = 3
+
+// validationMessage summarizes the failures reported by kops validate, only
+// the first line of each failure message is used
+func validationMessage(failures []clusteroperatorv1alpha1.KopsFailure) string {
+	var msgs []string
+	for i, f := range failures {
+		if i == maxMessageFailures {
+			msgs = append(msgs, fmt.Sprintf("and %d more", len(failures)-i))
+			break
+		}
+		msgs = append(msgs, fmt.Sprintf("%s %s: %s", f.Type, f.Name, strings.SplitN(f.Message, "\n", 2)[0]))
+	}
+	return fmt.Sprintf("%d validation failures: %s", len(failures), strings.Join(msgs, "; "))
+}
+
+// nodesMessage reports the ready nodes per role of a validated cluster
+func nodesMessage(counts []clusteroperatorv1alpha1.KopsNodeCount) string {
+	var msgs []string
+	for _, c := range counts {
+		msgs = append(msgs, fmt.Sprintf("%d/%d %s", c.Ready, c.Total, c.Role))
+	}
+	return "Cluster passed kops validate, ready nodes: " + strings.Join(msgs, ", ")
+}
@@ -0,0 +1,104 @@
+package cluster
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	clusteroperatorv1alpha1 "github.com/infobloxopen/cluster-operator/pkg/apis/clusteroperator/v1alpha1"
+	"github.com/infobloxopen/cluster-operator/utils"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSetCondition(t *testing.T) {
+	instance := &clusteroperatorv1alpha1.Cluster{}
+	instance.Generation = 1
+
+	setCondition(instance, clusteroperatorv1alpha1.ConditionValidated, clusteroperatorv1alpha1.ConditionFalse, reasonValidationFailed, "first")
+	c := clusteroperatorv1alpha1.FindCondition(instance.Status.Conditions, clusteroperatorv1alpha1.ConditionValidated)
+	if c == nil || c.LastTransitionTime.IsZero() {
+		t.Fatal("Expected condition with transition time got", c)
+	}
+	past := metav1.NewTime(time.Now().Add(-time.Hour))
+	c.LastTransitionTime = past
+
+	// same status keeps the transition time
+	instance.Generation = 2
+	setCondition(instance, clusteroperatorv1alpha1.ConditionValidated, clusteroperatorv1alpha1.ConditionFalse, reasonValidationFailed, "second")
+	c = clusteroperatorv1alpha1.FindCondition(instance.Status.Conditions, clusteroperatorv1alpha1.ConditionValidated)
+	if !c.LastTransitionTime.Equal(&past) {
+		t.Error("Expected transition time to be kept got", c.LastTransitionTime)
+	}
+	if c.Message != "second" || c.ObservedGeneration != 2 {
+		t.Error("Expected message and generation to be updated got", c)
+	}
+
+	// status change moves it
+	setCondition(instance, clusteroperatorv1alpha1.ConditionValidated, clusteroperatorv1alpha1.ConditionTrue, reasonSucceeded, "")
+	c = clusteroperatorv1alpha1.FindCondition(instance.Status.Conditions, clusteroperatorv1alpha1.ConditionValidated)
+	if c.LastTransitionTime.Equal(&past) {
+		t.Error("Expected transition time to move")
+	}
+	if len(instance.Status.Conditions) != 1 {
+		t.Error("Expected 1 condition got", len(instance.Status.Conditions))
+	}
+}
+
+func TestSetPhaseFailed(t *testing.T) {
+	throttled := &utils.CommandError{Reason: utils.ReasonThrottled, Err: errors.New("exit status 1")}
+	cases := []struct {
+		phase     clusteroperatorv1alpha1.ClusterPhase
+		err       error
+		condition clusteroperatorv1alpha1.ConditionType
+		status    clusteroperatorv1alpha1.ConditionStatus
+		reason    string
+	}{
+		{clusteroperatorv1alpha1.ClusterConfiguring, throttled, clusteroperatorv1alpha1.ConditionConfigApplied, clusteroperatorv1alpha1.ConditionFalse, "Throttled"},
+		{clusteroperatorv1alpha1.ClusterApplying, errors.New("conflict"), clusteroperatorv1alpha1.ConditionCloudResourcesReady, clusteroperatorv1alpha1.ConditionFalse, "Unknown"},
+		{clusteroperatorv1alpha1.ClusterDeleting, throttled, clusteroperatorv1alpha1.ConditionDeleting, clusteroperatorv1alpha1.ConditionTrue, "Throttled"},
+	}
+
+	for _, c := range cases {
+		instance := &clusteroperatorv1alpha1.Cluster{}
+		setPhaseFailed(instance, c.phase, c.err)
+		cond := clusteroperatorv1alpha1.FindCondition(instance.Status.Conditions, c.condition)
+		if cond == nil {
+			t.Errorf("%s: expected condition %s", c.phase, c.condition)
+			continue
+		}
+		if cond.Status != c.status || cond.Reason != c.reason {
+			t.Errorf("%s: expected %s/%s got %s/%s", c.phase, c.status, c.reason, cond.Status, cond.Reason)
+		}
+	}
+}
+
+func TestSetReady(t *testing.T) {
+	instance := &clusteroperatorv1alpha1.Cluster{}
+	instance.Status.Phase = clusteroperatorv1alpha1.ClusterReady
+	setReady(instance)
+	if !clusteroperatorv1alpha1.IsConditionTrue(instance.Status.Conditions, clusteroperatorv1alpha1.ConditionReady) {
+		t.Error("Expected Ready for phase", instance.Status.Phase)
+	}
+
+	setPhaseFailed(instance, clusteroperatorv1alpha1.ClusterApplying, &utils.CommandError{Reason: utils.ReasonUnauthorized, Err: errors.New("exit status 1")})
+	instance.Status.Phase = clusteroperatorv1alpha1.ClusterFailed
+	instance.Status.FailedPhase = clusteroperatorv1alpha1.ClusterApplying
+	setReady(instance)
+	c := clusteroperatorv1alpha1.FindCondition(instance.Status.Conditions, clusteroperatorv1alpha1.ConditionReady)
+	if c.Status != clusteroperatorv1alpha1.ConditionFalse || c.Reason != "Unauthorized" {
+		t.Error("Expected Ready False with reason Unauthorized got", c)
+	}
+}
+
+func TestValidationMessage(t *testing.T) {
+	failures := []clusteroperatorv1alpha1.KopsFailure{
+		{Type: "dns", Name: "apiserver", Message: "Validation Failed\n\nThe dns-controller Kubernetes deployment has not updated"},
+		{Type: "Node", Name: "ip-1", Message: `node "ip-1" is not ready`},
+		{Type: "Pod", Name: "kube-system/kube-dns", Message: `kube-system pod "kube-dns" is pending`},
+		{Type: "InstanceGroup", Name: "nodes", Message: `InstanceGroup "nodes" did not have enough nodes 1 vs 2`},
+	}
+	expected := `4 validation failures: dns apiserver: Validation Failed; Node ip-1: node "ip-1" is not ready; Pod kube-system/kube-dns: kube-system pod "kube-dns" is pending; and 1 more`
+	if got := validationMessage(failures); got != expected {
+		t.Errorf("Expected %q got %q", expected, got)
+	}
+}
@@ -0,0 +1,199 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	clusteroperatorv1alpha1 "github.com/infobloxopen/cluster-operator/pkg/apis/clusteroperator/v1alpha1"
+	"github.com/infobloxopen/cluster-operator/utils"
+	"github.com/spf13/viper"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// CredentialsConfig controls the Secrets Clusters take their cloud credentials
+// from
+type CredentialsConfig struct {
+	// AllowedNamespaces are the namespaces besides their own that Clusters
+	// may reference credentials Secrets in, * allows all
+	AllowedNamespaces []string
+}
+
+// Files and profiles written to the workspace when a role is assumed
+const (
+	awsConfigFile      = "aws-config"
+	awsCredentialsFile = "aws-credentials"
+	awsProfile         = "cluster-operator"
+	awsSourceProfile   = "cluster-operator-source"
+)
+
+// awsCredentials are static AWS keys
+type awsCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// operatorCredentials are the AWS keys the operator runs with
+func operatorCredentials() awsCredentials {
+	return awsCredentials{
+		AccessKeyID:     viper.GetString("aws.access.key.id"),
+		SecretAccessKey: viper.GetString("aws.secret.access.key"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+}
+
+// credentialsSource is where the kops processes of a cluster take their cloud
+// credentials and region from, the zero value runs them with those of the
+// operator
+type credentialsSource struct {
+	// Secret holding the credentials, empty for the operator credentials
+	Secret types.NamespacedName
+	// Region of the ClusterProvider, empty for the operator region
+	Region string
+}
+
+// clusterCredentials returns the credentials source of instance: its own
+// credentials Secret, or the one of its provider. Secrets of Clusters must
+// be in their namespace or one of allowed, and the provider must let the
+// namespace of instance use it.
+func clusterCredentials(instance *clusteroperatorv1alpha1.Cluster, provider *clusteroperatorv1alpha1.ClusterProvider, allowed []string) (credentialsSource, error) {
+	if provider != nil {
+		if err := provider.ValidateAccess(instance.Namespace, allowed); err != nil {
+			return credentialsSource{}, err
+		}
+	}
+	src, err := providerSource(provider)
+	if instance.Spec.CredentialsRef == nil {
+		return src, err
+	}
+	if err := instance.ValidateCredentialsRef(allowed); err != nil {
+		return src, err
+	}
+	src.Secret = types.NamespacedName{Namespace: instance.CredentialsNamespace(), Name: instance.Spec.CredentialsRef.Name}
+	return src, nil
+}
+
+// providerSource returns the credentials source of provider, the operator
+// credentials for no provider
+func providerSource(provider *clusteroperatorv1alpha1.ClusterProvider) (credentialsSource, error) {
+	src := credentialsSource{}
+	if provider == nil {
+		return src, nil
+	}
+	src.Region = provider.Spec.Region
+	if provider.Spec.CredentialsRef == nil {
+		return src, nil
+	}
+	// ClusterProviders are set up by cluster admins, their Secrets are not
+	// limited to the allowed namespaces. Who may use them is checked by
+	// clusterCredentials.
+	var err error
+	src.Secret, err = providerCredentials(provider)
+	return src, err
+}
+
+// setCredentials reads the credentials Secret of instance, or the one of its
+// provider, and passes the credentials in it to the kops processes run in ws,
+// and to no others. The kops processes of a Cluster without either run with
+// the operator credentials. The region of the provider goes along with them.
+func (r *ReconcileCluster) setCredentials(ctx context.Context, instance *clusteroperatorv1alpha1.Cluster, provider *clusteroperatorv1alpha1.ClusterProvider, ws *utils.Workspace) error {
+	src, err := clusterCredentials(instance, provider, r.credentials.AllowedNamespaces)
+	if err != nil {
+		return err
+	}
+	return useCredentials(ctx, r.reader, ws, src)
+}
+
+// useCredentials passes the credentials of src to the kops processes run in ws
+func useCredentials(ctx context.Context, reader client.Reader, ws *utils.Workspace, src credentialsSource) error {
+	if src.Region != "" {
+		ws.SetEnv("AWS_REGION", src.Region)
+	}
+	if src.Secret.Name == "" {
+		return nil
+	}
+
+	// the Secret can be outside the namespaces the cache watches
+	secret := &corev1.Secret{}
+	if err := reader.Get(ctx, src.Secret, secret); err != nil {
+		return fmt.Errorf("cannot read credentials Secret %s: %v", src.Secret, err)
+	}
+	if err := exportCredentials(ws, secret.Data, operatorCredentials()); err != nil {
+		return fmt.Errorf("credentials Secret %s: %v", src.Secret, err)
+	}
+	return nil
+}
+
+// exportCredentials sets up the environment of ws for the credentials Secret
+// data. Static keys are exported as they are. A role is assumed through an
+// AWS profile written to the workspace, with the keys of the Secret when it
+// has any and the operator keys otherwise.
+func exportCredentials(ws *utils.Workspace, data map[string][]byte, operator awsCredentials) error {
+	keys := awsCredentials{
+		AccessKeyID:     string(data[clusteroperatorv1alpha1.CredentialsAccessKeyID]),
+		SecretAccessKey: string(data[clusteroperatorv1alpha1.CredentialsSecretAccessKey]),
+		SessionToken:    string(data[clusteroperatorv1alpha1.CredentialsSessionToken]),
+	}
+	role := string(data[clusteroperatorv1alpha1.CredentialsRoleARN])
+	externalID := string(data[clusteroperatorv1alpha1.CredentialsExternalID])
+
+	if (keys.AccessKeyID == "") != (keys.SecretAccessKey == "") {
+		return fmt.Errorf("%s and %s must be set together", clusteroperatorv1alpha1.CredentialsAccessKeyID, clusteroperatorv1alpha1.CredentialsSecretAccessKey)
+	}
+	if keys.AccessKeyID == "" && role == "" {
+		return fmt.Errorf("neither %s and %s nor %s are set", clusteroperatorv1alpha1.CredentialsAccessKeyID, clusteroperatorv1alpha1.CredentialsSecretAccessKey, clusteroperatorv1alpha1.CredentialsRoleARN)
+	}
+
+	if role == "" {
+		ws.SetEnv("AWS_ACCESS_KEY_ID", keys.AccessKeyID)
+		ws.SetEnv("AWS_SECRET_ACCESS_KEY", keys.SecretAccessKey)
+		// a session token of the operator does not belong to these keys
+		ws.SetEnv("AWS_SESSION_TOKEN", keys.SessionToken)
+		return nil
+	}
+
+	source := keys
+	if source.AccessKeyID == "" {
+		source = operator
+	}
+	if source.AccessKeyID == "" || source.SecretAccessKey == "" {
+		return fmt.Errorf("no keys to assume role %s with", role)
+	}
+	for _, value := range []string{source.AccessKeyID, source.SecretAccessKey, source.SessionToken, role, externalID} {
+		if strings.ContainsAny(value, "\r\n") {
+			return fmt.Errorf("credentials may not contain line breaks")
+		}
+	}
+
+	config := fmt.Sprintf("[profile %s]\nrole_arn = %s\nsource_profile = %s\nrole_session_name = %s\n", awsProfile, role, awsSourceProfile, awsProfile)
+	if externalID != "" {
+		config += "external_id = " + externalID + "\n"
+	}
+	credentials := fmt.Sprintf("[%s]\naws_access_key_id = %s\naws_secret_access_key = %s\n", awsSourceProfile, source.AccessKeyID, source.SecretAccessKey)
+	if source.SessionToken != "" {
+		credentials += "aws_session_token = " + source.SessionToken + "\n"
+	}
+
+	configPath, err := ws.WriteFile(awsConfigFile, []byte(config))
+	if err != nil {
+		return err
+	}
+	credentialsPath, err := ws.WriteFile(awsCredentialsFile, []byte(credentials))
+	if err != nil {
+		return err
+	}
+	ws.SetEnv("AWS_CONFIG_FILE", configPath)
+	ws.SetEnv("AWS_SHARED_CREDENTIALS_FILE", credentialsPath)
+	ws.SetEnv("AWS_PROFILE", awsProfile)
+	ws.SetEnv("AWS_SDK_LOAD_CONFIG", "1")
+	// keys in the environment take precedence over the profile, the
+	// operator keys must not be used instead of the role
+	ws.SetEnv("AWS_ACCESS_KEY_ID", "")
+	ws.SetEnv("AWS_SECRET_ACCESS_KEY", "")
+	ws.SetEnv("AWS_SESSION_TOKEN", "")
+	return nil
+}
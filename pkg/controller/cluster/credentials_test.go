@@ -0,0 +1,127 @@
+package cluster
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	clusteroperatorv1alpha1 "github.com/infobloxopen/cluster-operator/pkg/apis/clusteroperator/v1alpha1"
+	"github.com/infobloxopen/cluster-operator/utils"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestExportCredentials(t *testing.T) {
+	operator := awsCredentials{AccessKeyID: "OPERATOR", SecretAccessKey: "operator-secret"}
+	tests := []struct {
+		name        string
+		data        map[string]string
+		env         []string
+		config      []string
+		credentials []string
+		err         bool
+	}{
+		{
+			name: "static keys",
+			data: map[string]string{"accessKeyID": "KEY", "secretAccessKey": "secret"},
+			env:  []string{"AWS_ACCESS_KEY_ID=KEY", "AWS_SECRET_ACCESS_KEY=secret", "AWS_SESSION_TOKEN="},
+		},
+		{
+			name:        "assume role with the operator keys",
+			data:        map[string]string{"roleARN": "arn:aws:iam::123456789012:role/kops", "externalID": "team"},
+			env:         []string{"AWS_PROFILE=cluster-operator", "AWS_SDK_LOAD_CONFIG=1", "AWS_ACCESS_KEY_ID="},
+			config:      []string{"role_arn = arn:aws:iam::123456789012:role/kops", "external_id = team", "source_profile = cluster-operator-source"},
+			credentials: []string{"aws_access_key_id = OPERATOR", "aws_secret_access_key = operator-secret"},
+		},
+		{
+			name:        "assume role with the Secret keys",
+			data:        map[string]string{"roleARN": "arn:aws:iam::123456789012:role/kops", "accessKeyID": "KEY", "secretAccessKey": "secret", "sessionToken": "token"},
+			env:         []string{"AWS_PROFILE=cluster-operator"},
+			credentials: []string{"aws_access_key_id = KEY", "aws_secret_access_key = secret", "aws_session_token = token"},
+		},
+		{
+			name: "key without secret",
+			data: map[string]string{"accessKeyID": "KEY"},
+			err:  true,
+		},
+		{
+			name: "empty",
+			data: map[string]string{},
+			err:  true,
+		},
+		{
+			name: "line break",
+			data: map[string]string{"roleARN": "arn\n[profile other]"},
+			err:  true,
+		},
+	}
+
+	for _, test := range tests {
+		root, err := ioutil.TempDir("", "credentials")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(root)
+		ws, err := utils.NewWorkspace(root, "test")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		data := map[string][]byte{}
+		for k, v := range test.data {
+			data[k] = []byte(v)
+		}
+		err = exportCredentials(ws, data, operator)
+		if (err != nil) != test.err {
+			t.Errorf("%s: expected error %v got %v", test.name, test.err, err)
+			continue
+		}
+
+		env := strings.Join(ws.Env(), "\n") + "\n"
+		for _, e := range test.env {
+			if !strings.Contains(env, e+"\n") {
+				t.Errorf("%s: expected %s in the environment got %v", test.name, e, ws.Env())
+			}
+		}
+		for file, expected := range map[string][]string{awsConfigFile: test.config, awsCredentialsFile: test.credentials} {
+			if len(expected) == 0 {
+				continue
+			}
+			content, err := ioutil.ReadFile(ws.Path(file))
+			if err != nil {
+				t.Errorf("%s: %v", test.name, err)
+				continue
+			}
+			for _, line := range expected {
+				if !strings.Contains(string(content), line+"\n") {
+					t.Errorf("%s: expected %q in %s got %s", test.name, line, file, content)
+				}
+			}
+		}
+	}
+}
+
+func TestClusterCredentialsProviderAccess(t *testing.T) {
+	provider := &clusteroperatorv1alpha1.ClusterProvider{
+		ObjectMeta: metav1.ObjectMeta{Name: "team"},
+		Spec: clusteroperatorv1alpha1.ClusterProviderSpec{
+			CredentialsRef: &clusteroperatorv1alpha1.CredentialsReference{Name: "aws", Namespace: "accounts"},
+		},
+	}
+	instance := &clusteroperatorv1alpha1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"}}
+
+	// the credentials.allowed.namespaces of the operator are not bypassed
+	// through the provider
+	if src, err := clusterCredentials(instance, provider, nil); err == nil {
+		t.Errorf("expected the provider credentials to be refused got %+v", src)
+	}
+	src, err := clusterCredentials(instance, provider, []string{"accounts"})
+	if err != nil || src.Secret.String() != "accounts/aws" {
+		t.Errorf("expected the provider credentials got %+v, %v", src, err)
+	}
+	provider.Spec.AllowedNamespaces = []string{"default"}
+	src, err = clusterCredentials(instance, provider, nil)
+	if err != nil || src.Secret.String() != "accounts/aws" {
+		t.Errorf("expected the namespace allowed by the provider to use its credentials got %+v, %v", src, err)
+	}
+}
@@ -0,0 +1,91 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/infobloxopen/cluster-operator/kops"
+	clusteroperatorv1alpha1 "github.com/infobloxopen/cluster-operator/pkg/apis/clusteroperator/v1alpha1"
+	"github.com/infobloxopen/cluster-operator/utils"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// maxMessagePaths is how many drifted paths are spelled out in the Drifted
+// condition, all of them are in status.drift
+const maxMessagePaths = 3
+
+// checkDrift compares the state store and the cloud with the spec of a Ready
+// cluster and reports the result on the Drifted condition. It returns true
+// when the DriftPolicy asks for the drift to be corrected by applying the
+// spec again.
+func checkDrift(ctx context.Context, c *clusterContext) bool {
+	instance := c.instance
+
+	drift, err := findDrift(ctx, c)
+	if err != nil {
+		// a failed check says nothing about the cluster, the last result
+		// is kept
+		c.log.Info("Cannot check for drift", "reason", utils.ErrorReasonFor(err), "error", err.Error())
+		setCondition(instance, clusteroperatorv1alpha1.ConditionDrifted, clusteroperatorv1alpha1.ConditionUnknown, string(utils.ErrorReasonFor(err)), err.Error())
+		return false
+	}
+	instance.Status.Drift = drift
+
+	if len(drift.StateStore) == 0 && len(drift.Cloud) == 0 {
+		setCondition(instance, clusteroperatorv1alpha1.ConditionDrifted, clusteroperatorv1alpha1.ConditionFalse, reasonNoDrift, "State store and cloud match the spec")
+		return false
+	}
+	c.log.Info("Cluster drifted", "StateStore", drift.StateStore, "Cloud", drift.Cloud)
+	if instance.Spec.DriftPolicy != clusteroperatorv1alpha1.DriftCorrect {
+		setCondition(instance, clusteroperatorv1alpha1.ConditionDrifted, clusteroperatorv1alpha1.ConditionTrue, reasonDriftDetected, driftMessage(drift))
+		return false
+	}
+	setCondition(instance, clusteroperatorv1alpha1.ConditionDrifted, clusteroperatorv1alpha1.ConditionTrue, reasonCorrectingDrift, driftMessage(drift))
+	return true
+}
+
+// findDrift diffs the kops manifest of the spec against the state store and
+// lists the cloud resources kops update cluster would change
+func findDrift(ctx context.Context, c *clusterContext) (*clusteroperatorv1alpha1.Drift, error) {
+	desired, err := kops.Manifest(c.kc, c.instance.Spec, ownerLabels(c.instance))
+	if err != nil {
+		return nil, err
+	}
+	actual, err := c.kops.GetManifest(ctx, c.kc)
+	if err != nil {
+		return nil, err
+	}
+	paths, err := kops.DiffManifests(desired, actual)
+	if err != nil {
+		return nil, err
+	}
+	plan, err := c.kops.PlanCluster(ctx, c.kc)
+	if err != nil {
+		return nil, err
+	}
+
+	drift := &clusteroperatorv1alpha1.Drift{LastChecked: metav1.NewTime(clock()), StateStore: paths}
+	for _, change := range plan.Changes {
+		drift.Cloud = append(drift.Cloud, fmt.Sprintf("%s %s/%s", change.Action, change.Type, change.Name))
+	}
+	return drift, nil
+}
+
+// driftMessage summarizes drift for the Drifted condition
+func driftMessage(drift *clusteroperatorv1alpha1.Drift) string {
+	var msgs []string
+	if paths := drift.StateStore; len(paths) > 0 {
+		msg := "state store differs from the spec at "
+		if len(paths) > maxMessagePaths {
+			msg += fmt.Sprintf("%s and %d more", strings.Join(paths[:maxMessagePaths], ", "), len(paths)-maxMessagePaths)
+		} else {
+			msg += strings.Join(paths, ", ")
+		}
+		msgs = append(msgs, msg)
+	}
+	if len(drift.Cloud) > 0 {
+		msgs = append(msgs, fmt.Sprintf("kops update cluster would change %d cloud resources", len(drift.Cloud)))
+	}
+	return strings.Join(msgs, "; ")
+}
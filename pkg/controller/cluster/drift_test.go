@@ -0,0 +1,115 @@
+package cluster
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/infobloxopen/cluster-operator/kops"
+	"github.com/infobloxopen/cluster-operator/kops/kopstest"
+	clusteroperatorv1alpha1 "github.com/infobloxopen/cluster-operator/pkg/apis/clusteroperator/v1alpha1"
+	"github.com/infobloxopen/cluster-operator/utils"
+	"github.com/spf13/viper"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDriftMessage(t *testing.T) {
+	cases := []struct {
+		name     string
+		drift    clusteroperatorv1alpha1.Drift
+		expected string
+	}{
+		{
+			name:     "state store",
+			drift:    clusteroperatorv1alpha1.Drift{StateStore: []string{"InstanceGroup/nodes.spec.maxSize"}},
+			expected: "state store differs from the spec at InstanceGroup/nodes.spec.maxSize",
+		},
+		{
+			name: "state store and cloud",
+			drift: clusteroperatorv1alpha1.Drift{
+				StateStore: []string{"a", "b", "c", "d", "e"},
+				Cloud:      []string{"Modify AutoscalingGroup/nodes.test.example.com"},
+			},
+			expected: "state store differs from the spec at a, b, c and 2 more; kops update cluster would change 1 cloud resources",
+		},
+	}
+
+	for _, c := range cases {
+		if msg := driftMessage(&c.drift); msg != c.expected {
+			t.Errorf("%s: expected %q got %q", c.name, c.expected, msg)
+		}
+	}
+}
+
+func TestCheckDrift(t *testing.T) {
+	dir, err := ioutil.TempDir("", "drift")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path, err := kopstest.Build(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store, err := kopstest.NewStateStore(filepath.Join(dir, "state"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ws, err := utils.NewWorkspace(dir, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	viper.Set("kops.path", path)
+	defer viper.Set("kops.path", nil)
+	k, err := kops.NewKops(ws)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	size := int32(2)
+	instance := &clusteroperatorv1alpha1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default", UID: "1234"},
+		Spec: clusteroperatorv1alpha1.ClusterSpec{
+			Name:        "test",
+			KopsConfig:  clusteroperatorv1alpha1.KopsConfig{Name: "test.example.com", StateStore: store.URL()},
+			DriftPolicy: clusteroperatorv1alpha1.DriftReport,
+			Kops: &clusteroperatorv1alpha1.KopsSpec{
+				Cluster:        clusteroperatorv1alpha1.KopsClusterSpec{KubernetesVersion: "1.15.7"},
+				InstanceGroups: []clusteroperatorv1alpha1.KopsInstanceGroupSpec{{Name: "nodes", Role: "Node", MinSize: &size, MaxSize: &size}},
+			},
+		},
+	}
+	c := &clusterContext{instance: instance, kops: k, kc: instance.Spec.KopsConfig, log: log}
+	ctx := context.TODO()
+	if err := k.ReplaceCluster(ctx, c.kc, instance.Spec, ownerLabels(instance)); err != nil {
+		t.Fatal(err)
+	}
+	if err := k.UpdateCluster(ctx, c.kc); err != nil {
+		t.Fatal(err)
+	}
+
+	if checkDrift(ctx, c) {
+		t.Error("expected no drift to correct")
+	}
+	if cond := clusteroperatorv1alpha1.FindCondition(instance.Status.Conditions, clusteroperatorv1alpha1.ConditionDrifted); cond == nil || cond.Reason != reasonNoDrift {
+		t.Errorf("expected no drift got %+v", cond)
+	}
+
+	// the nodes are resized in the state store behind the back of the operator
+	changed := instance.DeepCopy()
+	*changed.Spec.Kops.InstanceGroups[0].MaxSize = 4
+	if err := k.ReplaceCluster(ctx, c.kc, changed.Spec, ownerLabels(instance)); err != nil {
+		t.Fatal(err)
+	}
+	if checkDrift(ctx, c) {
+		t.Error("expected drift to be reported only")
+	}
+	if cond := clusteroperatorv1alpha1.FindCondition(instance.Status.Conditions, clusteroperatorv1alpha1.ConditionDrifted); cond == nil || cond.Reason != reasonDriftDetected {
+		t.Errorf("expected drift got %+v", cond)
+	}
+	if drift := instance.Status.Drift; len(drift.StateStore) != 1 || len(drift.Cloud) != 1 {
+		t.Errorf("expected the state store and cloud to drift got %+v", drift)
+	}
+}
@@ -0,0 +1,571 @@
+// +build integration
+
+package cluster
+
+import (
+	"context"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/infobloxopen/cluster-operator/kops/kopstest"
+	"github.com/infobloxopen/cluster-operator/pkg/apis"
+	clusteroperatorv1alpha1 "github.com/infobloxopen/cluster-operator/pkg/apis/clusteroperator/v1alpha1"
+	"github.com/infobloxopen/cluster-operator/pkg/webhook/conversion"
+	"github.com/spf13/viper"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apiextensionsclient "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/yaml"
+)
+
+// The integration suite runs ReconcileCluster against the API server and etcd
+// of envtest, with the CRDs of the chart installed, and the fake kops of
+// kopstest as the backend:
+//
+//	KUBEBUILDER_ASSETS=/usr/local/kubebuilder/bin go test -mod=mod -tags integration ./pkg/controller/cluster/
+
+var (
+	testClient client.Client
+	testScheme *runtime.Scheme
+	// kopsPath is the fake kops and tmpDir the parent of the workspaces
+	kopsPath string
+	tmpDir   string
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(runSuite(m))
+}
+
+func runSuite(m *testing.M) int {
+	testScheme = runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(testScheme); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if err := apis.AddToScheme(testScheme); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	// Clusters are stored as v1alpha2, the API server calls the conversion
+	// webhook to serve them as v1alpha1
+	wh := &conversion.Webhook{}
+	if err := wh.InjectScheme(testScheme); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	conversionServer := httptest.NewTLSServer(wh)
+	defer conversionServer.Close()
+
+	env := &envtest.Environment{}
+	cfg, err := env.Start()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cannot start envtest, is KUBEBUILDER_ASSETS set? %v\n", err)
+		return 1
+	}
+	defer env.Stop()
+	if err := installCRDs(cfg, filepath.Join("..", "..", "..", "deploy", "cluster-operator", "crds"), conversionServer); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	testClient, err = client.New(cfg, client.Options{Scheme: testScheme})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	dir, err := ioutil.TempDir("", "integration")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer os.RemoveAll(dir)
+	kopsPath, err = kopstest.Build(dir)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	tmpDir = dir
+
+	return m.Run()
+}
+
+// installCRDs creates the CRDs in the files of dir, with their conversion
+// webhook pointed at conversionServer, and waits until their versions are
+// served. envtest installs CRDs with apiextensions v1beta1, which does not
+// take the v1 CRDs of the chart as they are.
+func installCRDs(cfg *rest.Config, dir string, conversionServer *httptest.Server) error {
+	cs, err := apiextensionsclient.NewForConfig(cfg)
+	if err != nil {
+		return err
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.yaml"))
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		crd := &apiextensionsv1.CustomResourceDefinition{}
+		if err := yaml.Unmarshal(data, crd); err != nil {
+			return fmt.Errorf("%s: %v", file, err)
+		}
+		if c := crd.Spec.Conversion; c != nil && c.Strategy == apiextensionsv1.WebhookConverter {
+			url := conversionServer.URL + conversion.Path
+			c.Webhook.ClientConfig = &apiextensionsv1.WebhookClientConfig{
+				URL:      &url,
+				CABundle: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: conversionServer.Certificate().Raw}),
+			}
+		}
+		if _, err := cs.ApiextensionsV1().CustomResourceDefinitions().Create(crd); err != nil {
+			return fmt.Errorf("%s: %v", file, err)
+		}
+		for _, version := range crd.Spec.Versions {
+			if !version.Served {
+				continue
+			}
+			gv := crd.Spec.Group + "/" + version.Name
+			err := wait.PollImmediate(100*time.Millisecond, 10*time.Second, func() (bool, error) {
+				resources, err := cs.Discovery().ServerResourcesForGroupVersion(gv)
+				if err != nil {
+					return false, nil
+				}
+				for _, r := range resources.APIResources {
+					if r.Name == crd.Spec.Names.Plural {
+						return true, nil
+					}
+				}
+				return false, nil
+			})
+			if err != nil {
+				return fmt.Errorf("%s is not served as %s: %v", crd.Name, gv, err)
+			}
+		}
+	}
+	return nil
+}
+
+// testCluster is a Cluster in its own namespace and state store
+type testCluster struct {
+	t        *testing.T
+	r        *ReconcileCluster
+	store    *kopstest.StateStore
+	instance *clusteroperatorv1alpha1.Cluster
+}
+
+// newTestCluster creates a Cluster named name in a new namespace, with a
+// state store of the fake kops. The returned func removes the state store.
+func newTestCluster(t *testing.T, name string) (*testCluster, func()) {
+	ctx := context.TODO()
+	dir, err := ioutil.TempDir("", "integration")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store, err := kopstest.NewStateStore(filepath.Join(dir, "state"))
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+
+	// the operator config is global and the unit tests of the package
+	// change it
+	viper.Set("kops.path", kopsPath)
+	viper.Set("tmp.dir", tmpDir)
+	viper.Set("kops.cluster.dns.zone", "example.com")
+	viper.Set("kops.state.store", store.URL())
+
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{GenerateName: name + "-"}}
+	if err := testClient.Create(ctx, ns); err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+
+	masters, nodes := int32(1), int32(2)
+	instance := &clusteroperatorv1alpha1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns.Name},
+		Spec: clusteroperatorv1alpha1.ClusterSpec{
+			Name:              name,
+			KubernetesVersion: "1.15.7",
+			KopsConfig:        clusteroperatorv1alpha1.KopsConfig{StateStore: store.URL()},
+			Kops: &clusteroperatorv1alpha1.KopsSpec{
+				Cluster: clusteroperatorv1alpha1.KopsClusterSpec{
+					CloudProvider: "aws",
+					Subnets:       []clusteroperatorv1alpha1.KopsSubnetSpec{{Name: "us-east-2a", Zone: "us-east-2a"}},
+				},
+				InstanceGroups: []clusteroperatorv1alpha1.KopsInstanceGroupSpec{
+					{Name: "master-us-east-2a", Role: "Master", MachineType: "t2.medium", MinSize: &masters, MaxSize: &masters, Subnets: []string{"us-east-2a"}},
+					{Name: "nodes", Role: "Node", MachineType: "t2.medium", MinSize: &nodes, MaxSize: &nodes, Subnets: []string{"us-east-2a"}},
+				},
+			},
+		},
+	}
+	if err := testClient.Create(ctx, instance); err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+
+	r := &ReconcileCluster{
+		client: testClient,
+		reader: testClient,
+		scheme: testScheme,
+		locks:  NewLockManager(1),
+		// the fake kops clusters have no API to ask, they always run the
+		// version of the spec
+		versions: func([]byte) (string, []string, error) {
+			return "v1.15.7", []string{"v1.15.7", "v1.15.7", "v1.15.7"}, nil
+		},
+	}
+	return &testCluster{t: t, r: r, store: store, instance: instance}, func() { os.RemoveAll(dir) }
+}
+
+// reconcile runs one Reconcile of the cluster and reads it back
+func (tc *testCluster) reconcile() (reconcile.Result, error) {
+	tc.t.Helper()
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: tc.instance.Namespace, Name: tc.instance.Name}}
+	result, err := tc.r.Reconcile(request)
+	tc.refresh()
+	return result, err
+}
+
+// expect runs one Reconcile and fails the test unless it moves the cluster
+// to phase with result and no error
+func (tc *testCluster) expect(phase clusteroperatorv1alpha1.ClusterPhase, expected reconcile.Result) {
+	tc.t.Helper()
+	result, err := tc.reconcile()
+	if err != nil {
+		tc.t.Fatalf("expected phase %s got error %v", phase, err)
+	}
+	if tc.instance.Status.Phase != phase || result != expected {
+		tc.t.Fatalf("expected phase %s and %+v got %s and %+v: %s", phase, expected, tc.instance.Status.Phase, result, tc.instance.Status.Message)
+	}
+}
+
+// refresh reads the cluster from the API server, a deleted cluster is left
+// as it was
+func (tc *testCluster) refresh() {
+	tc.t.Helper()
+	instance := &clusteroperatorv1alpha1.Cluster{}
+	err := testClient.Get(context.TODO(), types.NamespacedName{Namespace: tc.instance.Namespace, Name: tc.instance.Name}, instance)
+	if errors.IsNotFound(err) {
+		return
+	}
+	if err != nil {
+		tc.t.Fatal(err)
+	}
+	tc.instance = instance
+}
+
+// expectCondition fails the test unless condition t has status and reason
+func (tc *testCluster) expectCondition(t clusteroperatorv1alpha1.ConditionType, status clusteroperatorv1alpha1.ConditionStatus, reason string) {
+	tc.t.Helper()
+	c := clusteroperatorv1alpha1.FindCondition(tc.instance.Status.Conditions, t)
+	if c == nil || c.Status != status || c.Reason != reason {
+		tc.t.Errorf("expected condition %s %s with reason %s got %+v", t, status, reason, c)
+	}
+}
+
+// expectCalls fails the test unless the fake kops ran commands starting with
+// each of prefixes, in order
+func (tc *testCluster) expectCalls(prefixes ...string) {
+	tc.t.Helper()
+	calls, err := tc.store.Calls()
+	if err != nil {
+		tc.t.Fatal(err)
+	}
+	next := 0
+	for _, call := range calls {
+		if next < len(prefixes) && strings.HasPrefix(call, prefixes[next]) {
+			next++
+		}
+	}
+	if next < len(prefixes) {
+		tc.t.Errorf("expected the kops commands %q got %q", prefixes, calls)
+	}
+}
+
+func TestReconcileLifecycle(t *testing.T) {
+	tc, cleanup := newTestCluster(t, "lifecycle")
+	defer cleanup()
+	ctx := context.TODO()
+
+	tc.expect(clusteroperatorv1alpha1.ClusterConfiguring, reconcile.Result{Requeue: true})
+	if len(tc.instance.Finalizers) != 1 || tc.instance.Finalizers[0] != clusterFinalizer {
+		t.Errorf("expected the finalizer got %v", tc.instance.Finalizers)
+	}
+	if kc := tc.instance.Spec.KopsConfig; kc.Name != "lifecycle.example.com" || kc.StateStore != tc.store.URL() {
+		t.Errorf("expected the defaulted kops config to be stored got %+v", kc)
+	}
+	tc.expectCondition(clusteroperatorv1alpha1.ConditionReady, clusteroperatorv1alpha1.ConditionFalse, string(clusteroperatorv1alpha1.ClusterConfiguring))
+
+	tc.expect(clusteroperatorv1alpha1.ClusterApplying, reconcile.Result{Requeue: true})
+	tc.expectCondition(clusteroperatorv1alpha1.ConditionConfigApplied, clusteroperatorv1alpha1.ConditionTrue, reasonSucceeded)
+	if tc.instance.Status.ObservedGeneration != tc.instance.Generation {
+		t.Errorf("expected generation %d to be observed got %d", tc.instance.Generation, tc.instance.Status.ObservedGeneration)
+	}
+
+	tc.expect(clusteroperatorv1alpha1.ClusterValidating, reconcile.Result{Requeue: true})
+	tc.expectCondition(clusteroperatorv1alpha1.ConditionCloudResourcesReady, clusteroperatorv1alpha1.ConditionTrue, reasonSucceeded)
+	tc.expectCondition(clusteroperatorv1alpha1.ConditionRollingUpdateComplete, clusteroperatorv1alpha1.ConditionTrue, reasonNotValidated)
+	ref := tc.instance.Status.KubeconfigSecretRef
+	if ref == nil || ref.Name != "lifecycle-kubeconfig" {
+		t.Fatalf("expected the kubeconfig Secret to be referenced got %+v", ref)
+	}
+	secret := &corev1.Secret{}
+	if err := testClient.Get(ctx, types.NamespacedName{Namespace: tc.instance.Namespace, Name: ref.Name}, secret); err != nil {
+		t.Fatal(err)
+	}
+	if len(secret.Data[DefaultKubeconfigSecretKey]) == 0 || len(secret.OwnerReferences) != 1 || secret.OwnerReferences[0].UID != tc.instance.UID {
+		t.Errorf("expected a kubeconfig Secret owned by the cluster got %+v", secret)
+	}
+
+	tc.expect(clusteroperatorv1alpha1.ClusterReady, reconcile.Result{RequeueAfter: readyResyncInterval})
+	status := tc.instance.Status
+	if !status.Validated || len(status.KopsStatus.Nodes) != 3 || status.KopsStatus.LastValidated == nil {
+		t.Errorf("expected 3 validated nodes got %+v", status.KopsStatus)
+	}
+	if status.KubernetesVersion != "1.15.7" {
+		t.Errorf("expected the version to be verified got %q", status.KubernetesVersion)
+	}
+	tc.expectCondition(clusteroperatorv1alpha1.ConditionValidated, clusteroperatorv1alpha1.ConditionTrue, reasonSucceeded)
+	tc.expectCondition(clusteroperatorv1alpha1.ConditionUpgraded, clusteroperatorv1alpha1.ConditionTrue, reasonSucceeded)
+	tc.expectCondition(clusteroperatorv1alpha1.ConditionReady, clusteroperatorv1alpha1.ConditionTrue, reasonSucceeded)
+
+	// a Ready cluster is validated again and checked for drift
+	tc.expect(clusteroperatorv1alpha1.ClusterReady, reconcile.Result{RequeueAfter: readyResyncInterval})
+	tc.expectCondition(clusteroperatorv1alpha1.ConditionDrifted, clusteroperatorv1alpha1.ConditionFalse, reasonNoDrift)
+
+	if err := testClient.Delete(ctx, tc.instance); err != nil {
+		t.Fatal(err)
+	}
+	if result, err := tc.reconcile(); err != nil || result != (reconcile.Result{}) {
+		t.Fatalf("expected the cluster to be deleted got %+v, %v", result, err)
+	}
+	err := testClient.Get(ctx, types.NamespacedName{Namespace: tc.instance.Namespace, Name: tc.instance.Name}, &clusteroperatorv1alpha1.Cluster{})
+	if !errors.IsNotFound(err) {
+		t.Errorf("expected the finalizer to be removed got %v", err)
+	}
+	tc.expectCalls("replace cluster", "update cluster", "export kubecfg", "validate cluster", "delete cluster")
+
+	// the request for the deleted object is dropped
+	if result, err := tc.reconcile(); err != nil || result != (reconcile.Result{}) {
+		t.Errorf("expected nothing to do got %+v, %v", result, err)
+	}
+}
+
+func TestReconcileUpgrade(t *testing.T) {
+	tc, cleanup := newTestCluster(t, "upgrade")
+	defer cleanup()
+	ctx := context.TODO()
+
+	tc.expect(clusteroperatorv1alpha1.ClusterConfiguring, reconcile.Result{Requeue: true})
+	tc.expect(clusteroperatorv1alpha1.ClusterApplying, reconcile.Result{Requeue: true})
+	tc.expect(clusteroperatorv1alpha1.ClusterValidating, reconcile.Result{Requeue: true})
+	tc.expect(clusteroperatorv1alpha1.ClusterReady, reconcile.Result{RequeueAfter: readyResyncInterval})
+
+	tc.instance.Spec.KubernetesVersion = "1.16.7"
+	if err := testClient.Update(ctx, tc.instance); err != nil {
+		t.Fatal(err)
+	}
+	tc.r.versions = func([]byte) (string, []string, error) {
+		return "v1.16.7", []string{"v1.16.7", "v1.16.7", "v1.16.7"}, nil
+	}
+	tc.expect(clusteroperatorv1alpha1.ClusterApplying, reconcile.Result{Requeue: true})
+	if upgrade := tc.instance.Status.Upgrade; upgrade == nil || upgrade.From != "1.15.7" || upgrade.To != "1.16.7" {
+		t.Errorf("expected the upgrade to be recorded got %+v", upgrade)
+	}
+	tc.expect(clusteroperatorv1alpha1.ClusterRollingUpdate, reconcile.Result{Requeue: true})
+	tc.expect(clusteroperatorv1alpha1.ClusterValidating, reconcile.Result{Requeue: true})
+	tc.expect(clusteroperatorv1alpha1.ClusterReady, reconcile.Result{RequeueAfter: readyResyncInterval})
+	if status := tc.instance.Status; status.KubernetesVersion != "1.16.7" || status.Upgrade != nil || len(status.UpgradeHistory) != 1 {
+		t.Errorf("expected the upgrade to be completed got %+v", status)
+	}
+	// the version goes through kops upgrade cluster before the cloud and
+	// the nodes are changed
+	tc.expectCalls("replace cluster", "upgrade cluster", "update cluster", "rolling-update cluster", "validate cluster")
+	tc.expectCalls("upgrade cluster --state=" + tc.store.URL() + " --name=upgrade.example.com --target-version=1.16.7 --yes")
+}
+
+func TestReconcileTransientFailure(t *testing.T) {
+	tc, cleanup := newTestCluster(t, "transient")
+	defer cleanup()
+
+	tc.expect(clusteroperatorv1alpha1.ClusterConfiguring, reconcile.Result{Requeue: true})
+	tc.expect(clusteroperatorv1alpha1.ClusterApplying, reconcile.Result{Requeue: true})
+
+	err := tc.store.Script(kopstest.Step{Command: "update cluster", ExitCode: 1, Stderr: "error: Throttling: Rate exceeded\n", Times: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// retried with the controller back-off in the phase that failed
+	if _, err := tc.reconcile(); err == nil {
+		t.Fatal("expected the throttled update to be retried")
+	}
+	if tc.instance.Status.Phase != clusteroperatorv1alpha1.ClusterApplying {
+		t.Errorf("expected the phase to be kept got %s", tc.instance.Status.Phase)
+	}
+	tc.expectCondition(clusteroperatorv1alpha1.ConditionCloudResourcesReady, clusteroperatorv1alpha1.ConditionFalse, "Throttled")
+
+	tc.expect(clusteroperatorv1alpha1.ClusterValidating, reconcile.Result{Requeue: true})
+	tc.expectCondition(clusteroperatorv1alpha1.ConditionCloudResourcesReady, clusteroperatorv1alpha1.ConditionTrue, reasonSucceeded)
+}
+
+func TestReconcilePermanentFailure(t *testing.T) {
+	tc, cleanup := newTestCluster(t, "permanent")
+	defer cleanup()
+
+	tc.expect(clusteroperatorv1alpha1.ClusterConfiguring, reconcile.Result{Requeue: true})
+	tc.expect(clusteroperatorv1alpha1.ClusterApplying, reconcile.Result{Requeue: true})
+
+	err := tc.store.Script(kopstest.Step{Command: "update cluster", ExitCode: 1, Stderr: "error: AccessDenied: Access Denied\n", Times: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tc.expect(clusteroperatorv1alpha1.ClusterFailed, reconcile.Result{RequeueAfter: failedRetryInterval})
+	if tc.instance.Status.FailedPhase != clusteroperatorv1alpha1.ClusterApplying || !strings.Contains(tc.instance.Status.Message, "AccessDenied") {
+		t.Errorf("expected the failed phase and message got %q, %q", tc.instance.Status.FailedPhase, tc.instance.Status.Message)
+	}
+	tc.expectCondition(clusteroperatorv1alpha1.ConditionCloudResourcesReady, clusteroperatorv1alpha1.ConditionFalse, "Unauthorized")
+	tc.expectCondition(clusteroperatorv1alpha1.ConditionReady, clusteroperatorv1alpha1.ConditionFalse, "Unauthorized")
+
+	// the failed phase is tried again
+	tc.expect(clusteroperatorv1alpha1.ClusterApplying, reconcile.Result{Requeue: true})
+	if tc.instance.Status.FailedPhase != "" || tc.instance.Status.Message != "" {
+		t.Errorf("expected the failure to be cleared got %q, %q", tc.instance.Status.FailedPhase, tc.instance.Status.Message)
+	}
+	tc.expect(clusteroperatorv1alpha1.ClusterValidating, reconcile.Result{Requeue: true})
+}
+
+func TestReconcileValidationFailure(t *testing.T) {
+	tc, cleanup := newTestCluster(t, "validation")
+	defer cleanup()
+
+	tc.expect(clusteroperatorv1alpha1.ClusterConfiguring, reconcile.Result{Requeue: true})
+	tc.expect(clusteroperatorv1alpha1.ClusterApplying, reconcile.Result{Requeue: true})
+	tc.expect(clusteroperatorv1alpha1.ClusterValidating, reconcile.Result{Requeue: true})
+
+	err := tc.store.Script(kopstest.Step{
+		Command:  "validate cluster",
+		ExitCode: 2,
+		Stdout:   `{"failures":[{"type":"InstanceGroup","name":"nodes","message":"InstanceGroup \"nodes\" did not have enough nodes 0 vs 2"}],"nodes":[]}`,
+		Times:    1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tc.expect(clusteroperatorv1alpha1.ClusterValidating, reconcile.Result{RequeueAfter: validateRetryInterval})
+	if tc.instance.Status.Validated || len(tc.instance.Status.KopsStatus.Failures) != 1 {
+		t.Errorf("expected the failure in status got %+v", tc.instance.Status.KopsStatus)
+	}
+	tc.expectCondition(clusteroperatorv1alpha1.ConditionValidated, clusteroperatorv1alpha1.ConditionFalse, reasonValidationFailed)
+	tc.expectCondition(clusteroperatorv1alpha1.ConditionReady, clusteroperatorv1alpha1.ConditionFalse, string(clusteroperatorv1alpha1.ClusterValidating))
+
+	tc.expect(clusteroperatorv1alpha1.ClusterReady, reconcile.Result{RequeueAfter: readyResyncInterval})
+}
+
+func TestReconcileProviderUnavailable(t *testing.T) {
+	tc, cleanup := newTestCluster(t, "provider")
+	defer cleanup()
+
+	tc.instance.Spec.ProviderRef = &corev1.LocalObjectReference{Name: "missing"}
+	if err := testClient.Update(context.TODO(), tc.instance); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tc.reconcile(); err == nil {
+		t.Fatal("expected the missing ClusterProvider to be retried")
+	}
+	if tc.instance.Status.Phase != "" || len(tc.instance.Finalizers) != 0 {
+		t.Errorf("expected the cluster to be left alone got phase %q and finalizers %v", tc.instance.Status.Phase, tc.instance.Finalizers)
+	}
+	tc.expectCondition(clusteroperatorv1alpha1.ConditionReady, clusteroperatorv1alpha1.ConditionFalse, reasonProviderUnavailable)
+
+	calls, err := tc.store.Calls()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(calls) != 0 {
+		t.Errorf("expected kops not to run got %q", calls)
+	}
+}
+
+func TestReconcileDeleteProviderUnavailable(t *testing.T) {
+	tc, cleanup := newTestCluster(t, "deleteprovider")
+	defer cleanup()
+	ctx := context.TODO()
+
+	tc.expect(clusteroperatorv1alpha1.ClusterConfiguring, reconcile.Result{Requeue: true})
+
+	// the ClusterProvider was deleted before the cluster
+	tc.instance.Spec.ProviderRef = &corev1.LocalObjectReference{Name: "missing"}
+	if err := testClient.Update(ctx, tc.instance); err != nil {
+		t.Fatal(err)
+	}
+	if err := testClient.Delete(ctx, tc.instance); err != nil {
+		t.Fatal(err)
+	}
+	tc.expect(clusteroperatorv1alpha1.ClusterDeleting, reconcile.Result{RequeueAfter: deleteRetryInterval})
+	tc.expectCondition(clusteroperatorv1alpha1.ConditionDeleting, clusteroperatorv1alpha1.ConditionTrue, reasonProviderUnavailable)
+}
+
+func TestReconcileDeleteCredentialsUnavailable(t *testing.T) {
+	tc, cleanup := newTestCluster(t, "credentials")
+	defer cleanup()
+	ctx := context.TODO()
+
+	tc.expect(clusteroperatorv1alpha1.ClusterConfiguring, reconcile.Result{Requeue: true})
+
+	// the credentials Secret went with the namespace before the cluster
+	tc.instance.Spec.CredentialsRef = &clusteroperatorv1alpha1.CredentialsReference{Name: "missing"}
+	if err := testClient.Update(ctx, tc.instance); err != nil {
+		t.Fatal(err)
+	}
+	if err := testClient.Delete(ctx, tc.instance); err != nil {
+		t.Fatal(err)
+	}
+	tc.expect(clusteroperatorv1alpha1.ClusterDeleting, reconcile.Result{RequeueAfter: deleteRetryInterval})
+	tc.expectCondition(clusteroperatorv1alpha1.ConditionDeleting, clusteroperatorv1alpha1.ConditionTrue, reasonCredentialsUnavailable)
+	if c := clusteroperatorv1alpha1.FindCondition(tc.instance.Status.Conditions, clusteroperatorv1alpha1.ConditionDeleting); c == nil || !strings.Contains(c.Message, clusteroperatorv1alpha1.SkipKopsDeleteAnnotation) {
+		t.Errorf("expected the condition to point at the annotation got %+v", c)
+	}
+
+	tc.instance.Annotations = map[string]string{clusteroperatorv1alpha1.SkipKopsDeleteAnnotation: "true"}
+	if err := testClient.Update(ctx, tc.instance); err != nil {
+		t.Fatal(err)
+	}
+	if result, err := tc.reconcile(); err != nil || result != (reconcile.Result{}) {
+		t.Fatalf("expected the cluster to be deleted got %+v, %v", result, err)
+	}
+	err := testClient.Get(ctx, types.NamespacedName{Namespace: tc.instance.Namespace, Name: tc.instance.Name}, &clusteroperatorv1alpha1.Cluster{})
+	if !errors.IsNotFound(err) {
+		t.Errorf("expected the finalizer to be removed got %v", err)
+	}
+
+	calls, err := tc.store.Calls()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, call := range calls {
+		if strings.HasPrefix(call, "delete cluster") {
+			t.Errorf("expected the kops cluster to be left behind got %q", calls)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	clusteroperatorv1alpha1 "github.com/infobloxopen/cluster-operator/pkg/apis/clusteroperator/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// DefaultKubeconfigSecretKey is the key of the kubeconfig in the Secret when
+// none is configured
+const DefaultKubeconfigSecretKey = "kubeconfig"
+
+// KubeconfigSecretConfig controls the Secret the kubeconfig of a cluster is
+// written to
+type KubeconfigSecretConfig struct {
+	// Key of the kubeconfig in the Secret data
+	Key string
+	// Labels added to the Secret
+	Labels map[string]string
+}
+
+// ParseLabels turns a list of key=value pairs into labels, an entry may hold
+// several comma separated pairs
+func ParseLabels(entries []string) (map[string]string, error) {
+	labels := map[string]string{}
+	for _, entry := range entries {
+		for _, pair := range strings.Split(entry, ",") {
+			pair = strings.TrimSpace(pair)
+			if pair == "" {
+				continue
+			}
+			kv := strings.SplitN(pair, "=", 2)
+			if len(kv) != 2 || kv[0] == "" {
+				return nil, fmt.Errorf("invalid label %q, expected key=value", pair)
+			}
+			labels[kv[0]] = kv[1]
+		}
+	}
+	return labels, nil
+}
+
+// kubeconfigSecretName is the name of the Secret holding the kubeconfig of instance
+func kubeconfigSecretName(instance *clusteroperatorv1alpha1.Cluster) string {
+	return instance.Name + "-kubeconfig"
+}
+
+// writeKubeconfigSecret stores kubeconfig in a Secret owned by instance and
+// references it from status
+func (r *ReconcileCluster) writeKubeconfigSecret(ctx context.Context, instance *clusteroperatorv1alpha1.Cluster, kubeconfig []byte) error {
+	secret := &corev1.Secret{}
+	name := types.NamespacedName{Namespace: instance.Namespace, Name: kubeconfigSecretName(instance)}
+	err := r.client.Get(ctx, name, secret)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	create := errors.IsNotFound(err)
+	if create {
+		secret.Namespace = name.Namespace
+		secret.Name = name.Name
+	} else if err := checkKubeconfigSecretOwner(secret, instance); err != nil {
+		return err
+	}
+
+	r.kubeconfigSecret.mutate(secret, instance, kubeconfig)
+	if create {
+		err = r.client.Create(ctx, secret)
+	} else {
+		err = r.client.Update(ctx, secret)
+	}
+	if err != nil {
+		return err
+	}
+
+	instance.Status.KubeconfigSecretRef = &corev1.LocalObjectReference{Name: secret.Name}
+	return nil
+}
+
+// kubeconfigSecretConflict is returned when the kubeconfig Secret of a
+// Cluster exists but is not controlled by it
+type kubeconfigSecretConflict struct {
+	secret types.NamespacedName
+	owner  *metav1.OwnerReference
+}
+
+func (e *kubeconfigSecretConflict) Error() string {
+	if e.owner == nil {
+		return fmt.Sprintf("Secret %s exists and is not controlled by the cluster, remove or rename it", e.secret)
+	}
+	return fmt.Sprintf("Secret %s is controlled by %s %s, remove or rename it", e.secret, e.owner.Kind, e.owner.Name)
+}
+
+// checkKubeconfigSecretOwner refuses to write the kubeconfig of instance to an
+// existing Secret that instance does not control, it could belong to anyone
+func checkKubeconfigSecretOwner(secret *corev1.Secret, instance *clusteroperatorv1alpha1.Cluster) error {
+	owner := metav1.GetControllerOf(secret)
+	if owner != nil && owner.UID == instance.UID {
+		return nil
+	}
+	return &kubeconfigSecretConflict{
+		secret: types.NamespacedName{Namespace: secret.Namespace, Name: secret.Name},
+		owner:  owner,
+	}
+}
+
+// mutate sets the labels, owner and data of the kubeconfig Secret of instance,
+// anything else already on the Secret is left alone
+func (cfg KubeconfigSecretConfig) mutate(secret *corev1.Secret, instance *clusteroperatorv1alpha1.Cluster, kubeconfig []byte) {
+	key := cfg.Key
+	if key == "" {
+		key = DefaultKubeconfigSecretKey
+	}
+
+	if secret.Labels == nil {
+		secret.Labels = map[string]string{}
+	}
+	for k, v := range cfg.Labels {
+		secret.Labels[k] = v
+	}
+
+	owner := metav1.NewControllerRef(instance, clusteroperatorv1alpha1.SchemeGroupVersion.WithKind("Cluster"))
+	refs := []metav1.OwnerReference{*owner}
+	for _, ref := range secret.OwnerReferences {
+		if ref.UID != owner.UID {
+			refs = append(refs, ref)
+		}
+	}
+	secret.OwnerReferences = refs
+
+	if secret.Type == "" {
+		secret.Type = corev1.SecretTypeOpaque
+	}
+	secret.Data = map[string][]byte{key: kubeconfig}
+}
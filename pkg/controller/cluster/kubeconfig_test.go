@@ -0,0 +1,101 @@
+package cluster
+
+import (
+	"reflect"
+	"testing"
+
+	clusteroperatorv1alpha1 "github.com/infobloxopen/cluster-operator/pkg/apis/clusteroperator/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParseLabels(t *testing.T) {
+	cases := []struct {
+		entries  []string
+		expected map[string]string
+		err      bool
+	}{
+		{nil, map[string]string{}, false},
+		{[]string{"team=infra", "env=dev"}, map[string]string{"team": "infra", "env": "dev"}, false},
+		{[]string{"team=infra,env=dev"}, map[string]string{"team": "infra", "env": "dev"}, false},
+		{[]string{"empty="}, map[string]string{"empty": ""}, false},
+		{[]string{"team"}, nil, true},
+		{[]string{"=infra"}, nil, true},
+	}
+
+	for _, c := range cases {
+		labels, err := ParseLabels(c.entries)
+		if (err != nil) != c.err {
+			t.Errorf("%v: expected error %v got %v", c.entries, c.err, err)
+			continue
+		}
+		if !c.err && !reflect.DeepEqual(labels, c.expected) {
+			t.Errorf("%v: expected %v got %v", c.entries, c.expected, labels)
+		}
+	}
+}
+
+func TestMutateKubeconfigSecret(t *testing.T) {
+	instance := &clusteroperatorv1alpha1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default", UID: "1234"},
+	}
+	other := metav1.OwnerReference{Name: "other", UID: "5678"}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels:          map[string]string{"keep": "me"},
+			OwnerReferences: []metav1.OwnerReference{other},
+		},
+		Data: map[string][]byte{"stale": []byte("old")},
+	}
+	cfg := KubeconfigSecretConfig{Key: "value", Labels: map[string]string{"team": "infra"}}
+
+	cfg.mutate(secret, instance, []byte("apiVersion: v1"))
+	cfg.mutate(secret, instance, []byte("apiVersion: v1"))
+
+	if !reflect.DeepEqual(secret.Data, map[string][]byte{"value": []byte("apiVersion: v1")}) {
+		t.Error("Expected kubeconfig under key value got", secret.Data)
+	}
+	if secret.Labels["keep"] != "me" || secret.Labels["team"] != "infra" {
+		t.Error("Expected existing and configured labels got", secret.Labels)
+	}
+	if len(secret.OwnerReferences) != 2 {
+		t.Fatal("Expected 2 owner references got", secret.OwnerReferences)
+	}
+	owner := secret.OwnerReferences[0]
+	if owner.UID != instance.UID || owner.Kind != "Cluster" || owner.Controller == nil || !*owner.Controller {
+		t.Error("Expected Cluster as controller got", owner)
+	}
+	if secret.Type != corev1.SecretTypeOpaque {
+		t.Error("Expected type", corev1.SecretTypeOpaque, "got", secret.Type)
+	}
+
+	KubeconfigSecretConfig{}.mutate(secret, instance, []byte("x"))
+	if _, ok := secret.Data[DefaultKubeconfigSecretKey]; !ok {
+		t.Error("Expected default key", DefaultKubeconfigSecretKey, "got", secret.Data)
+	}
+}
+
+func TestCheckKubeconfigSecretOwner(t *testing.T) {
+	instance := &clusteroperatorv1alpha1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default", UID: "1234"},
+	}
+	controller := true
+	cases := []struct {
+		name     string
+		owners   []metav1.OwnerReference
+		conflict bool
+	}{
+		{"controlled by the cluster", []metav1.OwnerReference{{Kind: "Cluster", Name: "example", UID: "1234", Controller: &controller}}, false},
+		{"no owner", nil, true},
+		{"owned without controller", []metav1.OwnerReference{{Kind: "Cluster", Name: "example", UID: "1234"}}, true},
+		{"controlled by another cluster", []metav1.OwnerReference{{Kind: "Cluster", Name: "example", UID: "5678", Controller: &controller}}, true},
+	}
+
+	for _, c := range cases {
+		secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "example-kubeconfig", Namespace: "default", OwnerReferences: c.owners}}
+		err := checkKubeconfigSecretOwner(secret, instance)
+		if (err != nil) != c.conflict {
+			t.Errorf("%s: expected conflict %v got %v", c.name, c.conflict, err)
+		}
+	}
+}
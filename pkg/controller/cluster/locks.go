@@ -0,0 +1,70 @@
+package cluster
+
+import (
+	"context"
+	"time"
+
+	"github.com/infobloxopen/cluster-operator/utils"
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var lockWaitSeconds = prometheus.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Name:    "cluster_operator_lock_wait_seconds",
+		Help:    "Time spent waiting for a kops mutation lock",
+		Buckets: []float64{0.01, 0.1, 1, 5, 15, 30, 60, 300, 900, 1800},
+	},
+	[]string{"lock", "result"},
+)
+
+func init() {
+	metrics.Registry.MustRegister(lockWaitSeconds)
+}
+
+// LockManager serializes kops mutations. At most one mutation runs per kops
+// cluster name, no matter which Cluster resource asks for it, and at most
+// stateStoreLimit run against the same state store.
+type LockManager struct {
+	clusters    *utils.KeyedSemaphore
+	stateStores *utils.KeyedSemaphore
+}
+
+// NewLockManager returns a LockManager allowing stateStoreLimit concurrent
+// mutations per state store
+func NewLockManager(stateStoreLimit int) *LockManager {
+	return &LockManager{
+		clusters:    utils.NewKeyedSemaphore(1),
+		stateStores: utils.NewKeyedSemaphore(stateStoreLimit),
+	}
+}
+
+// Lock takes the cluster lock and then a state store slot, always in that
+// order so two callers cannot deadlock each other. The returned func releases
+// both.
+func (l *LockManager) Lock(ctx context.Context, clusterName, stateStore string) (func(), error) {
+	releaseCluster, err := acquire(ctx, l.clusters, "cluster", clusterName)
+	if err != nil {
+		return nil, err
+	}
+	releaseStore, err := acquire(ctx, l.stateStores, "state_store", stateStoreKey(stateStore))
+	if err != nil {
+		releaseCluster()
+		return nil, err
+	}
+	return func() {
+		releaseStore()
+		releaseCluster()
+	}, nil
+}
+
+func acquire(ctx context.Context, s *utils.KeyedSemaphore, lock, key string) (func(), error) {
+	start := time.Now()
+	release, err := s.Acquire(ctx, key)
+	result := "acquired"
+	if err != nil {
+		result = "timeout"
+	}
+	lockWaitSeconds.WithLabelValues(lock, result).Observe(time.Since(start).Seconds())
+	return release, err
+}
@@ -0,0 +1,57 @@
+package cluster
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLockManagerCluster(t *testing.T) {
+	l := NewLockManager(2)
+
+	unlock, err := l.Lock(context.TODO(), "a.example.com", "s3://store")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := l.Lock(ctx, "a.example.com", "s3://other"); err == nil {
+		t.Fatal("expected a second mutation of the same cluster to wait")
+	}
+
+	unlock()
+	unlock, err = l.Lock(context.TODO(), "a.example.com", "s3://store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	unlock()
+}
+
+func TestLockManagerStateStore(t *testing.T) {
+	l := NewLockManager(2)
+
+	unlockA, err := l.Lock(context.TODO(), "a.example.com", "s3://store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	unlockB, err := l.Lock(context.TODO(), "b.example.com", "s3://store")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := l.Lock(ctx, "c.example.com", "s3://store"); err == nil {
+		t.Fatal("expected the state store limit to be enforced")
+	}
+
+	// a failed state store wait must give the cluster lock back
+	unlockC, err := l.Lock(context.TODO(), "c.example.com", "s3://other")
+	if err != nil {
+		t.Fatal(err)
+	}
+	unlockC()
+	unlockA()
+	unlockB()
+}
@@ -0,0 +1,46 @@
+package cluster
+
+import (
+	"time"
+
+	clusteroperatorv1alpha1 "github.com/infobloxopen/cluster-operator/pkg/apis/clusteroperator/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// clock is what maintenance windows are checked against, tests replace it
+var clock = time.Now
+
+// inMaintenanceWindow reports whether the disruptive step of phase may run
+// now. Outside of the maintenance windows of the cluster the condition of
+// phase says when the next window opens and the returned result requeues the
+// cluster for it. Invalid windows fail the phase until the spec is fixed.
+func inMaintenanceWindow(c *clusterContext, phase clusteroperatorv1alpha1.ClusterPhase) (bool, reconcile.Result, error) {
+	instance := c.instance
+	now := clock()
+
+	open, next, err := clusteroperatorv1alpha1.InMaintenanceWindow(instance.Spec.MaintenanceWindows, now)
+	if err != nil {
+		return false, reconcile.Result{}, &specError{reason: reasonInvalidMaintenanceWindow, err: err}
+	}
+	setNextMaintenanceWindow(instance, next)
+	if open {
+		return true, reconcile.Result{}, nil
+	}
+
+	c.log.Info("Outside of maintenance windows, requeueing", "Next", next)
+	setCondition(instance, phaseConditions[phase], clusteroperatorv1alpha1.ConditionFalse, reasonOutsideMaintenanceWindow,
+		"Waiting for the maintenance window at "+next.UTC().Format(time.RFC3339))
+	return false, reconcile.Result{RequeueAfter: next.Sub(now)}, nil
+}
+
+// setNextMaintenanceWindow records when the next maintenance window opens,
+// a zero next means the cluster has no windows
+func setNextMaintenanceWindow(instance *clusteroperatorv1alpha1.Cluster, next time.Time) {
+	if next.IsZero() {
+		instance.Status.NextMaintenanceWindow = nil
+		return
+	}
+	t := metav1.NewTime(next)
+	instance.Status.NextMaintenanceWindow = &t
+}
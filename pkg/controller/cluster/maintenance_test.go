@@ -0,0 +1,67 @@
+package cluster
+
+import (
+	"testing"
+	"time"
+
+	clusteroperatorv1alpha1 "github.com/infobloxopen/cluster-operator/pkg/apis/clusteroperator/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestInMaintenanceWindow(t *testing.T) {
+	defer func() { clock = time.Now }()
+	// Saturday noon
+	now := time.Date(2020, 2, 1, 12, 0, 0, 0, time.UTC)
+	clock = func() time.Time { return now }
+	window := clusteroperatorv1alpha1.MaintenanceWindow{Days: []string{"Saturday"}, Start: "22:00", Duration: metav1.Duration{Duration: 4 * time.Hour}}
+
+	cases := []struct {
+		name    string
+		windows []clusteroperatorv1alpha1.MaintenanceWindow
+		open    bool
+		requeue time.Duration
+	}{
+		{"no windows", nil, true, 0},
+		{"closed", []clusteroperatorv1alpha1.MaintenanceWindow{window}, false, 10 * time.Hour},
+	}
+
+	for _, c := range cases {
+		instance := &clusteroperatorv1alpha1.Cluster{}
+		instance.Spec.MaintenanceWindows = c.windows
+
+		open, result, err := inMaintenanceWindow(&clusterContext{instance: instance, log: log}, clusteroperatorv1alpha1.ClusterRollingUpdate)
+		if err != nil {
+			t.Fatalf("%s: expected no error got %v", c.name, err)
+		}
+		if open != c.open {
+			t.Errorf("%s: expected open %v got %v", c.name, c.open, open)
+		}
+		if result.RequeueAfter != c.requeue {
+			t.Errorf("%s: expected requeue after %v got %v", c.name, c.requeue, result.RequeueAfter)
+		}
+		if next := instance.Status.NextMaintenanceWindow; (next != nil) != (len(c.windows) > 0) {
+			t.Errorf("%s: unexpected next maintenance window %v", c.name, next)
+		}
+		if !c.open && !waiting(instance, clusteroperatorv1alpha1.ClusterRollingUpdate) {
+			t.Errorf("%s: expected rolling update to wait for the window got %v", c.name, instance.Status.Conditions)
+		}
+	}
+}
+
+func TestInMaintenanceWindowInvalid(t *testing.T) {
+	instance := &clusteroperatorv1alpha1.Cluster{}
+	instance.Spec.MaintenanceWindows = []clusteroperatorv1alpha1.MaintenanceWindow{{Days: []string{"Saturday"}, Start: "25:00", Duration: metav1.Duration{Duration: time.Hour}}}
+
+	open, _, err := inMaintenanceWindow(&clusterContext{instance: instance, log: log}, clusteroperatorv1alpha1.ClusterRollingUpdate)
+	if open || err == nil {
+		t.Fatalf("expected an error for an invalid window got open %v error %v", open, err)
+	}
+	if !permanent(err) {
+		t.Errorf("expected an invalid window to fail the phase got %v", err)
+	}
+	setPhaseFailed(instance, clusteroperatorv1alpha1.ClusterRollingUpdate, err)
+	c := clusteroperatorv1alpha1.FindCondition(instance.Status.Conditions, clusteroperatorv1alpha1.ConditionRollingUpdateComplete)
+	if c == nil || c.Reason != reasonInvalidMaintenanceWindow {
+		t.Errorf("expected reason %s got %v", reasonInvalidMaintenanceWindow, c)
+	}
+}
@@ -0,0 +1,480 @@
+package cluster
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/infobloxopen/cluster-operator/kops"
+	clusteroperatorv1alpha1 "github.com/infobloxopen/cluster-operator/pkg/apis/clusteroperator/v1alpha1"
+	"github.com/infobloxopen/cluster-operator/utils"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+//Finalizer name
+const clusterFinalizer = "cluster.finalizer.cluster-operator.infobloxopen.github.com"
+
+const (
+	// readyResyncInterval is how often a Ready cluster is validated again
+	readyResyncInterval = 10 * time.Minute
+	// validateRetryInterval is how often a cluster that is coming up is validated
+	validateRetryInterval = 5 * time.Minute
+	// failedRetryInterval is how long a Failed cluster waits before the
+	// failed phase is tried again
+	failedRetryInterval = 10 * time.Minute
+	// planRefreshInterval is how often a plan waiting for approval is
+	// computed again, to pick up changes made outside of the operator
+	planRefreshInterval = 10 * time.Minute
+	// kubeconfigConflictRetryInterval is how often writing the kubeconfig
+	// to a Secret the cluster does not control is tried again
+	kubeconfigConflictRetryInterval = 5 * time.Minute
+	// deleteRetryInterval is how often the deletion of a cluster that kops
+	// cannot run for is tried again
+	deleteRetryInterval = time.Minute
+)
+
+// clusterContext is the state shared by the phase handlers of one reconcile
+type clusterContext struct {
+	instance *clusteroperatorv1alpha1.Cluster
+	kops     *kops.KopsCmd
+	kc       clusteroperatorv1alpha1.KopsConfig
+	log      logr.Logger
+	// unavailable is why kops cannot run for a Cluster being deleted, the
+	// deleting phase reports it with unavailableReason
+	unavailable       error
+	unavailableReason string
+}
+
+// phaseHandler runs the step of the cluster life cycle for one phase and
+// returns the phase to move to. Handlers must be idempotent, the operator can
+// stop at any point and run the same phase again. Returning an empty phase
+// means the handler is done with the object and nothing is persisted.
+type phaseHandler func(ctx context.Context, c *clusterContext) (clusteroperatorv1alpha1.ClusterPhase, reconcile.Result, error)
+
+func (r *ReconcileCluster) phases() map[clusteroperatorv1alpha1.ClusterPhase]phaseHandler {
+	return map[clusteroperatorv1alpha1.ClusterPhase]phaseHandler{
+		clusteroperatorv1alpha1.ClusterPending:       r.pending,
+		clusteroperatorv1alpha1.ClusterConfiguring:   r.configuring,
+		clusteroperatorv1alpha1.ClusterApplying:      r.applying,
+		clusteroperatorv1alpha1.ClusterRollingUpdate: r.rollingUpdate,
+		clusteroperatorv1alpha1.ClusterValidating:    r.validating,
+		clusteroperatorv1alpha1.ClusterReady:         r.validating,
+		clusteroperatorv1alpha1.ClusterFailed:        r.failed,
+		clusteroperatorv1alpha1.ClusterDeleting:      r.deleting,
+	}
+}
+
+// legacyPhases maps the phases written by earlier versions to their
+// replacement
+var legacyPhases = map[clusteroperatorv1alpha1.ClusterPhase]clusteroperatorv1alpha1.ClusterPhase{
+	clusteroperatorv1alpha1.ClusterUpdate: clusteroperatorv1alpha1.ClusterApplying,
+	clusteroperatorv1alpha1.ClusterSetup:  clusteroperatorv1alpha1.ClusterValidating,
+	clusteroperatorv1alpha1.ClusterDone:   clusteroperatorv1alpha1.ClusterReady,
+}
+
+// migratePhase replaces a phase written by an earlier version in the status
+// of instance, it returns true when the status has to be persisted
+func migratePhase(instance *clusteroperatorv1alpha1.Cluster) bool {
+	phase, ok := legacyPhases[instance.Status.Phase]
+	if ok {
+		instance.Status.Phase = phase
+	}
+	return ok
+}
+
+// currentPhase returns the phase to run for instance. A new spec generation
+// restarts the cycle once the cluster has settled, which includes waiting
+// for an approval or a maintenance window.
+func currentPhase(instance *clusteroperatorv1alpha1.Cluster) clusteroperatorv1alpha1.ClusterPhase {
+	if !instance.ObjectMeta.DeletionTimestamp.IsZero() {
+		return clusteroperatorv1alpha1.ClusterDeleting
+	}
+
+	phase := instance.Status.Phase
+	if phase == "" {
+		return clusteroperatorv1alpha1.ClusterPending
+	}
+
+	if instance.Generation == instance.Status.ObservedGeneration {
+		return phase
+	}
+	switch phase {
+	case clusteroperatorv1alpha1.ClusterValidating, clusteroperatorv1alpha1.ClusterReady, clusteroperatorv1alpha1.ClusterFailed:
+		return clusteroperatorv1alpha1.ClusterConfiguring
+	case clusteroperatorv1alpha1.ClusterApplying, clusteroperatorv1alpha1.ClusterRollingUpdate:
+		if waiting(instance, phase) {
+			return clusteroperatorv1alpha1.ClusterConfiguring
+		}
+	}
+	return phase
+}
+
+// waiting reports whether phase has not started yet because it waits for an
+// approval or a maintenance window
+func waiting(instance *clusteroperatorv1alpha1.Cluster, phase clusteroperatorv1alpha1.ClusterPhase) bool {
+	c := clusteroperatorv1alpha1.FindCondition(instance.Status.Conditions, phaseConditions[phase])
+	return c != nil && c.Status == clusteroperatorv1alpha1.ConditionFalse &&
+		(c.Reason == reasonAwaitingApproval || c.Reason == reasonOutsideMaintenanceWindow)
+}
+
+// permanent reports whether err came from kops and is not expected to go
+// away on retry, or is a spec the phase cannot act on. Errors talking to the
+// API server are always retried.
+func permanent(err error) bool {
+	var cmdErr *utils.CommandError
+	var specErr *specError
+	return stderrors.As(err, &specErr) || stderrors.As(err, &cmdErr) && !utils.IsRetryable(err)
+}
+
+// specError is a spec the phase cannot act on, e.g. an invalid maintenance
+// window admitted without the webhook. It fails the phase with reason until
+// the spec changes.
+type specError struct {
+	reason string
+	err    error
+}
+
+func (e *specError) Error() string { return e.err.Error() }
+
+func (e *specError) Unwrap() error { return e.err }
+
+// busy is returned by mutating phases when the kops locks are not available
+func busy(c *clusterContext, phase clusteroperatorv1alpha1.ClusterPhase) (clusteroperatorv1alpha1.ClusterPhase, reconcile.Result, error) {
+	c.log.Info("Cluster or state store busy, requeueing")
+	return phase, reconcile.Result{RequeueAfter: lockWaitTimeout}, nil
+}
+
+// pending adds defaults and the finalizer to a new Cluster
+func (r *ReconcileCluster) pending(ctx context.Context, c *clusterContext) (clusteroperatorv1alpha1.ClusterPhase, reconcile.Result, error) {
+	instance := c.instance
+
+	instance.Spec.KopsConfig = c.kc
+	// Add the finalizer and update the object
+	if !utils.Contains(instance.ObjectMeta.Finalizers, clusterFinalizer) {
+		instance.ObjectMeta.Finalizers = append(instance.ObjectMeta.Finalizers, clusterFinalizer)
+	}
+	status := instance.Status
+	if err := r.client.Update(ctx, instance); err != nil {
+		return clusteroperatorv1alpha1.ClusterPending, reconcile.Result{}, err
+	}
+	// Update returns the object as stored, status is not part of it
+	instance.Status = status
+
+	return clusteroperatorv1alpha1.ClusterConfiguring, reconcile.Result{}, nil
+}
+
+// configuring writes the desired state to the state store
+func (r *ReconcileCluster) configuring(ctx context.Context, c *clusterContext) (clusteroperatorv1alpha1.ClusterPhase, reconcile.Result, error) {
+	unlock, err := r.lock(ctx, c.kc)
+	if err != nil {
+		return busy(c, clusteroperatorv1alpha1.ClusterConfiguring)
+	}
+	defer unlock()
+
+	c.instance.Status.ObservedGeneration = c.instance.Generation
+	if err := startUpgrade(c.instance); err != nil {
+		// waits for a spec with a version the cluster can be moved to
+		c.log.Info("Refusing Kubernetes version", "reason", err.Error())
+		setCondition(c.instance, clusteroperatorv1alpha1.ConditionConfigApplied, clusteroperatorv1alpha1.ConditionFalse, reasonUnsupportedUpgrade, err.Error())
+		return clusteroperatorv1alpha1.ClusterConfiguring, reconcile.Result{}, nil
+	}
+	if err := c.kops.ReplaceCluster(ctx, c.kc, c.instance.Spec, ownerLabels(c.instance)); err != nil {
+		return clusteroperatorv1alpha1.ClusterConfiguring, reconcile.Result{}, err
+	}
+	c.log.Info("Cluster Config Updated")
+	if upgrade := c.instance.Status.Upgrade; upgrade != nil {
+		// kops upgrade cluster brings the images and components kops
+		// recommends for the version, update and rolling-update apply them
+		// in the next phases
+		if err := c.kops.UpgradeCluster(ctx, c.kc, upgrade.To); err != nil {
+			return clusteroperatorv1alpha1.ClusterConfiguring, reconcile.Result{}, err
+		}
+		c.log.Info("Cluster Config Upgraded", "From", upgrade.From, "To", upgrade.To)
+	}
+	setCondition(c.instance, clusteroperatorv1alpha1.ConditionConfigApplied, clusteroperatorv1alpha1.ConditionTrue, reasonSucceeded, "Cluster config written to the state store")
+
+	return clusteroperatorv1alpha1.ClusterApplying, reconcile.Result{}, nil
+}
+
+// applying applies the state store config to the cloud
+func (r *ReconcileCluster) applying(ctx context.Context, c *clusterContext) (clusteroperatorv1alpha1.ClusterPhase, reconcile.Result, error) {
+	unlock, err := r.lock(ctx, c.kc)
+	if err != nil {
+		return busy(c, clusteroperatorv1alpha1.ClusterApplying)
+	}
+	defer unlock()
+
+	if c.instance.Spec.ApplyPolicy == clusteroperatorv1alpha1.ApplyOnApproval {
+		approved, err := planApproved(ctx, c)
+		if err != nil {
+			return clusteroperatorv1alpha1.ClusterApplying, reconcile.Result{}, err
+		}
+		if !approved {
+			return clusteroperatorv1alpha1.ClusterApplying, reconcile.Result{RequeueAfter: planRefreshInterval}, nil
+		}
+	}
+	if c.instance.Status.Validated {
+		// changes to a running cluster can replace load balancers, security
+		// groups and the like, a new cluster is built right away
+		open, result, err := inMaintenanceWindow(c, clusteroperatorv1alpha1.ClusterApplying)
+		if !open || err != nil {
+			return clusteroperatorv1alpha1.ClusterApplying, result, err
+		}
+	}
+
+	if err := c.kops.UpdateCluster(ctx, c.kc); err != nil {
+		return clusteroperatorv1alpha1.ClusterApplying, reconcile.Result{}, err
+	}
+	c.log.Info("Cluster Updated")
+	setCondition(c.instance, clusteroperatorv1alpha1.ConditionCloudResourcesReady, clusteroperatorv1alpha1.ConditionTrue, reasonSucceeded, "Cluster config applied to the cloud")
+
+	config, err := c.kops.GetKubeConfig(ctx, c.kc)
+	if err != nil {
+		return clusteroperatorv1alpha1.ClusterApplying, reconcile.Result{}, err
+	}
+	data, err := config.Marshal()
+	if err != nil {
+		return clusteroperatorv1alpha1.ClusterApplying, reconcile.Result{}, err
+	}
+	if err := r.writeKubeconfigSecret(ctx, c.instance, data); err != nil {
+		var conflict *kubeconfigSecretConflict
+		if stderrors.As(err, &conflict) {
+			// nothing watches the Secret of someone else, check again later
+			c.log.Info("Cannot write kubeconfig", "reason", err.Error())
+			setCondition(c.instance, clusteroperatorv1alpha1.ConditionCloudResourcesReady, clusteroperatorv1alpha1.ConditionFalse, reasonKubeconfigSecretConflict, err.Error())
+			return clusteroperatorv1alpha1.ClusterApplying, reconcile.Result{RequeueAfter: kubeconfigConflictRetryInterval}, nil
+		}
+		return clusteroperatorv1alpha1.ClusterApplying, reconcile.Result{}, err
+	}
+	c.log.Info("KUBECONFIG Updated", "Secret", c.instance.Status.KubeconfigSecretRef.Name)
+
+	// Some changes will require rebuilding the nodes (for example, resizing nodes or changing the AMI)
+	// We call rolling-update to apply these changes, a cluster that never came up has nothing to roll
+	if !c.instance.Status.Validated {
+		c.log.Info("Cluster not validated yet... Skipping rolling update for now")
+		setCondition(c.instance, clusteroperatorv1alpha1.ConditionRollingUpdateComplete, clusteroperatorv1alpha1.ConditionTrue, reasonNotValidated, "Cluster never validated, no nodes to replace")
+		return clusteroperatorv1alpha1.ClusterValidating, reconcile.Result{}, nil
+	}
+	return clusteroperatorv1alpha1.ClusterRollingUpdate, reconcile.Result{}, nil
+}
+
+// planApproved computes the changes kops would apply and reports whether they
+// may be applied. Changes are held back in status.pendingPlan until the
+// ApprovedPlanAnnotation of the cluster is set to the hash of the plan.
+func planApproved(ctx context.Context, c *clusterContext) (bool, error) {
+	instance := c.instance
+
+	plan, err := c.kops.PlanCluster(ctx, c.kc)
+	if err != nil {
+		return false, err
+	}
+
+	switch {
+	case len(plan.Changes) == 0:
+		instance.Status.PendingPlan = nil
+		setCondition(instance, clusteroperatorv1alpha1.ConditionPlanReady, clusteroperatorv1alpha1.ConditionFalse, reasonNoChanges, "No changes to apply to the cloud")
+		return true, nil
+	case instance.Annotations[clusteroperatorv1alpha1.ApprovedPlanAnnotation] == plan.Hash:
+		c.log.Info("Plan approved", "Hash", plan.Hash)
+		instance.Status.PendingPlan = nil
+		setCondition(instance, clusteroperatorv1alpha1.ConditionPlanReady, clusteroperatorv1alpha1.ConditionFalse, reasonApproved, "Plan "+plan.Hash+" approved")
+		return true, nil
+	}
+
+	c.log.Info("Plan awaiting approval", "Hash", plan.Hash, "Changes", len(plan.Changes))
+	instance.Status.PendingPlan = &plan
+	setCondition(instance, clusteroperatorv1alpha1.ConditionPlanReady, clusteroperatorv1alpha1.ConditionTrue, reasonAwaitingApproval,
+		fmt.Sprintf("%d changes pending, set annotation %s=%s to apply them", len(plan.Changes), clusteroperatorv1alpha1.ApprovedPlanAnnotation, plan.Hash))
+	setCondition(instance, clusteroperatorv1alpha1.ConditionCloudResourcesReady, clusteroperatorv1alpha1.ConditionFalse, reasonAwaitingApproval, "Plan "+plan.Hash+" awaiting approval")
+	return false, nil
+}
+
+// rollingUpdate replaces the nodes that need to pick up changes
+func (r *ReconcileCluster) rollingUpdate(ctx context.Context, c *clusterContext) (clusteroperatorv1alpha1.ClusterPhase, reconcile.Result, error) {
+	if !rollingUpdateAllowed(c) {
+		return clusteroperatorv1alpha1.ClusterRollingUpdate, reconcile.Result{}, nil
+	}
+	if strategy(c.instance) == clusteroperatorv1alpha1.RollingUpdateNever {
+		c.log.Info("Rolling updates disabled... Skipping rolling update")
+		setCondition(c.instance, clusteroperatorv1alpha1.ConditionRollingUpdateComplete, clusteroperatorv1alpha1.ConditionTrue, reasonSkipped, "Rolling updates are disabled by spec.rollingUpdate.strategy")
+		return clusteroperatorv1alpha1.ClusterValidating, reconcile.Result{}, nil
+	}
+	if open, result, err := inMaintenanceWindow(c, clusteroperatorv1alpha1.ClusterRollingUpdate); !open || err != nil {
+		return clusteroperatorv1alpha1.ClusterRollingUpdate, result, err
+	}
+
+	unlock, err := r.lock(ctx, c.kc)
+	if err != nil {
+		return busy(c, clusteroperatorv1alpha1.ClusterRollingUpdate)
+	}
+	defer unlock()
+
+	// a rolling update can take an hour, let clients see that it started
+	setCondition(c.instance, clusteroperatorv1alpha1.ConditionRollingUpdateComplete, clusteroperatorv1alpha1.ConditionFalse, reasonInProgress, "Replacing nodes")
+	setReady(c.instance)
+	if err := r.client.Status().Update(ctx, c.instance); err != nil {
+		return clusteroperatorv1alpha1.ClusterRollingUpdate, reconcile.Result{}, err
+	}
+
+	if err := c.kops.RollingUpdateCluster(ctx, c.kc, c.instance.Spec.RollingUpdate); err != nil {
+		return clusteroperatorv1alpha1.ClusterRollingUpdate, reconcile.Result{}, err
+	}
+	c.log.Info("Rolling Update Complete")
+	setCondition(c.instance, clusteroperatorv1alpha1.ConditionRollingUpdateComplete, clusteroperatorv1alpha1.ConditionTrue, reasonSucceeded, "All nodes are up to date")
+
+	return clusteroperatorv1alpha1.ClusterValidating, reconcile.Result{}, nil
+}
+
+// strategy returns the rolling update strategy of the cluster, Auto unless set
+func strategy(instance *clusteroperatorv1alpha1.Cluster) clusteroperatorv1alpha1.RollingUpdateStrategy {
+	if instance.Spec.RollingUpdate == nil || instance.Spec.RollingUpdate.Strategy == "" {
+		return clusteroperatorv1alpha1.RollingUpdateAuto
+	}
+	return instance.Spec.RollingUpdate.Strategy
+}
+
+// rollingUpdateAllowed reports whether the rolling update may start. With the
+// Manual strategy it waits for ApprovedRollingUpdateAnnotation to be set to the
+// generation the update is for, an approval does not carry over to later specs.
+func rollingUpdateAllowed(c *clusterContext) bool {
+	instance := c.instance
+	if strategy(instance) != clusteroperatorv1alpha1.RollingUpdateManual {
+		return true
+	}
+
+	generation := strconv.FormatInt(instance.Status.ObservedGeneration, 10)
+	if instance.Annotations[clusteroperatorv1alpha1.ApprovedRollingUpdateAnnotation] == generation {
+		return true
+	}
+	c.log.Info("Rolling update awaiting approval", "Generation", generation)
+	setCondition(instance, clusteroperatorv1alpha1.ConditionRollingUpdateComplete, clusteroperatorv1alpha1.ConditionFalse, reasonAwaitingApproval,
+		fmt.Sprintf("Set annotation %s=%s to replace nodes", clusteroperatorv1alpha1.ApprovedRollingUpdateAnnotation, generation))
+	return false
+}
+
+// validating checks whether the cluster is up, it handles both a cluster that
+// is coming up and the periodic check of a Ready cluster
+func (r *ReconcileCluster) validating(ctx context.Context, c *clusterContext) (clusteroperatorv1alpha1.ClusterPhase, reconcile.Result, error) {
+	instance := c.instance
+
+	status, err := c.kops.ValidateCluster(ctx, c.kc)
+	if err == nil {
+		// keep the last result when kops could not validate at all, the
+		// timestamp tells how old it is
+		now := metav1.Now()
+		status.LastValidated = &now
+		instance.Status.KopsStatus = status
+	}
+	if _, next, err := clusteroperatorv1alpha1.InMaintenanceWindow(instance.Spec.MaintenanceWindows, clock()); err == nil {
+		setNextMaintenanceWindow(instance, next)
+	}
+
+	if err != nil || len(status.Failures) > 0 {
+		c.log.Info("Cluster Not Ready", "reason", utils.ErrorReasonFor(err), "failures", len(status.Failures))
+		instance.Status.Validated = false
+		if len(status.Failures) > 0 {
+			setCondition(instance, clusteroperatorv1alpha1.ConditionValidated, clusteroperatorv1alpha1.ConditionFalse, reasonValidationFailed, validationMessage(status.Failures))
+		} else {
+			setPhaseFailed(instance, clusteroperatorv1alpha1.ClusterValidating, err)
+		}
+	} else if len(status.Nodes) > 0 {
+		instance.Status.Validated = true
+		setCondition(instance, clusteroperatorv1alpha1.ConditionValidated, clusteroperatorv1alpha1.ConditionTrue, reasonSucceeded, nodesMessage(status.NodeCounts))
+		verified, err := r.verifyKubernetesVersion(ctx, c)
+		if err != nil || !verified {
+			return clusteroperatorv1alpha1.ClusterValidating, reconcile.Result{RequeueAfter: validateRetryInterval}, err
+		}
+		if instance.Status.Phase == clusteroperatorv1alpha1.ClusterReady && checkDrift(ctx, c) {
+			c.log.Info("Correcting drift")
+			return clusteroperatorv1alpha1.ClusterConfiguring, reconcile.Result{}, nil
+		}
+		c.log.Info("Cluster Ready")
+		//requeues every ten minutes to make sure its synced if any manual changes were done
+		return clusteroperatorv1alpha1.ClusterReady, reconcile.Result{RequeueAfter: readyResyncInterval}, nil
+	} else {
+		// FIXME - If we get this state try validate again!!!
+		c.log.Info("Validate Returned Unexpected Result")
+		setCondition(instance, clusteroperatorv1alpha1.ConditionValidated, clusteroperatorv1alpha1.ConditionUnknown, string(utils.ReasonUnknown), "kops validate reported no nodes")
+	}
+
+	//It did not finish validating, requeue in five minutes
+	return clusteroperatorv1alpha1.ClusterValidating, reconcile.Result{RequeueAfter: validateRetryInterval}, nil
+}
+
+// failed retries the phase that failed
+func (r *ReconcileCluster) failed(ctx context.Context, c *clusterContext) (clusteroperatorv1alpha1.ClusterPhase, reconcile.Result, error) {
+	phase := c.instance.Status.FailedPhase
+	if phase == "" || phase == clusteroperatorv1alpha1.ClusterFailed {
+		phase = clusteroperatorv1alpha1.ClusterPending
+	}
+	c.log.Info("Retrying failed phase", "FailedPhase", phase)
+	return phase, reconcile.Result{}, nil
+}
+
+// deleting removes the cluster from the cloud and then the finalizer
+func (r *ReconcileCluster) deleting(ctx context.Context, c *clusterContext) (clusteroperatorv1alpha1.ClusterPhase, reconcile.Result, error) {
+	instance := c.instance
+
+	if instance.Status.Phase != clusteroperatorv1alpha1.ClusterDeleting {
+		instance.Status.Phase = clusteroperatorv1alpha1.ClusterDeleting
+		setCondition(instance, clusteroperatorv1alpha1.ConditionDeleting, clusteroperatorv1alpha1.ConditionTrue, reasonDeletionStarted, "Removing cluster from the cloud")
+		setReady(instance)
+		if err := r.client.Status().Update(ctx, instance); err != nil {
+			return clusteroperatorv1alpha1.ClusterDeleting, reconcile.Result{}, err
+		}
+	}
+
+	if instance.Annotations[clusteroperatorv1alpha1.SkipKopsDeleteAnnotation] == "true" {
+		c.log.Info("Leaving the kops cluster behind as annotated")
+		return r.removeFinalizer(ctx, c)
+	}
+	if c.unavailable != nil {
+		// the Secret is usually deleted along with the namespace before
+		// the Cluster, it may never come back
+		c.log.Info("Cannot delete the kops cluster", "reason", c.unavailableReason, "error", c.unavailable.Error())
+		setCondition(instance, clusteroperatorv1alpha1.ConditionDeleting, clusteroperatorv1alpha1.ConditionTrue, c.unavailableReason,
+			fmt.Sprintf("%v, set annotation %s=true to remove the Cluster and leave the kops cluster behind", c.unavailable, clusteroperatorv1alpha1.SkipKopsDeleteAnnotation))
+		return clusteroperatorv1alpha1.ClusterDeleting, reconcile.Result{RequeueAfter: deleteRetryInterval}, nil
+	}
+
+	unlock, err := r.lock(ctx, instance.Spec.KopsConfig)
+	if err != nil {
+		return busy(c, clusteroperatorv1alpha1.ClusterDeleting)
+	}
+	defer unlock()
+
+	// only a positively identified NotFound lets the finalizer go, any
+	// other failure (credentials, state store outage) is retried
+	exists, err := c.kops.GetCluster(ctx, instance.Spec.KopsConfig)
+	if err != nil {
+		c.log.WithValues("error", err, "reason", utils.ErrorReasonFor(err)).Info("Error getting cluster")
+		return clusteroperatorv1alpha1.ClusterDeleting, reconcile.Result{}, err
+	} else if !exists {
+		c.log.Info("Cluster is already deleted...")
+	} else {
+		err = c.kops.DeleteCluster(ctx, instance.Spec.KopsConfig)
+		if err != nil {
+			//error deleting cluster
+			return clusteroperatorv1alpha1.ClusterDeleting, reconcile.Result{}, err
+		}
+	}
+
+	return r.removeFinalizer(ctx, c)
+}
+
+// removeFinalizer lets the API server remove the Cluster
+func (r *ReconcileCluster) removeFinalizer(ctx context.Context, c *clusterContext) (clusteroperatorv1alpha1.ClusterPhase, reconcile.Result, error) {
+	// our finalizer is present, so delete cluster first
+	// remove our finalizer from the list and update it.
+	c.instance.ObjectMeta.Finalizers = utils.Remove(c.instance.ObjectMeta.Finalizers, clusterFinalizer)
+	if err := r.client.Update(ctx, c.instance); err != nil {
+		return clusteroperatorv1alpha1.ClusterDeleting, reconcile.Result{}, err
+	}
+
+	// Stop reconciliation as the item is being deleted
+	return "", reconcile.Result{}, nil
+}
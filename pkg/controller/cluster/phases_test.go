@@ -0,0 +1,128 @@
+package cluster
+
+import (
+	"errors"
+	"testing"
+
+	clusteroperatorv1alpha1 "github.com/infobloxopen/cluster-operator/pkg/apis/clusteroperator/v1alpha1"
+	"github.com/infobloxopen/cluster-operator/utils"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCurrentPhase(t *testing.T) {
+	now := metav1.Now()
+	cases := []struct {
+		name       string
+		phase      clusteroperatorv1alpha1.ClusterPhase
+		waiting    string
+		generation int64
+		observed   int64
+		deleted    *metav1.Time
+		expected   clusteroperatorv1alpha1.ClusterPhase
+	}{
+		{"new", "", "", 1, 0, nil, clusteroperatorv1alpha1.ClusterPending},
+		{"resume applying", clusteroperatorv1alpha1.ClusterApplying, "", 2, 1, nil, clusteroperatorv1alpha1.ClusterApplying},
+		{"resume rolling update", clusteroperatorv1alpha1.ClusterRollingUpdate, "", 1, 1, nil, clusteroperatorv1alpha1.ClusterRollingUpdate},
+		{"ready", clusteroperatorv1alpha1.ClusterReady, "", 1, 1, nil, clusteroperatorv1alpha1.ClusterReady},
+		{"ready spec changed", clusteroperatorv1alpha1.ClusterReady, "", 2, 1, nil, clusteroperatorv1alpha1.ClusterConfiguring},
+		{"validating spec changed", clusteroperatorv1alpha1.ClusterValidating, "", 2, 1, nil, clusteroperatorv1alpha1.ClusterConfiguring},
+		{"failed spec changed", clusteroperatorv1alpha1.ClusterFailed, "", 3, 2, nil, clusteroperatorv1alpha1.ClusterConfiguring},
+		{"failed", clusteroperatorv1alpha1.ClusterFailed, "", 2, 2, nil, clusteroperatorv1alpha1.ClusterFailed},
+		{"awaiting approval", clusteroperatorv1alpha1.ClusterApplying, reasonAwaitingApproval, 1, 1, nil, clusteroperatorv1alpha1.ClusterApplying},
+		{"awaiting approval spec changed", clusteroperatorv1alpha1.ClusterApplying, reasonAwaitingApproval, 2, 1, nil, clusteroperatorv1alpha1.ClusterConfiguring},
+		{"awaiting rolling update spec changed", clusteroperatorv1alpha1.ClusterRollingUpdate, reasonAwaitingApproval, 2, 1, nil, clusteroperatorv1alpha1.ClusterConfiguring},
+		{"outside maintenance window spec changed", clusteroperatorv1alpha1.ClusterRollingUpdate, reasonOutsideMaintenanceWindow, 2, 1, nil, clusteroperatorv1alpha1.ClusterConfiguring},
+		{"deleted", clusteroperatorv1alpha1.ClusterApplying, "", 1, 1, &now, clusteroperatorv1alpha1.ClusterDeleting},
+	}
+
+	for _, c := range cases {
+		instance := &clusteroperatorv1alpha1.Cluster{}
+		instance.Generation = c.generation
+		instance.DeletionTimestamp = c.deleted
+		instance.Status.Phase = c.phase
+		instance.Status.ObservedGeneration = c.observed
+		if c.waiting != "" {
+			setCondition(instance, phaseConditions[c.phase], clusteroperatorv1alpha1.ConditionFalse, c.waiting, "")
+		}
+
+		if phase := currentPhase(instance); phase != c.expected {
+			t.Errorf("%s: got: %s wanted: %s", c.name, phase, c.expected)
+		}
+	}
+}
+
+func TestMigratePhase(t *testing.T) {
+	cases := []struct {
+		phase    clusteroperatorv1alpha1.ClusterPhase
+		expected clusteroperatorv1alpha1.ClusterPhase
+		migrated bool
+	}{
+		{"", "", false},
+		{clusteroperatorv1alpha1.ClusterReady, clusteroperatorv1alpha1.ClusterReady, false},
+		{clusteroperatorv1alpha1.ClusterUpdate, clusteroperatorv1alpha1.ClusterApplying, true},
+		{clusteroperatorv1alpha1.ClusterSetup, clusteroperatorv1alpha1.ClusterValidating, true},
+		{clusteroperatorv1alpha1.ClusterDone, clusteroperatorv1alpha1.ClusterReady, true},
+	}
+
+	for _, c := range cases {
+		instance := &clusteroperatorv1alpha1.Cluster{}
+		instance.Status.Phase = c.phase
+		migrated := migratePhase(instance)
+		if instance.Status.Phase != c.expected || migrated != c.migrated {
+			t.Errorf("%q: got: %s, %v wanted: %s, %v", c.phase, instance.Status.Phase, migrated, c.expected, c.migrated)
+		}
+	}
+}
+
+func TestPermanent(t *testing.T) {
+	cases := []struct {
+		err      error
+		expected bool
+	}{
+		{errors.New("conflict"), false},
+		{&utils.CommandError{Reason: utils.ReasonThrottled}, false},
+		{&utils.CommandError{Reason: utils.ReasonTransient}, false},
+		{&utils.CommandError{Reason: utils.ReasonUnauthorized}, true},
+		{&utils.CommandError{Reason: utils.ReasonUnknown}, true},
+		{&specError{reason: reasonInvalidMaintenanceWindow, err: errors.New("invalid window")}, true},
+	}
+
+	for _, c := range cases {
+		if p := permanent(c.err); p != c.expected {
+			t.Errorf("%v: got: %v wanted: %v", c.err, p, c.expected)
+		}
+	}
+}
+
+func TestRollingUpdateAllowed(t *testing.T) {
+	cases := []struct {
+		name     string
+		strategy clusteroperatorv1alpha1.RollingUpdateStrategy
+		approved string
+		expected bool
+	}{
+		{"default", "", "", true},
+		{"auto", clusteroperatorv1alpha1.RollingUpdateAuto, "", true},
+		{"manual", clusteroperatorv1alpha1.RollingUpdateManual, "", false},
+		{"manual approved", clusteroperatorv1alpha1.RollingUpdateManual, "3", true},
+		{"manual approved earlier generation", clusteroperatorv1alpha1.RollingUpdateManual, "2", false},
+	}
+
+	for _, c := range cases {
+		instance := &clusteroperatorv1alpha1.Cluster{}
+		instance.Generation = 3
+		instance.Status.ObservedGeneration = 3
+		instance.Spec.RollingUpdate = &clusteroperatorv1alpha1.RollingUpdateSpec{Strategy: c.strategy}
+		if c.approved != "" {
+			instance.Annotations = map[string]string{clusteroperatorv1alpha1.ApprovedRollingUpdateAnnotation: c.approved}
+		}
+
+		if allowed := rollingUpdateAllowed(&clusterContext{instance: instance, log: log}); allowed != c.expected {
+			t.Errorf("%s: got: %v wanted: %v", c.name, allowed, c.expected)
+		}
+		cond := clusteroperatorv1alpha1.FindCondition(instance.Status.Conditions, clusteroperatorv1alpha1.ConditionRollingUpdateComplete)
+		if awaiting := cond != nil && cond.Reason == reasonAwaitingApproval; awaiting == c.expected {
+			t.Errorf("%s: expected awaiting approval condition %v got %v", c.name, !c.expected, cond)
+		}
+	}
+}
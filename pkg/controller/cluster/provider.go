@@ -0,0 +1,36 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+
+	clusteroperatorv1alpha1 "github.com/infobloxopen/cluster-operator/pkg/apis/clusteroperator/v1alpha1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// getProvider returns the ClusterProvider instance references, nil when it
+// uses the operator settings
+func (r *ReconcileCluster) getProvider(ctx context.Context, instance *clusteroperatorv1alpha1.Cluster) (*clusteroperatorv1alpha1.ClusterProvider, error) {
+	ref := instance.Spec.ProviderRef
+	if ref == nil {
+		return nil, nil
+	}
+
+	// ClusterProviders are cluster scoped, the namespaced cache does not
+	// hold them
+	provider := &clusteroperatorv1alpha1.ClusterProvider{}
+	if err := r.reader.Get(ctx, types.NamespacedName{Name: ref.Name}, provider); err != nil {
+		return nil, fmt.Errorf("cannot read ClusterProvider %s: %v", ref.Name, err)
+	}
+	return provider, nil
+}
+
+// providerCredentials is the credentials Secret of provider, the namespace of
+// the reference is required as the ClusterProvider has none
+func providerCredentials(provider *clusteroperatorv1alpha1.ClusterProvider) (types.NamespacedName, error) {
+	ref := provider.Spec.CredentialsRef
+	if ref.Name == "" || ref.Namespace == "" {
+		return types.NamespacedName{}, fmt.Errorf("ClusterProvider %s: the name and namespace of the credentials Secret are required", provider.Name)
+	}
+	return types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}, nil
+}
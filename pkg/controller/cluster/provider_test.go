@@ -0,0 +1,36 @@
+package cluster
+
+import (
+	"testing"
+
+	clusteroperatorv1alpha1 "github.com/infobloxopen/cluster-operator/pkg/apis/clusteroperator/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestProviderCredentials(t *testing.T) {
+	tests := []struct {
+		ref      clusteroperatorv1alpha1.CredentialsReference
+		expected string
+	}{
+		{clusteroperatorv1alpha1.CredentialsReference{Name: "aws", Namespace: "accounts"}, "accounts/aws"},
+		{clusteroperatorv1alpha1.CredentialsReference{Name: "aws"}, ""},
+		{clusteroperatorv1alpha1.CredentialsReference{Namespace: "accounts"}, ""},
+	}
+
+	for _, test := range tests {
+		provider := &clusteroperatorv1alpha1.ClusterProvider{
+			ObjectMeta: metav1.ObjectMeta{Name: "team"},
+			Spec:       clusteroperatorv1alpha1.ClusterProviderSpec{CredentialsRef: &test.ref},
+		}
+		name, err := providerCredentials(provider)
+		if test.expected == "" {
+			if err == nil {
+				t.Errorf("%+v: expected an error got %s", test.ref, name)
+			}
+			continue
+		}
+		if err != nil || name.String() != test.expected {
+			t.Errorf("%+v: expected %s got %s, %v", test.ref, test.expected, name, err)
+		}
+	}
+}
@@ -0,0 +1,408 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/infobloxopen/cluster-operator/kops"
+	clusteroperatorv1alpha1 "github.com/infobloxopen/cluster-operator/pkg/apis/clusteroperator/v1alpha1"
+	"github.com/infobloxopen/cluster-operator/utils"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/spf13/viper"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var reapedClusters = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "cluster_operator_reaped_clusters_total",
+		Help: "Orphaned kops clusters found by the reaper, by what was done about them",
+	},
+	[]string{"state_store", "action"},
+)
+
+func init() {
+	metrics.Registry.MustRegister(reapedClusters)
+}
+
+// Actions the reaper counts and Event reasons it records
+const (
+	reapDeleted = "deleted"
+	reapDryRun  = "dry_run"
+	reapFailed  = "failed"
+
+	eventOrphanDetected     = "OrphanDetected"
+	eventOrphanDeleted      = "OrphanDeleted"
+	eventOrphanDeleteFailed = "OrphanDeleteFailed"
+)
+
+// ReaperConfig controls the removal of kops clusters that were created by the
+// operator and lost their Cluster
+type ReaperConfig struct {
+	// Enabled runs the reaper
+	Enabled bool
+	// Interval is how often the state stores are checked
+	Interval time.Duration
+	// DryRun only reports orphaned clusters
+	DryRun bool
+	// MinAge is how long a kops cluster has to be seen without its Cluster
+	// before it is removed
+	MinAge time.Duration
+	// Allowlist are kops cluster names, or path.Match patterns of them,
+	// that are never removed
+	Allowlist []string
+	// StateStore is checked besides the state stores of the Clusters
+	StateStore string
+}
+
+// Validate checks the settings of an enabled reaper
+func (c ReaperConfig) Validate() error {
+	if c.Interval <= 0 {
+		return fmt.Errorf("reaper.interval must be positive, got %v", c.Interval)
+	}
+	return nil
+}
+
+// Reaper periodically removes orphaned kops clusters from the state stores.
+// A kops cluster is orphaned when it carries the owner labels the operator
+// writes and no Cluster in any namespace owns it anymore.
+type Reaper struct {
+	ReaperConfig
+	// reader lists Clusters in all namespaces, past the namespaced cache
+	reader      client.Reader
+	credentials CredentialsConfig
+	recorder    record.EventRecorder
+	locks       *LockManager
+	log         logr.Logger
+
+	// orphans is when each orphaned kops cluster was first seen, keyed
+	// by state store and name
+	orphans map[string]time.Time
+}
+
+// blank assignment to verify that Reaper implements manager.Runnable
+var _ manager.Runnable = &Reaper{}
+
+// newReaper returns a Reaper taking the kops locks from locks
+func newReaper(cfg ReconcilerConfig, locks *LockManager) *Reaper {
+	return &Reaper{
+		ReaperConfig: cfg.Reaper,
+		reader:       cfg.Mgr.GetAPIReader(),
+		credentials:  cfg.Credentials,
+		recorder:     cfg.Mgr.GetEventRecorderFor("cluster-operator-reaper"),
+		locks:        locks,
+		log:          log.WithName("reaper"),
+		orphans:      map[string]time.Time{},
+	}
+}
+
+// Start checks the state stores every Interval until stop is closed
+func (r *Reaper) Start(stop <-chan struct{}) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-stop
+		cancel()
+	}()
+
+	r.log.Info("Starting reaper", "Interval", r.Interval, "DryRun", r.DryRun, "MinAge", r.MinAge, "Allowlist", r.Allowlist)
+	ticker := time.NewTicker(r.Interval)
+	defer ticker.Stop()
+	for {
+		if err := r.reap(ctx); err != nil {
+			r.log.Error(err, "Reaping failed", "reason", utils.ErrorReasonFor(err))
+		}
+		select {
+		case <-stop:
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// reap checks every state store once. Nothing is removed when the Clusters
+// cannot be listed, a kops cluster that cannot be inspected is left alone.
+func (r *Reaper) reap(ctx context.Context) error {
+	clusters := &clusteroperatorv1alpha1.ClusterList{}
+	if err := r.reader.List(ctx, clusters); err != nil {
+		return err
+	}
+	providers := &clusteroperatorv1alpha1.ClusterProviderList{}
+	if err := r.reader.List(ctx, providers); err != nil {
+		return err
+	}
+
+	seen := map[string]bool{}
+	for i, target := range reapTargets(r.StateStore, clusters.Items, providers.Items, r.credentials.AllowedNamespaces) {
+		if target.err != nil {
+			r.log.Info("Skipping state store", "StateStore", target.stateStore, "reason", target.err.Error())
+			continue
+		}
+		if err := r.reapStateStore(ctx, fmt.Sprintf("reaper-%d", i), target, clusters.Items, seen); err != nil {
+			r.log.Error(err, "Cannot check state store", "StateStore", target.stateStore, "reason", utils.ErrorReasonFor(err))
+		}
+	}
+
+	// a cluster that is gone or owned again starts over
+	for key := range r.orphans {
+		if !seen[key] {
+			delete(r.orphans, key)
+		}
+	}
+	return nil
+}
+
+// reapStateStore removes the orphaned kops clusters of target, running kops
+// in workspace id with the credentials of target. The orphans found are
+// added to seen.
+func (r *Reaper) reapStateStore(ctx context.Context, id string, target reapTarget, clusters []clusteroperatorv1alpha1.Cluster, seen map[string]bool) error {
+	stateStore := target.stateStore
+	ws, err := utils.NewWorkspace(viper.GetString("tmp.dir"), id)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := ws.Close(); err != nil {
+			r.log.Error(err, "cannot remove workspace", "dir", ws.Dir)
+		}
+	}()
+	if err := useCredentials(ctx, r.reader, ws, target.credentials); err != nil {
+		return err
+	}
+	k, err := kops.NewKops(ws)
+	if err != nil {
+		return err
+	}
+
+	summaries, err := k.ListClusters(ctx, stateStore)
+	if err != nil {
+		return err
+	}
+	for _, summary := range summaries {
+		name := summary.Name
+		if name == "" || owned(name, stateStore, "", clusters) || allowed(name, r.Allowlist) {
+			continue
+		}
+		kc := clusteroperatorv1alpha1.KopsConfig{Name: name, StateStore: stateStore}
+		metadata, err := k.GetClusterMetadata(ctx, kc)
+		if err != nil {
+			r.log.Error(err, "Cannot get cluster", "StateStore", stateStore, "Cluster", name)
+			continue
+		}
+		uid := metadata.Labels[clusteroperatorv1alpha1.OwnerUIDLabel]
+		if uid == "" || owned(name, stateStore, uid, clusters) {
+			// not created by the operator, or owned by a Cluster
+			// that moved it to another name
+			continue
+		}
+
+		key := orphanKey(kc)
+		seen[key] = true
+		if !r.graceElapsed(key, clock()) {
+			r.log.Info("Orphaned cluster in grace period", "StateStore", stateStore, "Cluster", name, "FirstSeen", r.orphans[key])
+			continue
+		}
+		r.remove(ctx, k, kc, metadata.Labels)
+	}
+	return nil
+}
+
+// remove deletes the orphaned kops cluster kc, or only reports it in dry-run
+// mode, and records the outcome on the Cluster it belonged to
+func (r *Reaper) remove(ctx context.Context, k *kops.KopsCmd, kc clusteroperatorv1alpha1.KopsConfig, labels map[string]string) {
+	owner := ownerReference(labels)
+	log := r.log.WithValues("StateStore", kc.StateStore, "Cluster", kc.Name, "Owner", owner.Namespace+"/"+owner.Name)
+
+	if r.DryRun {
+		log.Info("Orphaned cluster found, not deleting it in dry-run mode")
+		reapedClusters.WithLabelValues(kc.StateStore, reapDryRun).Inc()
+		r.recorder.Eventf(owner, corev1.EventTypeNormal, eventOrphanDetected, "Orphaned kops cluster %s in %s would be deleted", kc.Name, kc.StateStore)
+		return
+	}
+
+	lockCtx, cancel := context.WithTimeout(ctx, lockWaitTimeout)
+	unlock, err := r.locks.Lock(lockCtx, kc.Name, kc.StateStore)
+	cancel()
+	if err != nil {
+		log.Info("Cluster or state store busy, trying again next time")
+		return
+	}
+	defer unlock()
+
+	log.Info("Deleting orphaned cluster")
+	if err := k.DeleteCluster(ctx, kc); err != nil {
+		log.Error(err, "Cannot delete orphaned cluster", "reason", utils.ErrorReasonFor(err))
+		reapedClusters.WithLabelValues(kc.StateStore, reapFailed).Inc()
+		r.recorder.Eventf(owner, corev1.EventTypeWarning, eventOrphanDeleteFailed, "Cannot delete orphaned kops cluster %s in %s: %v", kc.Name, kc.StateStore, err)
+		return
+	}
+	delete(r.orphans, orphanKey(kc))
+	reapedClusters.WithLabelValues(kc.StateStore, reapDeleted).Inc()
+	r.recorder.Eventf(owner, corev1.EventTypeNormal, eventOrphanDeleted, "Deleted orphaned kops cluster %s in %s", kc.Name, kc.StateStore)
+}
+
+// graceElapsed records when the orphan key was first seen and reports whether
+// it has been orphaned for MinAge at now
+func (r *Reaper) graceElapsed(key string, now time.Time) bool {
+	first, ok := r.orphans[key]
+	if !ok {
+		r.orphans[key] = now
+		first = now
+	}
+	return now.Sub(first) >= r.MinAge
+}
+
+// ownerLabels are the labels tying the kops cluster of instance to instance
+func ownerLabels(instance *clusteroperatorv1alpha1.Cluster) map[string]string {
+	return map[string]string{
+		clusteroperatorv1alpha1.OwnerUIDLabel:       string(instance.UID),
+		clusteroperatorv1alpha1.OwnerNamespaceLabel: instance.Namespace,
+		clusteroperatorv1alpha1.OwnerNameLabel:      instance.Name,
+	}
+}
+
+// ownerReference refers to the Cluster named in the owner labels, Events
+// about its kops cluster are recorded on it even after it is gone
+func ownerReference(labels map[string]string) *corev1.ObjectReference {
+	return &corev1.ObjectReference{
+		APIVersion: clusteroperatorv1alpha1.SchemeGroupVersion.String(),
+		Kind:       "Cluster",
+		Namespace:  labels[clusteroperatorv1alpha1.OwnerNamespaceLabel],
+		Name:       labels[clusteroperatorv1alpha1.OwnerNameLabel],
+		UID:        types.UID(labels[clusteroperatorv1alpha1.OwnerUIDLabel]),
+	}
+}
+
+// owned reports whether a Cluster in any namespace uses the kops cluster name
+// in stateStore, or has the UID uid when it is not empty
+func owned(name, stateStore, uid string, clusters []clusteroperatorv1alpha1.Cluster) bool {
+	for _, c := range clusters {
+		kc := c.Spec.KopsConfig
+		if kc.Name == name && sameStateStore(kc.StateStore, stateStore) {
+			return true
+		}
+		if uid != "" && string(c.UID) == uid {
+			return true
+		}
+	}
+	return false
+}
+
+// allowed reports whether name matches an entry of allowlist
+func allowed(name string, allowlist []string) bool {
+	for _, pattern := range allowlist {
+		if ok, err := path.Match(pattern, name); ok && err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// reapTarget is a state store the reaper checks and the credentials it runs
+// kops with there
+type reapTarget struct {
+	stateStore  string
+	credentials credentialsSource
+	// err is why the state store cannot be checked
+	err error
+}
+
+// reapTargets lists the state stores of the Clusters, the ClusterProviders
+// and the operator without duplicates, with the credentials the Clusters and
+// ClusterProviders use them with. A state store whose credentials cannot be
+// resolved, or that is used with different credentials, is not checked: its
+// kops clusters may not be listed and could be deleted in the wrong account.
+func reapTargets(stateStore string, clusters []clusteroperatorv1alpha1.Cluster, providers []clusteroperatorv1alpha1.ClusterProvider, allowed []string) []reapTarget {
+	var targets []reapTarget
+	add := func(stateStore string, src credentialsSource, err error) {
+		if stateStore == "" {
+			return
+		}
+		for i := range targets {
+			t := &targets[i]
+			if !sameStateStore(t.stateStore, stateStore) {
+				continue
+			}
+			if t.err == nil && err != nil {
+				t.err = err
+			} else if t.err == nil && t.credentials != src {
+				t.err = fmt.Errorf("the state store is used with different credentials")
+			}
+			return
+		}
+		targets = append(targets, reapTarget{stateStore: stateStore, credentials: src, err: err})
+	}
+
+	byName := map[string]*clusteroperatorv1alpha1.ClusterProvider{}
+	for i := range providers {
+		provider := &providers[i]
+		byName[provider.Name] = provider
+		src, err := providerSource(provider)
+		add(provider.Spec.StateStore, src, err)
+	}
+	for i := range clusters {
+		c := &clusters[i]
+		var provider *clusteroperatorv1alpha1.ClusterProvider
+		if ref := c.Spec.ProviderRef; ref != nil {
+			if provider = byName[ref.Name]; provider == nil {
+				add(c.Spec.KopsConfig.StateStore, credentialsSource{}, fmt.Errorf("ClusterProvider %s of Cluster %s/%s not found", ref.Name, c.Namespace, c.Name))
+				continue
+			}
+		}
+		src, err := clusterCredentials(c, provider, allowed)
+		add(c.Spec.KopsConfig.StateStore, src, err)
+	}
+
+	// the state store of the operator is checked with the operator
+	// credentials unless Clusters or ClusterProviders say otherwise
+	for _, t := range targets {
+		if sameStateStore(t.stateStore, stateStore) {
+			return targets
+		}
+	}
+	add(stateStore, credentialsSource{}, nil)
+	return targets
+}
+
+// sameStateStore compares state store URLs, s3://bucket and s3://bucket/ are
+// the same
+func sameStateStore(a, b string) bool {
+	return stateStoreKey(a) == stateStoreKey(b)
+}
+
+// stateStoreKey is the normalized URL of stateStore, URLs that cannot be
+// parsed are only stripped of trailing slashes
+func stateStoreKey(stateStore string) string {
+	if normalized, err := kops.NormalizeStateStore(stateStore); err == nil {
+		return normalized
+	}
+	return strings.TrimSuffix(stateStore, "/")
+}
+
+// orphanKey identifies the kops cluster kc across state stores, spellings of
+// the same state store share the key
+func orphanKey(kc clusteroperatorv1alpha1.KopsConfig) string {
+	return stateStoreKey(kc.StateStore) + "/" + kc.Name
+}
+
+// ParseList splits entries that may hold several comma separated values,
+// empty values are dropped
+func ParseList(entries []string) []string {
+	var values []string
+	for _, entry := range entries {
+		for _, value := range strings.Split(entry, ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				values = append(values, value)
+			}
+		}
+	}
+	return values
+}
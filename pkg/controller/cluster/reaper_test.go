@@ -0,0 +1,184 @@
+package cluster
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	clusteroperatorv1alpha1 "github.com/infobloxopen/cluster-operator/pkg/apis/clusteroperator/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func reaperCluster(namespace, uid, name, stateStore string) clusteroperatorv1alpha1.Cluster {
+	c := clusteroperatorv1alpha1.Cluster{}
+	c.Namespace = namespace
+	c.UID = types.UID(uid)
+	c.Spec.KopsConfig = clusteroperatorv1alpha1.KopsConfig{Name: name, StateStore: stateStore}
+	return c
+}
+
+func TestOwned(t *testing.T) {
+	clusters := []clusteroperatorv1alpha1.Cluster{
+		reaperCluster("default", "1", "a.example.com", "s3://state"),
+		reaperCluster("other", "2", "b.example.com", "s3://other/"),
+	}
+
+	cases := []struct {
+		name       string
+		cluster    string
+		stateStore string
+		uid        string
+		expected   bool
+	}{
+		{"same namespace", "a.example.com", "s3://state/", "", true},
+		{"other namespace", "b.example.com", "s3://other", "", true},
+		{"other state store", "a.example.com", "s3://other", "", false},
+		{"renamed", "old.example.com", "s3://state", "1", true},
+		{"orphaned", "old.example.com", "s3://state", "3", false},
+	}
+
+	for _, c := range cases {
+		if got := owned(c.cluster, c.stateStore, c.uid, clusters); got != c.expected {
+			t.Errorf("%s: expected %v got %v", c.name, c.expected, got)
+		}
+	}
+}
+
+func TestAllowed(t *testing.T) {
+	allowlist := []string{"prod.example.com", "*.keep.example.com"}
+	cases := map[string]bool{
+		"prod.example.com":    true,
+		"a.keep.example.com":  true,
+		"dev.example.com":     false,
+		"a.b.keep.example.co": false,
+	}
+	for name, expected := range cases {
+		if got := allowed(name, allowlist); got != expected {
+			t.Errorf("%s: expected %v got %v", name, expected, got)
+		}
+	}
+}
+
+func TestReapTargets(t *testing.T) {
+	withSecret := reaperCluster("team", "4", "d.example.com", "s3://team")
+	withSecret.Spec.CredentialsRef = &clusteroperatorv1alpha1.CredentialsReference{Name: "aws"}
+	shared := reaperCluster("default", "5", "e.example.com", "s3://team")
+	withProvider := reaperCluster("default", "6", "f.example.com", "s3://provider/")
+	withProvider.Spec.ProviderRef = &corev1.LocalObjectReference{Name: "provider"}
+	missing := reaperCluster("default", "7", "g.example.com", "s3://missing")
+	missing.Spec.ProviderRef = &corev1.LocalObjectReference{Name: "missing"}
+	forbidden := reaperCluster("default", "8", "h.example.com", "s3://forbidden")
+	forbidden.Spec.CredentialsRef = &clusteroperatorv1alpha1.CredentialsReference{Name: "aws", Namespace: "kube-system"}
+	clusters := []clusteroperatorv1alpha1.Cluster{
+		reaperCluster("default", "1", "a.example.com", "s3://state/"),
+		reaperCluster("other", "2", "b.example.com", "s3://other"),
+		reaperCluster("other", "3", "c.example.com", ""),
+		withSecret, shared, withProvider, missing, forbidden,
+	}
+	providers := []clusteroperatorv1alpha1.ClusterProvider{{
+		ObjectMeta: metav1.ObjectMeta{Name: "provider"},
+		Spec: clusteroperatorv1alpha1.ClusterProviderSpec{
+			StateStore:        "s3://provider",
+			Region:            "us-east-2",
+			CredentialsRef:    &clusteroperatorv1alpha1.CredentialsReference{Name: "aws", Namespace: "accounts"},
+			AllowedNamespaces: []string{"default"},
+		},
+	}}
+
+	expected := []struct {
+		stateStore string
+		secret     string
+		region     string
+		err        bool
+	}{
+		{"s3://provider", "accounts/aws", "us-east-2", false},
+		{"s3://state/", "", "", false},
+		{"s3://other", "", "", false},
+		{"s3://team", "", "", true},
+		{"s3://missing", "", "", true},
+		{"s3://forbidden", "", "", true},
+		{"s3://operator", "", "", false},
+	}
+	targets := reapTargets("s3://operator", clusters, providers, nil)
+	if len(targets) != len(expected) {
+		t.Fatalf("expected %d state stores got %+v", len(expected), targets)
+	}
+	for i, e := range expected {
+		target := targets[i]
+		if target.stateStore != e.stateStore || (target.err != nil) != e.err {
+			t.Errorf("expected %s with error %v got %+v", e.stateStore, e.err, target)
+			continue
+		}
+		secret := ""
+		if target.credentials.Secret.Name != "" {
+			secret = target.credentials.Secret.String()
+		}
+		if !e.err && (secret != e.secret || target.credentials.Region != e.region) {
+			t.Errorf("%s: expected credentials %s in %q got %+v", e.stateStore, e.secret, e.region, target.credentials)
+		}
+	}
+
+	// the operator state store is checked with the credentials its Clusters use
+	targets = reapTargets("s3://provider/", clusters, providers, nil)
+	if len(targets) != len(expected)-1 || targets[0].credentials.Region != "us-east-2" {
+		t.Errorf("expected the operator state store to use the provider credentials got %+v", targets)
+	}
+}
+
+func TestGraceElapsed(t *testing.T) {
+	r := &Reaper{ReaperConfig: ReaperConfig{MinAge: time.Hour}, orphans: map[string]time.Time{}}
+	now := time.Date(2020, 2, 1, 12, 0, 0, 0, time.UTC)
+
+	if r.graceElapsed("s3://state/a.example.com", now) {
+		t.Error("Expected no removal when first seen")
+	}
+	if r.graceElapsed("s3://state/a.example.com", now.Add(59*time.Minute)) {
+		t.Error("Expected no removal within the grace period")
+	}
+	if !r.graceElapsed("s3://state/a.example.com", now.Add(time.Hour)) {
+		t.Error("Expected removal after the grace period")
+	}
+}
+
+func TestOrphanKey(t *testing.T) {
+	a := orphanKey(clusteroperatorv1alpha1.KopsConfig{Name: "a.example.com", StateStore: "s3://state//prefix/"})
+	b := orphanKey(clusteroperatorv1alpha1.KopsConfig{Name: "a.example.com", StateStore: "s3://state/prefix"})
+	if a != b {
+		t.Errorf("Expected spellings of the same state store to share the key got %s and %s", a, b)
+	}
+}
+
+func TestParseList(t *testing.T) {
+	got := ParseList([]string{"a.example.com, b.example.com", "", "c.example.com"})
+	if expected := "a.example.com,b.example.com,c.example.com"; strings.Join(got, ",") != expected {
+		t.Errorf("Expected %s got %v", expected, got)
+	}
+}
+
+func TestReaperConfigValidate(t *testing.T) {
+	cases := []struct {
+		interval time.Duration
+		valid    bool
+	}{
+		{time.Hour, true},
+		{0, false},
+		{-time.Minute, false},
+	}
+
+	for _, c := range cases {
+		err := ReaperConfig{Enabled: true, Interval: c.interval}.Validate()
+		if (err == nil) != c.valid {
+			t.Errorf("%v: expected valid %v got %v", c.interval, c.valid, err)
+		}
+	}
+}
+
+func TestAddRejectsReaperInterval(t *testing.T) {
+	// the interval is checked before the manager is used
+	err := Add(ReconcilerConfig{Reaper: ReaperConfig{Enabled: true}})
+	if err == nil || !strings.Contains(err.Error(), "reaper.interval") {
+		t.Errorf("expected the interval to be rejected got %v", err)
+	}
+}
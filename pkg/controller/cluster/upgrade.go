@@ -0,0 +1,164 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	clusteroperatorv1alpha1 "github.com/infobloxopen/cluster-operator/pkg/apis/clusteroperator/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+const (
+	// maxUpgradeHistory is how many completed upgrades are kept in status
+	maxUpgradeHistory = 10
+	// versionTimeout bounds the requests to the workload cluster API
+	versionTimeout = 30 * time.Second
+)
+
+// versionFunc returns the version of the API server and the kubelet versions
+// of the nodes of the cluster kubeconfig points to
+type versionFunc func(kubeconfig []byte) (string, []string, error)
+
+// clusterVersions asks the workload cluster API for its versions
+func clusterVersions(kubeconfig []byte) (string, []string, error) {
+	config, err := clientcmd.RESTConfigFromKubeConfig(kubeconfig)
+	if err != nil {
+		return "", nil, err
+	}
+	config.Timeout = versionTimeout
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return "", nil, err
+	}
+
+	info, err := clientset.Discovery().ServerVersion()
+	if err != nil {
+		return "", nil, err
+	}
+	nodes, err := clientset.CoreV1().Nodes().List(metav1.ListOptions{})
+	if err != nil {
+		return "", nil, err
+	}
+	var kubelets []string
+	for _, node := range nodes.Items {
+		kubelets = append(kubelets, node.Status.NodeInfo.KubeletVersion)
+	}
+	return info.GitVersion, kubelets, nil
+}
+
+// startUpgrade records an upgrade when spec.kubernetesVersion moves away from
+// the verified version of the cluster. Upgrades that skip a minor version or
+// go back are refused, the webhook rejects them on admission already.
+func startUpgrade(instance *clusteroperatorv1alpha1.Cluster) error {
+	target := instance.Spec.KubernetesVersion
+	running := instance.Status.KubernetesVersion
+	if target == "" || running == "" {
+		// the version is not managed or was never verified, there is
+		// nothing to upgrade from
+		return nil
+	}
+	if err := clusteroperatorv1alpha1.ValidateUpgrade(running, target); err != nil {
+		return err
+	}
+
+	if upgrade := instance.Status.Upgrade; upgrade != nil {
+		// the target changed during the upgrade, it still starts from the
+		// version that was verified
+		upgrade.To = target
+	} else if target != running {
+		instance.Status.Upgrade = &clusteroperatorv1alpha1.KubernetesUpgrade{
+			From:      running,
+			To:        target,
+			StartTime: metav1.NewTime(clock()),
+		}
+	} else {
+		return nil
+	}
+	setCondition(instance, clusteroperatorv1alpha1.ConditionUpgraded, clusteroperatorv1alpha1.ConditionFalse, reasonInProgress,
+		fmt.Sprintf("Upgrading from %s to %s", running, target))
+	return nil
+}
+
+// verifyKubernetesVersion checks on the workload cluster API that the API
+// server and all nodes run spec.kubernetesVersion, it completes the upgrade
+// in progress once they do. It reports whether the version is verified.
+func (r *ReconcileCluster) verifyKubernetesVersion(ctx context.Context, c *clusterContext) (bool, error) {
+	instance := c.instance
+	target := instance.Spec.KubernetesVersion
+	if target == "" || (instance.Status.KubernetesVersion == target && instance.Status.Upgrade == nil) {
+		return true, nil
+	}
+
+	config, err := c.kops.GetKubeConfig(ctx, c.kc)
+	if err != nil {
+		return false, err
+	}
+	// memory state stores export no kubeconfig, there is no API to ask
+	if len(config.Clusters) > 0 {
+		data, err := config.Marshal()
+		if err != nil {
+			return false, err
+		}
+		server, kubelets, err := r.versions(data)
+		if err != nil {
+			return false, err
+		}
+		if mismatches := versionMismatches(target, server, kubelets); len(mismatches) > 0 {
+			c.log.Info("Kubernetes version not rolled out yet", "Version", target, "Mismatches", mismatches)
+			setCondition(instance, clusteroperatorv1alpha1.ConditionUpgraded, clusteroperatorv1alpha1.ConditionFalse, reasonVersionMismatch,
+				fmt.Sprintf("Waiting for %s to run %s", strings.Join(mismatches, " and "), target))
+			return false, nil
+		}
+	}
+
+	if upgrade := instance.Status.Upgrade; upgrade != nil {
+		c.log.Info("Upgrade complete", "From", upgrade.From, "To", upgrade.To)
+		now := metav1.NewTime(clock())
+		upgrade.CompletionTime = &now
+		history := append(instance.Status.UpgradeHistory, *upgrade)
+		if len(history) > maxUpgradeHistory {
+			history = history[len(history)-maxUpgradeHistory:]
+		}
+		instance.Status.UpgradeHistory = history
+		instance.Status.Upgrade = nil
+	}
+	instance.Status.KubernetesVersion = target
+	setCondition(instance, clusteroperatorv1alpha1.ConditionUpgraded, clusteroperatorv1alpha1.ConditionTrue, reasonSucceeded,
+		"API server and nodes run "+target)
+	return true, nil
+}
+
+// versionMismatches describes the parts of the cluster that do not run target
+func versionMismatches(target, server string, kubelets []string) []string {
+	var mismatches []string
+	if !sameVersion(target, server) {
+		mismatches = append(mismatches, "the API server ("+server+")")
+	}
+	behind := 0
+	for _, kubelet := range kubelets {
+		if !sameVersion(target, kubelet) {
+			behind++
+		}
+	}
+	if behind > 0 {
+		mismatches = append(mismatches, fmt.Sprintf("%d of %d nodes", behind, len(kubelets)))
+	}
+	return mismatches
+}
+
+// sameVersion compares release versions, v1.16.7 and 1.16.7 are the same
+func sameVersion(a, b string) bool {
+	va, err := clusteroperatorv1alpha1.ParseKubernetesVersion(a)
+	if err != nil {
+		return false
+	}
+	vb, err := clusteroperatorv1alpha1.ParseKubernetesVersion(b)
+	if err != nil {
+		return false
+	}
+	return va == vb
+}
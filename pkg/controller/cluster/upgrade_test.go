@@ -0,0 +1,74 @@
+package cluster
+
+import (
+	"strings"
+	"testing"
+
+	clusteroperatorv1alpha1 "github.com/infobloxopen/cluster-operator/pkg/apis/clusteroperator/v1alpha1"
+)
+
+func TestStartUpgrade(t *testing.T) {
+	cases := []struct {
+		name     string
+		target   string
+		running  string
+		upgrade  *clusteroperatorv1alpha1.KubernetesUpgrade
+		err      bool
+		expected *clusteroperatorv1alpha1.KubernetesUpgrade
+	}{
+		{name: "unmanaged", running: "1.15.10"},
+		{name: "never verified", target: "1.16.7"},
+		{name: "unchanged", target: "1.15.10", running: "1.15.10"},
+		{name: "minor upgrade", target: "1.16.7", running: "1.15.10", expected: &clusteroperatorv1alpha1.KubernetesUpgrade{From: "1.15.10", To: "1.16.7"}},
+		{name: "minor version skipped", target: "1.17.3", running: "1.15.10", err: true},
+		{name: "downgrade", target: "1.14.10", running: "1.15.10", err: true},
+		{
+			name:     "target changed during upgrade",
+			target:   "1.16.8",
+			running:  "1.15.10",
+			upgrade:  &clusteroperatorv1alpha1.KubernetesUpgrade{From: "1.15.10", To: "1.16.7"},
+			expected: &clusteroperatorv1alpha1.KubernetesUpgrade{From: "1.15.10", To: "1.16.8"},
+		},
+	}
+
+	for _, c := range cases {
+		instance := &clusteroperatorv1alpha1.Cluster{}
+		instance.Spec.KubernetesVersion = c.target
+		instance.Status.KubernetesVersion = c.running
+		instance.Status.Upgrade = c.upgrade
+
+		err := startUpgrade(instance)
+		if (err != nil) != c.err {
+			t.Errorf("%s: expected error %v got %v", c.name, c.err, err)
+			continue
+		}
+		upgrade := instance.Status.Upgrade
+		if (upgrade == nil) != (c.expected == nil) {
+			t.Errorf("%s: expected upgrade %v got %v", c.name, c.expected, upgrade)
+			continue
+		}
+		if upgrade != nil && (upgrade.From != c.expected.From || upgrade.To != c.expected.To) {
+			t.Errorf("%s: expected upgrade from %s to %s got %s to %s", c.name, c.expected.From, c.expected.To, upgrade.From, upgrade.To)
+		}
+	}
+}
+
+func TestVersionMismatches(t *testing.T) {
+	cases := []struct {
+		name     string
+		server   string
+		kubelets []string
+		expected string
+	}{
+		{"rolled out", "v1.16.7", []string{"v1.16.7", "v1.16.7"}, ""},
+		{"nodes behind", "v1.16.7", []string{"v1.15.10", "v1.16.7"}, "1 of 2 nodes"},
+		{"api server behind", "v1.15.10", []string{"v1.15.10"}, "the API server (v1.15.10),1 of 1 nodes"},
+	}
+
+	for _, c := range cases {
+		mismatches := strings.Join(versionMismatches("1.16.7", c.server, c.kubelets), ",")
+		if mismatches != c.expected {
+			t.Errorf("%s: expected %q got %q", c.name, c.expected, mismatches)
+		}
+	}
+}
@@ -0,0 +1,10 @@
+package webhook
+
+import (
+	"github.com/infobloxopen/cluster-operator/pkg/webhook/cluster"
+)
+
+func init() {
+	// AddToManagerFuncs is a list of functions to create webhooks and add them to a manager.
+	AddToManagerFuncs = append(AddToManagerFuncs, cluster.Add)
+}
@@ -0,0 +1,76 @@
+package cluster
+
+import (
+	"context"
+
+	clusteroperatorv1alpha1 "github.com/infobloxopen/cluster-operator/pkg/apis/clusteroperator/v1alpha1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+var log = logf.Log.WithName("webhook_cluster")
+
+// Config holds the operator settings the Cluster webhooks check against
+type Config struct {
+	// DNSZone is the zone the kops cluster names are in
+	DNSZone string
+	// StateStore is the kops state store of the operator
+	StateStore string
+	// CredentialsNamespaces are the namespaces besides their own that
+	// Clusters may reference credentials Secrets in
+	CredentialsNamespaces []string
+}
+
+// withProvider returns the Config for the Clusters of provider, its DNS zone
+// and state store replace those of the operator
+func (c Config) withProvider(provider *clusteroperatorv1alpha1.ClusterProvider) Config {
+	if provider == nil {
+		return c
+	}
+	if provider.Spec.DNSZone != "" {
+		c.DNSZone = provider.Spec.DNSZone
+	}
+	if provider.Spec.StateStore != "" {
+		c.StateStore = provider.Spec.StateStore
+	}
+	return c
+}
+
+// providerReader reads the ClusterProviders of Clusters with the API reader
+// the webhook server injects, they are cluster scoped and not in the cache
+type providerReader struct {
+	reader client.Reader
+}
+
+// InjectAPIReader is called by the webhook server with the API reader of the
+// manager
+func (p *providerReader) InjectAPIReader(reader client.Reader) error {
+	p.reader = reader
+	return nil
+}
+
+// provider returns the ClusterProvider instance references, nil when it uses
+// the operator settings
+func (p *providerReader) provider(ctx context.Context, instance *clusteroperatorv1alpha1.Cluster) (*clusteroperatorv1alpha1.ClusterProvider, error) {
+	ref := instance.Spec.ProviderRef
+	if ref == nil {
+		return nil, nil
+	}
+	provider := &clusteroperatorv1alpha1.ClusterProvider{}
+	if err := p.reader.Get(ctx, types.NamespacedName{Name: ref.Name}, provider); err != nil {
+		return nil, err
+	}
+	return provider, nil
+}
+
+// Add registers the Cluster webhooks with the webhook server of the Manager
+func Add(mgr manager.Manager, cfg Config) error {
+	log.Info("Registering Cluster defaulting webhook", "path", DefaultPath)
+	mgr.GetWebhookServer().Register(DefaultPath, &webhook.Admission{Handler: &Defaulter{Config: cfg}})
+	log.Info("Registering Cluster validating webhook", "path", ValidatePath)
+	mgr.GetWebhookServer().Register(ValidatePath, &webhook.Admission{Handler: &Validator{Config: cfg}})
+	return nil
+}
@@ -0,0 +1,59 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	clusteroperatorv1alpha1 "github.com/infobloxopen/cluster-operator/pkg/apis/clusteroperator/v1alpha1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// DefaultPath is where the defaulting webhook for Clusters is served
+const DefaultPath = "/mutate-cluster-operator-infobloxopen-github-com-v1alpha1-cluster"
+
+// Defaulter fills in the kops cluster name, state store, instance group sizes
+// and zones on admission, so the stored Cluster shows what will be built
+type Defaulter struct {
+	Config
+	providerReader
+	decoder *admission.Decoder
+}
+
+// InjectDecoder is called by the webhook server with the decoder for the
+// manager scheme
+func (d *Defaulter) InjectDecoder(decoder *admission.Decoder) error {
+	d.decoder = decoder
+	return nil
+}
+
+// Handle returns the patch that defaults the Cluster in req
+func (d *Defaulter) Handle(ctx context.Context, req admission.Request) admission.Response {
+	instance := &clusteroperatorv1alpha1.Cluster{}
+	if err := d.decoder.Decode(req, instance); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	// A Cluster being deleted is not built again, the updates that remove
+	// its finalizer must get through when its ClusterProvider is gone
+	if !instance.DeletionTimestamp.IsZero() {
+		return admission.Allowed("")
+	}
+
+	provider, err := d.provider(ctx, instance)
+	if apierrors.IsNotFound(err) {
+		return admission.Denied(fmt.Sprintf("spec.providerRef: ClusterProvider %s not found", instance.Spec.ProviderRef.Name))
+	}
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	instance.DefaultWithProvider(provider, d.DNSZone, d.StateStore)
+
+	data, err := json.Marshal(instance)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	return admission.PatchResponseFromRaw(req.Object.Raw, data)
+}
@@ -0,0 +1,160 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	clusteroperatorv1alpha1 "github.com/infobloxopen/cluster-operator/pkg/apis/clusteroperator/v1alpha1"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func TestDefaulterHandle(t *testing.T) {
+	d := &Defaulter{Config: testConfig}
+	if err := d.InjectDecoder(newTestDecoder(t)); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.InjectAPIReader(providerStub{}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		spec    clusteroperatorv1alpha1.ClusterSpec
+		patches map[string]bool
+	}{
+		{
+			name: "defaults",
+			spec: clusteroperatorv1alpha1.ClusterSpec{Name: "test"},
+			patches: map[string]bool{
+				"/spec/kops_config/name":        true,
+				"/spec/kops_config/state_store": true,
+				"/spec/applyPolicy":             true,
+				"/spec/rollingUpdate":           true,
+				"/spec/driftPolicy":             true,
+			},
+		},
+		{
+			name: "already defaulted",
+			spec: clusteroperatorv1alpha1.ClusterSpec{
+				Name:          "test",
+				ApplyPolicy:   clusteroperatorv1alpha1.ApplyAutomatic,
+				RollingUpdate: &clusteroperatorv1alpha1.RollingUpdateSpec{Strategy: clusteroperatorv1alpha1.RollingUpdateAuto},
+				DriftPolicy:   clusteroperatorv1alpha1.DriftReport,
+				KopsConfig: clusteroperatorv1alpha1.KopsConfig{
+					Name:       "test.example.com",
+					StateStore: "s3://state.example.com",
+				},
+			},
+			patches: map[string]bool{},
+		},
+		{
+			name: "provider",
+			spec: clusteroperatorv1alpha1.ClusterSpec{
+				Name:        "test",
+				ProviderRef: &corev1.LocalObjectReference{Name: "team"},
+				Kops:        &clusteroperatorv1alpha1.KopsSpec{},
+			},
+			patches: map[string]bool{
+				"/spec/kops_config/name":        true,
+				"/spec/kops_config/state_store": true,
+				"/spec/kops/cluster/configBase": true,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		resp := d.Handle(context.TODO(), admission.Request{AdmissionRequest: admissionv1beta1.AdmissionRequest{
+			Operation: admissionv1beta1.Create,
+			Object:    rawCluster(t, test.spec),
+		}})
+		if !resp.Allowed {
+			t.Errorf("%s: expected allowed got %v", test.name, resp.Result)
+			continue
+		}
+		got := map[string]bool{}
+		for _, p := range resp.Patches {
+			got[p.Path] = true
+		}
+		for path := range test.patches {
+			if !got[path] {
+				t.Errorf("%s: expected patch of %s got %v", test.name, path, resp.Patches)
+			}
+		}
+		if len(test.patches) == 0 && len(resp.Patches) != 0 {
+			t.Errorf("%s: expected no patches got %v", test.name, resp.Patches)
+		}
+	}
+
+	resp := d.Handle(context.TODO(), admission.Request{AdmissionRequest: admissionv1beta1.AdmissionRequest{
+		Operation: admissionv1beta1.Create,
+		Object:    rawCluster(t, clusteroperatorv1alpha1.ClusterSpec{Name: "test", ProviderRef: &corev1.LocalObjectReference{Name: "other"}}),
+	}})
+	if resp.Allowed {
+		t.Error("Expected a missing ClusterProvider to be denied")
+	}
+	// a Cluster being deleted is left as it is, its ClusterProvider may be
+	// gone before it
+	resp = d.Handle(context.TODO(), admission.Request{AdmissionRequest: admissionv1beta1.AdmissionRequest{
+		Operation: admissionv1beta1.Update,
+		Object:    rawDeletedCluster(t, clusteroperatorv1alpha1.ClusterSpec{Name: "test", ProviderRef: &corev1.LocalObjectReference{Name: "other"}}),
+	}})
+	if !resp.Allowed || len(resp.Patches) != 0 {
+		t.Errorf("Expected a deleted Cluster to be allowed unchanged got %v, %v", resp.Result, resp.Patches)
+	}
+}
+
+func TestDefaultThenValidateNoDNSZone(t *testing.T) {
+	cfg := Config{StateStore: "s3://state.example.com"}
+	d := &Defaulter{Config: cfg}
+	v := &Validator{Config: cfg}
+	for _, h := range []interface {
+		InjectDecoder(*admission.Decoder) error
+		InjectAPIReader(client.Reader) error
+	}{d, v} {
+		if err := h.InjectDecoder(newTestDecoder(t)); err != nil {
+			t.Fatal(err)
+		}
+		if err := h.InjectAPIReader(providerStub{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	req := admission.Request{AdmissionRequest: admissionv1beta1.AdmissionRequest{
+		Operation: admissionv1beta1.Create,
+		Object:    rawCluster(t, clusteroperatorv1alpha1.ClusterSpec{Name: "test"}),
+	}}
+	resp := d.Handle(context.TODO(), req)
+	if !resp.Allowed {
+		t.Fatalf("expected defaulting to be allowed got %v", resp.Result)
+	}
+	for _, p := range resp.Patches {
+		if p.Path == "/spec/kops_config/name" {
+			t.Errorf("expected no kops cluster name without a DNS zone got %v", p.Value)
+		}
+	}
+	data, err := json.Marshal(resp.Patches)
+	if err != nil {
+		t.Fatal(err)
+	}
+	patch, err := jsonpatch.DecodePatch(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Object.Raw, err = patch.Apply(req.Object.Raw); err != nil {
+		t.Fatal(err)
+	}
+
+	resp = v.Handle(context.TODO(), req)
+	if resp.Allowed {
+		t.Fatal("expected a Cluster without a DNS zone to be denied")
+	}
+	if resp.Result == nil || !strings.Contains(resp.Result.Message, "the operator has no DNS zone") {
+		t.Errorf("expected the missing DNS zone to be reported got %v", resp.Result)
+	}
+}
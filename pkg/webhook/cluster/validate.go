@@ -0,0 +1,231 @@
+package cluster
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+
+	"github.com/infobloxopen/cluster-operator/kops"
+	clusteroperatorv1alpha1 "github.com/infobloxopen/cluster-operator/pkg/apis/clusteroperator/v1alpha1"
+	"gopkg.in/yaml.v2"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// ValidatePath is where the validating webhook for Clusters is served
+const ValidatePath = "/validate-cluster-operator-infobloxopen-github-com-v1alpha1-cluster"
+
+// Validator rejects Clusters the operator cannot build or that would change
+// the identity of an existing kops cluster
+type Validator struct {
+	Config
+	providerReader
+	decoder *admission.Decoder
+}
+
+// InjectDecoder is called by the webhook server with the decoder for the
+// manager scheme
+func (v *Validator) InjectDecoder(d *admission.Decoder) error {
+	v.decoder = d
+	return nil
+}
+
+// Handle validates Cluster creates and updates
+func (v *Validator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	instance := &clusteroperatorv1alpha1.Cluster{}
+	if err := v.decoder.Decode(req, instance); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	// A Cluster being deleted only has to keep the identity of its kops
+	// cluster, the updates that remove its finalizer must get through when
+	// its ClusterProvider is already gone
+	deleting := !instance.DeletionTimestamp.IsZero()
+
+	var errs field.ErrorList
+	var provider *clusteroperatorv1alpha1.ClusterProvider
+	if !deleting {
+		var err error
+		provider, err = v.provider(ctx, instance)
+		if apierrors.IsNotFound(err) {
+			errs = append(errs, field.NotFound(field.NewPath("spec", "providerRef"), instance.Spec.ProviderRef.Name))
+		} else if err != nil {
+			return admission.Errored(http.StatusInternalServerError, err)
+		}
+	}
+
+	switch req.Operation {
+	case admissionv1beta1.Create:
+		errs = append(errs, v.ValidateCreate(instance, provider)...)
+	case admissionv1beta1.Update:
+		old := &clusteroperatorv1alpha1.Cluster{}
+		if err := v.decoder.DecodeRaw(req.OldObject, old); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		if deleting {
+			errs = append(errs, validateImmutable(instance, old)...)
+		} else {
+			errs = append(errs, v.ValidateUpdate(instance, old, provider)...)
+		}
+	}
+
+	if len(errs) > 0 {
+		status := apierrors.NewInvalid(clusteroperatorv1alpha1.SchemeGroupVersion.WithKind("Cluster").GroupKind(), instance.Name, errs).ErrStatus
+		resp := admission.Denied(status.Message)
+		resp.Result = &status
+		return resp
+	}
+	return admission.Allowed("")
+}
+
+// ValidateCreate checks a new Cluster, provider is its ClusterProvider or nil
+func (v *Validator) ValidateCreate(instance *clusteroperatorv1alpha1.Cluster, provider *clusteroperatorv1alpha1.ClusterProvider) field.ErrorList {
+	var errs field.ErrorList
+	specPath := field.NewPath("spec")
+	cfg := v.Config.withProvider(provider)
+
+	if instance.Spec.Name == "" {
+		errs = append(errs, field.Required(specPath.Child("name"), "the kops cluster is named after it"))
+	}
+	if cfg.StateStore == "" && instance.Spec.KopsConfig.StateStore == "" {
+		errs = append(errs, field.Required(specPath.Child("providerRef"), "the operator has no kops state store"))
+	}
+	if store := instance.Spec.KopsConfig.StateStore; store != "" {
+		if _, err := kops.NormalizeStateStore(store); err != nil {
+			errs = append(errs, field.Invalid(specPath.Child("kops_config", "state_store"), store, err.Error()))
+		}
+	}
+	if cfg.DNSZone == "" && instance.Spec.KopsConfig.Name == "" {
+		errs = append(errs, field.Required(specPath.Child("providerRef"), "the operator has no DNS zone"))
+	}
+
+	if instance.Spec.Kops != nil {
+		if instance.Spec.Config != "" {
+			errs = append(errs, field.Forbidden(specPath.Child("config"), "may not be set together with spec.kops"))
+		}
+		errs = append(errs, cfg.validateConfigBase(specPath.Child("kops", "cluster", "configBase"), instance.Spec.Kops.Cluster.ConfigBase, instance.Spec.Name)...)
+	} else if instance.Spec.Config != "" {
+		errs = append(errs, cfg.validateConfig(specPath.Child("config"), instance.Spec.Config, instance.Spec.Name)...)
+	}
+
+	for i, w := range instance.Spec.MaintenanceWindows {
+		if err := w.Validate(); err != nil {
+			errs = append(errs, field.Invalid(specPath.Child("maintenanceWindows").Index(i), w, err.Error()))
+		}
+	}
+
+	if version := instance.Spec.KubernetesVersion; version != "" {
+		if _, err := clusteroperatorv1alpha1.ParseKubernetesVersion(version); err != nil {
+			errs = append(errs, field.Invalid(specPath.Child("kubernetesVersion"), version, err.Error()))
+		}
+	}
+
+	if err := instance.ValidateCredentialsRef(v.CredentialsNamespaces); err != nil {
+		errs = append(errs, field.Invalid(specPath.Child("credentialsRef"), instance.Spec.CredentialsRef, err.Error()))
+	}
+	if provider != nil {
+		if err := provider.ValidateAccess(instance.Namespace, v.CredentialsNamespaces); err != nil {
+			errs = append(errs, field.Forbidden(specPath.Child("providerRef"), err.Error()))
+		}
+	}
+
+	return errs
+}
+
+// ValidateUpdate checks a Cluster update, the fields the kops cluster is
+// identified by cannot change
+func (v *Validator) ValidateUpdate(instance, old *clusteroperatorv1alpha1.Cluster, provider *clusteroperatorv1alpha1.ClusterProvider) field.ErrorList {
+	errs := v.ValidateCreate(instance, provider)
+	errs = append(errs, validateImmutable(instance, old)...)
+
+	// the version can only move one minor version at a time from the one
+	// the cluster was verified to run
+	if running, version := old.Status.KubernetesVersion, instance.Spec.KubernetesVersion; running != "" && version != "" && version != old.Spec.KubernetesVersion {
+		if err := clusteroperatorv1alpha1.ValidateUpgrade(running, version); err != nil {
+			errs = append(errs, field.Invalid(field.NewPath("spec", "kubernetesVersion"), version, err.Error()))
+		}
+	}
+
+	return errs
+}
+
+// validateImmutable checks that an update keeps the fields the kops cluster is
+// identified by
+func validateImmutable(instance, old *clusteroperatorv1alpha1.Cluster) field.ErrorList {
+	var errs field.ErrorList
+	specPath := field.NewPath("spec")
+
+	if instance.Spec.Name != old.Spec.Name {
+		errs = append(errs, field.Invalid(specPath.Child("name"), instance.Spec.Name, "field is immutable"))
+	}
+	if !reflect.DeepEqual(instance.Spec.ProviderRef, old.Spec.ProviderRef) {
+		errs = append(errs, field.Invalid(specPath.Child("providerRef"), instance.Spec.ProviderRef, "field is immutable"))
+	}
+	kopsConfigPath := specPath.Child("kops_config")
+	if old.Spec.KopsConfig.Name != "" && instance.Spec.KopsConfig.Name != old.Spec.KopsConfig.Name {
+		errs = append(errs, field.Invalid(kopsConfigPath.Child("name"), instance.Spec.KopsConfig.Name, "field is immutable"))
+	}
+	if old.Spec.KopsConfig.StateStore != "" && instance.Spec.KopsConfig.StateStore != old.Spec.KopsConfig.StateStore {
+		errs = append(errs, field.Invalid(kopsConfigPath.Child("state_store"), instance.Spec.KopsConfig.StateStore, "field is immutable"))
+	}
+
+	return errs
+}
+
+// clusterName is the kops cluster name the operator computes for name
+func (c Config) clusterName(name string) string {
+	return clusteroperatorv1alpha1.ClusterName(name, c.DNSZone)
+}
+
+// validateConfig checks the kops manifest in spec.config
+func (c Config) validateConfig(path *field.Path, config, name string) field.ErrorList {
+	var errs field.ErrorList
+
+	decoder := yaml.NewDecoder(bytes.NewBufferString(config))
+	for i := 0; ; i++ {
+		var doc struct {
+			Kind     string `yaml:"kind"`
+			Metadata struct {
+				Name string `yaml:"name"`
+			} `yaml:"metadata"`
+			Spec struct {
+				ConfigBase string `yaml:"configBase"`
+			} `yaml:"spec"`
+		}
+		err := decoder.Decode(&doc)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return append(errs, field.Invalid(path, fmt.Sprintf("document %d", i), "not a valid YAML kops manifest: "+err.Error()))
+		}
+		if doc.Kind != "Cluster" {
+			continue
+		}
+
+		if expected := c.clusterName(name); doc.Metadata.Name != expected {
+			errs = append(errs, field.Invalid(path.Key("metadata.name"), doc.Metadata.Name, fmt.Sprintf("must be %q, spec.name in zone %q", expected, c.DNSZone)))
+		}
+		errs = append(errs, c.validateConfigBase(path.Key("spec.configBase"), doc.Spec.ConfigBase, name)...)
+	}
+
+	return errs
+}
+
+// validateConfigBase checks that configBase, when set, is the cluster path in
+// the operator state store
+func (c Config) validateConfigBase(path *field.Path, configBase, name string) field.ErrorList {
+	if configBase == "" {
+		return nil
+	}
+	expected := clusteroperatorv1alpha1.ConfigBase(c.StateStore, c.clusterName(name))
+	if configBase != expected {
+		return field.ErrorList{field.Invalid(path, configBase, fmt.Sprintf("must be %q, the cluster path in state store %q", expected, c.StateStore))}
+	}
+	return nil
+}
@@ -0,0 +1,465 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/infobloxopen/cluster-operator/pkg/apis"
+	clusteroperatorv1alpha1 "github.com/infobloxopen/cluster-operator/pkg/apis/clusteroperator/v1alpha1"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+var testConfig = Config{
+	DNSZone:               "example.com",
+	StateStore:            "s3://state.example.com",
+	CredentialsNamespaces: []string{"accounts"},
+}
+
+// testProvider is the ClusterProvider providerStub serves
+var testProvider = clusteroperatorv1alpha1.ClusterProvider{
+	ObjectMeta: metav1.ObjectMeta{Name: "team"},
+	Spec: clusteroperatorv1alpha1.ClusterProviderSpec{
+		DNSZone:    "team.example.com",
+		StateStore: "s3://team",
+	},
+}
+
+// providerStub is a client.Reader that only knows testProvider
+type providerStub struct{}
+
+func (providerStub) Get(ctx context.Context, key client.ObjectKey, obj runtime.Object) error {
+	provider, ok := obj.(*clusteroperatorv1alpha1.ClusterProvider)
+	if !ok || key.Name != testProvider.Name {
+		return apierrors.NewNotFound(clusteroperatorv1alpha1.SchemeGroupVersion.WithResource("clusterproviders").GroupResource(), key.Name)
+	}
+	testProvider.DeepCopyInto(provider)
+	return nil
+}
+
+func (providerStub) List(ctx context.Context, list runtime.Object, opts ...client.ListOption) error {
+	return nil
+}
+
+func newCluster(spec clusteroperatorv1alpha1.ClusterSpec) *clusteroperatorv1alpha1.Cluster {
+	return &clusteroperatorv1alpha1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+		Spec:       spec,
+	}
+}
+
+func TestValidateCreate(t *testing.T) {
+	tests := []struct {
+		name   string
+		spec   clusteroperatorv1alpha1.ClusterSpec
+		fields []string
+	}{
+		{
+			name: "valid config",
+			spec: clusteroperatorv1alpha1.ClusterSpec{
+				Name: "test",
+				Config: `apiVersion: kops.k8s.io/v1alpha2
+kind: Cluster
+metadata:
+  name: test.example.com
+spec:
+  configBase: s3://state.example.com/test.example.com
+---
+apiVersion: kops.k8s.io/v1alpha2
+kind: InstanceGroup
+metadata:
+  name: nodes
+`,
+			},
+		},
+		{
+			name: "valid kops",
+			spec: clusteroperatorv1alpha1.ClusterSpec{
+				Name: "test",
+				Kops: &clusteroperatorv1alpha1.KopsSpec{},
+			},
+		},
+		{
+			name:   "missing name",
+			spec:   clusteroperatorv1alpha1.ClusterSpec{},
+			fields: []string{"spec.name"},
+		},
+		{
+			name: "unparseable config",
+			spec: clusteroperatorv1alpha1.ClusterSpec{
+				Name:   "test",
+				Config: "kind: Cluster\nmetadata: [name\n",
+			},
+			fields: []string{"spec.config"},
+		},
+		{
+			name: "config name mismatch",
+			spec: clusteroperatorv1alpha1.ClusterSpec{
+				Name:   "test",
+				Config: "kind: Cluster\nmetadata:\n  name: other.example.com\n",
+			},
+			fields: []string{"spec.config[metadata.name]"},
+		},
+		{
+			name: "config base in another state store",
+			spec: clusteroperatorv1alpha1.ClusterSpec{
+				Name:   "test",
+				Config: "kind: Cluster\nmetadata:\n  name: test.example.com\nspec:\n  configBase: s3://other/test.example.com\n",
+			},
+			fields: []string{"spec.config[spec.configBase]"},
+		},
+		{
+			name: "kops config base in another state store",
+			spec: clusteroperatorv1alpha1.ClusterSpec{
+				Name: "test",
+				Kops: &clusteroperatorv1alpha1.KopsSpec{
+					Cluster: clusteroperatorv1alpha1.KopsClusterSpec{ConfigBase: "s3://other/test.example.com"},
+				},
+			},
+			fields: []string{"spec.kops.cluster.configBase"},
+		},
+		{
+			name: "config and kops",
+			spec: clusteroperatorv1alpha1.ClusterSpec{
+				Name:   "test",
+				Config: "kind: Cluster\n",
+				Kops:   &clusteroperatorv1alpha1.KopsSpec{},
+			},
+			fields: []string{"spec.config"},
+		},
+		{
+			name: "maintenance windows",
+			spec: clusteroperatorv1alpha1.ClusterSpec{
+				Name: "test",
+				Kops: &clusteroperatorv1alpha1.KopsSpec{},
+				MaintenanceWindows: []clusteroperatorv1alpha1.MaintenanceWindow{
+					{Days: []string{"Saturday"}, Start: "22:00", Duration: metav1.Duration{Duration: 4 * time.Hour}},
+					{Start: "10pm", Duration: metav1.Duration{Duration: 4 * time.Hour}},
+				},
+			},
+			fields: []string{"spec.maintenanceWindows[1]"},
+		},
+		{
+			name: "kubernetes version",
+			spec: clusteroperatorv1alpha1.ClusterSpec{
+				Name:              "test",
+				Kops:              &clusteroperatorv1alpha1.KopsSpec{},
+				KubernetesVersion: "1.16",
+			},
+			fields: []string{"spec.kubernetesVersion"},
+		},
+		{
+			name: "credentials in the cluster namespace",
+			spec: clusteroperatorv1alpha1.ClusterSpec{
+				Name:           "test",
+				Kops:           &clusteroperatorv1alpha1.KopsSpec{},
+				CredentialsRef: &clusteroperatorv1alpha1.CredentialsReference{Name: "aws"},
+			},
+		},
+		{
+			name: "credentials in an allowed namespace",
+			spec: clusteroperatorv1alpha1.ClusterSpec{
+				Name:           "test",
+				Kops:           &clusteroperatorv1alpha1.KopsSpec{},
+				CredentialsRef: &clusteroperatorv1alpha1.CredentialsReference{Name: "aws", Namespace: "accounts"},
+			},
+		},
+		{
+			name: "credentials in another namespace",
+			spec: clusteroperatorv1alpha1.ClusterSpec{
+				Name:           "test",
+				Kops:           &clusteroperatorv1alpha1.KopsSpec{},
+				CredentialsRef: &clusteroperatorv1alpha1.CredentialsReference{Name: "aws", Namespace: "kube-system"},
+			},
+			fields: []string{"spec.credentialsRef"},
+		},
+		{
+			name: "file state store",
+			spec: clusteroperatorv1alpha1.ClusterSpec{
+				Name:       "test",
+				Kops:       &clusteroperatorv1alpha1.KopsSpec{},
+				KopsConfig: clusteroperatorv1alpha1.KopsConfig{StateStore: "file:///var/lib/kops"},
+			},
+		},
+		{
+			name: "relative file state store",
+			spec: clusteroperatorv1alpha1.ClusterSpec{
+				Name:       "test",
+				Kops:       &clusteroperatorv1alpha1.KopsSpec{},
+				KopsConfig: clusteroperatorv1alpha1.KopsConfig{StateStore: "file://kops"},
+			},
+			fields: []string{"spec.kops_config.state_store"},
+		},
+	}
+
+	v := &Validator{Config: testConfig}
+	for _, test := range tests {
+		errs := v.ValidateCreate(newCluster(test.spec), nil)
+		var fields []string
+		for _, err := range errs {
+			fields = append(fields, err.Field)
+		}
+		if strings.Join(fields, ",") != strings.Join(test.fields, ",") {
+			t.Errorf("%s: expected errors for %v got %v", test.name, test.fields, errs)
+		}
+	}
+}
+
+func TestValidateUpdate(t *testing.T) {
+	old := clusteroperatorv1alpha1.ClusterSpec{
+		Name: "test",
+		Kops: &clusteroperatorv1alpha1.KopsSpec{},
+		KopsConfig: clusteroperatorv1alpha1.KopsConfig{
+			Name:       "test.example.com",
+			StateStore: "s3://state.example.com",
+		},
+		KubernetesVersion: "1.15.10",
+	}
+
+	tests := []struct {
+		name   string
+		update func(*clusteroperatorv1alpha1.ClusterSpec)
+		fields []string
+	}{
+		{
+			name:   "unchanged",
+			update: func(*clusteroperatorv1alpha1.ClusterSpec) {},
+		},
+		{
+			name: "instance groups changed",
+			update: func(s *clusteroperatorv1alpha1.ClusterSpec) {
+				s.Kops = &clusteroperatorv1alpha1.KopsSpec{
+					InstanceGroups: []clusteroperatorv1alpha1.KopsInstanceGroupSpec{{Name: "nodes", Role: "Node"}},
+				}
+			},
+		},
+		{
+			name:   "name changed",
+			update: func(s *clusteroperatorv1alpha1.ClusterSpec) { s.Name = "other" },
+			fields: []string{"spec.name"},
+		},
+		{
+			name:   "kops config name changed",
+			update: func(s *clusteroperatorv1alpha1.ClusterSpec) { s.KopsConfig.Name = "other.example.com" },
+			fields: []string{"spec.kops_config.name"},
+		},
+		{
+			name:   "kops config state store changed",
+			update: func(s *clusteroperatorv1alpha1.ClusterSpec) { s.KopsConfig.StateStore = "s3://other" },
+			fields: []string{"spec.kops_config.state_store"},
+		},
+		{
+			name:   "kubernetes patch upgrade",
+			update: func(s *clusteroperatorv1alpha1.ClusterSpec) { s.KubernetesVersion = "1.15.12" },
+		},
+		{
+			name:   "kubernetes minor upgrade",
+			update: func(s *clusteroperatorv1alpha1.ClusterSpec) { s.KubernetesVersion = "v1.16.7" },
+		},
+		{
+			name:   "kubernetes minor version skipped",
+			update: func(s *clusteroperatorv1alpha1.ClusterSpec) { s.KubernetesVersion = "1.17.3" },
+			fields: []string{"spec.kubernetesVersion"},
+		},
+		{
+			name: "provider set",
+			update: func(s *clusteroperatorv1alpha1.ClusterSpec) {
+				s.ProviderRef = &corev1.LocalObjectReference{Name: "team"}
+			},
+			fields: []string{"spec.providerRef"},
+		},
+		{
+			name:   "kubernetes downgrade",
+			update: func(s *clusteroperatorv1alpha1.ClusterSpec) { s.KubernetesVersion = "1.14.10" },
+			fields: []string{"spec.kubernetesVersion"},
+		},
+	}
+
+	v := &Validator{Config: testConfig}
+	for _, test := range tests {
+		spec := *old.DeepCopy()
+		test.update(&spec)
+		oldCluster := newCluster(old)
+		oldCluster.Status.KubernetesVersion = old.KubernetesVersion
+		errs := v.ValidateUpdate(newCluster(spec), oldCluster, nil)
+		var fields []string
+		for _, err := range errs {
+			fields = append(fields, err.Field)
+		}
+		if strings.Join(fields, ",") != strings.Join(test.fields, ",") {
+			t.Errorf("%s: expected errors for %v got %v", test.name, test.fields, errs)
+		}
+	}
+}
+
+func TestValidateCreateWithProvider(t *testing.T) {
+	spec := clusteroperatorv1alpha1.ClusterSpec{
+		Name:        "test",
+		ProviderRef: &corev1.LocalObjectReference{Name: "team"},
+		Config:      "kind: Cluster\nmetadata:\n  name: test.team.example.com\nspec:\n  configBase: s3://team/test.team.example.com\n",
+	}
+	v := &Validator{Config: testConfig}
+	if errs := v.ValidateCreate(newCluster(spec), &testProvider); len(errs) > 0 {
+		t.Error("Expected the provider zone and state store to be used got", errs)
+	}
+	if errs := v.ValidateCreate(newCluster(spec), nil); len(errs) != 2 {
+		t.Error("Expected the operator zone and state store to be rejected got", errs)
+	}
+
+	unconfigured := &Validator{}
+	if errs := unconfigured.ValidateCreate(newCluster(clusteroperatorv1alpha1.ClusterSpec{Name: "test"}), nil); len(errs) != 2 {
+		t.Error("Expected a missing state store and DNS zone got", errs)
+	}
+}
+
+func TestValidateCreateProviderAccess(t *testing.T) {
+	spec := clusteroperatorv1alpha1.ClusterSpec{
+		Name:        "test",
+		ProviderRef: &corev1.LocalObjectReference{Name: "team"},
+	}
+	provider := testProvider.DeepCopy()
+	provider.Spec.CredentialsRef = &clusteroperatorv1alpha1.CredentialsReference{Name: "aws", Namespace: "team-a"}
+	v := &Validator{Config: testConfig}
+
+	errs := v.ValidateCreate(newCluster(spec), provider)
+	if len(errs) != 1 || errs[0].Field != "spec.providerRef" || errs[0].Type != field.ErrorTypeForbidden {
+		t.Errorf("Expected the credentials of the provider to be forbidden got %v", errs)
+	}
+	provider.Spec.AllowedNamespaces = []string{"default"}
+	if errs := v.ValidateCreate(newCluster(spec), provider); len(errs) > 0 {
+		t.Error("Expected the allowed namespace to use the provider got", errs)
+	}
+}
+
+func newTestDecoder(t *testing.T) *admission.Decoder {
+	scheme := runtime.NewScheme()
+	if err := apis.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	decoder, err := admission.NewDecoder(scheme)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return decoder
+}
+
+func rawCluster(t *testing.T, spec clusteroperatorv1alpha1.ClusterSpec) runtime.RawExtension {
+	c := newCluster(spec)
+	c.APIVersion = clusteroperatorv1alpha1.SchemeGroupVersion.String()
+	c.Kind = "Cluster"
+	data, err := json.Marshal(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return runtime.RawExtension{Raw: data}
+}
+
+// rawDeletedCluster is rawCluster with the deletion timestamp the API server
+// sets on a Cluster whose finalizer is not removed yet
+func rawDeletedCluster(t *testing.T, spec clusteroperatorv1alpha1.ClusterSpec) runtime.RawExtension {
+	c := newCluster(spec)
+	c.APIVersion = clusteroperatorv1alpha1.SchemeGroupVersion.String()
+	c.Kind = "Cluster"
+	now := metav1.Now()
+	c.DeletionTimestamp = &now
+	c.Finalizers = []string{"cluster.finalizer.cluster-operator.infobloxopen.github.com"}
+	data, err := json.Marshal(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return runtime.RawExtension{Raw: data}
+}
+
+func TestHandle(t *testing.T) {
+	v := &Validator{Config: testConfig}
+	if err := v.InjectDecoder(newTestDecoder(t)); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.InjectAPIReader(providerStub{}); err != nil {
+		t.Fatal(err)
+	}
+	raw := func(spec clusteroperatorv1alpha1.ClusterSpec) runtime.RawExtension {
+		return rawCluster(t, spec)
+	}
+
+	tests := []struct {
+		name    string
+		req     admissionv1beta1.AdmissionRequest
+		allowed bool
+	}{
+		{
+			name: "create allowed",
+			req: admissionv1beta1.AdmissionRequest{
+				Operation: admissionv1beta1.Create,
+				Object:    raw(clusteroperatorv1alpha1.ClusterSpec{Name: "test"}),
+			},
+			allowed: true,
+		},
+		{
+			name: "create denied",
+			req: admissionv1beta1.AdmissionRequest{
+				Operation: admissionv1beta1.Create,
+				Object:    raw(clusteroperatorv1alpha1.ClusterSpec{}),
+			},
+		},
+		{
+			name: "provider allowed",
+			req: admissionv1beta1.AdmissionRequest{
+				Operation: admissionv1beta1.Create,
+				Object:    raw(clusteroperatorv1alpha1.ClusterSpec{Name: "test", ProviderRef: &corev1.LocalObjectReference{Name: "team"}}),
+			},
+			allowed: true,
+		},
+		{
+			name: "missing provider denied",
+			req: admissionv1beta1.AdmissionRequest{
+				Operation: admissionv1beta1.Create,
+				Object:    raw(clusteroperatorv1alpha1.ClusterSpec{Name: "test", ProviderRef: &corev1.LocalObjectReference{Name: "other"}}),
+			},
+		},
+		{
+			name: "rename denied",
+			req: admissionv1beta1.AdmissionRequest{
+				Operation: admissionv1beta1.Update,
+				Object:    raw(clusteroperatorv1alpha1.ClusterSpec{Name: "other"}),
+				OldObject: raw(clusteroperatorv1alpha1.ClusterSpec{Name: "test"}),
+			},
+		},
+		{
+			name: "deleting with missing provider allowed",
+			req: admissionv1beta1.AdmissionRequest{
+				Operation: admissionv1beta1.Update,
+				Object:    rawDeletedCluster(t, clusteroperatorv1alpha1.ClusterSpec{Name: "test", ProviderRef: &corev1.LocalObjectReference{Name: "other"}}),
+				OldObject: rawDeletedCluster(t, clusteroperatorv1alpha1.ClusterSpec{Name: "test", ProviderRef: &corev1.LocalObjectReference{Name: "other"}}),
+			},
+			allowed: true,
+		},
+		{
+			name: "deleting rename denied",
+			req: admissionv1beta1.AdmissionRequest{
+				Operation: admissionv1beta1.Update,
+				Object:    rawDeletedCluster(t, clusteroperatorv1alpha1.ClusterSpec{Name: "other"}),
+				OldObject: rawDeletedCluster(t, clusteroperatorv1alpha1.ClusterSpec{Name: "test"}),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		resp := v.Handle(context.TODO(), admission.Request{AdmissionRequest: test.req})
+		if resp.Allowed != test.allowed {
+			t.Errorf("%s: expected allowed %v got %v: %v", test.name, test.allowed, resp.Allowed, resp.Result)
+		}
+		if !test.allowed && (resp.Result == nil || resp.Result.Details == nil || len(resp.Result.Details.Causes) == 0) {
+			t.Errorf("%s: expected field causes in the response", test.name)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+// Package conversion serves the CRD conversion webhook. It converts between
+// the versions of a kind through the version marked as conversion.Hub, the
+// other versions implement conversion.Convertible.
+package conversion
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+// Path is where the conversion webhook is served
+const Path = "/convert"
+
+var log = logf.Log.WithName("webhook_conversion")
+
+// Review is the apiextensions.k8s.io/v1beta1 ConversionReview. The
+// apiextensions API is not a dependency of the operator, only the fields the
+// webhook uses are modelled.
+type Review struct {
+	metav1.TypeMeta `json:",inline"`
+	Request         *Request  `json:"request,omitempty"`
+	Response        *Response `json:"response,omitempty"`
+}
+
+// Request holds the objects to convert and the version to convert them to
+type Request struct {
+	UID               types.UID              `json:"uid"`
+	DesiredAPIVersion string                 `json:"desiredAPIVersion"`
+	Objects           []runtime.RawExtension `json:"objects"`
+}
+
+// Response holds the converted objects in the order of the request
+type Response struct {
+	UID              types.UID              `json:"uid"`
+	ConvertedObjects []runtime.RawExtension `json:"convertedObjects"`
+	Result           metav1.Status          `json:"result"`
+}
+
+// Add registers the conversion webhook with the webhook server of the Manager
+func Add(mgr manager.Manager) error {
+	log.Info("Registering conversion webhook", "path", Path)
+	mgr.GetWebhookServer().Register(Path, &Webhook{})
+	return nil
+}
+
+// Webhook converts the objects of a ConversionReview with the conversion
+// functions of the types in the scheme
+type Webhook struct {
+	scheme *runtime.Scheme
+	codecs serializer.CodecFactory
+}
+
+// InjectScheme is called by the webhook server with the manager scheme
+func (wh *Webhook) InjectScheme(s *runtime.Scheme) error {
+	wh.scheme = s
+	wh.codecs = serializer.NewCodecFactory(s)
+	return nil
+}
+
+// ServeHTTP answers a ConversionReview
+func (wh *Webhook) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	review := &Review{}
+	if err := json.NewDecoder(r.Body).Decode(review); err != nil || review.Request == nil {
+		log.Error(err, "cannot read conversion review")
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	resp, err := wh.Convert(review.Request)
+	if err != nil {
+		log.Error(err, "conversion failed", "uid", review.Request.UID)
+		resp = &Response{Result: metav1.Status{Status: metav1.StatusFailure, Message: err.Error()}}
+	}
+	resp.UID = review.Request.UID
+	review.Request = nil
+	review.Response = resp
+
+	if err := json.NewEncoder(w).Encode(review); err != nil {
+		log.Error(err, "cannot write conversion review")
+	}
+}
+
+// Convert converts the objects of req to req.DesiredAPIVersion
+func (wh *Webhook) Convert(req *Request) (*Response, error) {
+	resp := &Response{
+		UID:    req.UID,
+		Result: metav1.Status{Status: metav1.StatusSuccess},
+	}
+	for _, raw := range req.Objects {
+		src, gvk, err := wh.codecs.UniversalDeserializer().Decode(raw.Raw, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		dst, err := wh.newObject(schema.FromAPIVersionAndKind(req.DesiredAPIVersion, gvk.Kind))
+		if err != nil {
+			return nil, err
+		}
+		if err := wh.convert(src, dst); err != nil {
+			return nil, err
+		}
+		resp.ConvertedObjects = append(resp.ConvertedObjects, runtime.RawExtension{Object: dst})
+	}
+	return resp, nil
+}
+
+// newObject returns an empty object of gvk with its type set
+func (wh *Webhook) newObject(gvk schema.GroupVersionKind) (runtime.Object, error) {
+	obj, err := wh.scheme.New(gvk)
+	if err != nil {
+		return nil, err
+	}
+	t, err := meta.TypeAccessor(obj)
+	if err != nil {
+		return nil, err
+	}
+	t.SetAPIVersion(gvk.GroupVersion().String())
+	t.SetKind(gvk.Kind)
+	return obj, nil
+}
+
+// convert converts src into dst directly when one of them is the hub and
+// through the hub otherwise
+func (wh *Webhook) convert(src, dst runtime.Object) error {
+	srcGVK := src.GetObjectKind().GroupVersionKind()
+	dstGVK := dst.GetObjectKind().GroupVersionKind()
+	if srcGVK.GroupKind() != dstGVK.GroupKind() {
+		return fmt.Errorf("cannot convert %s to %s", srcGVK, dstGVK)
+	}
+	if srcGVK == dstGVK {
+		return fmt.Errorf("%s is already in the desired version", srcGVK)
+	}
+
+	srcHub, srcIsHub := src.(conversion.Hub)
+	dstHub, dstIsHub := dst.(conversion.Hub)
+	srcSpoke, srcIsSpoke := src.(conversion.Convertible)
+	dstSpoke, dstIsSpoke := dst.(conversion.Convertible)
+	switch {
+	case srcIsHub && dstIsSpoke:
+		return dstSpoke.ConvertFrom(srcHub)
+	case dstIsHub && srcIsSpoke:
+		return srcSpoke.ConvertTo(dstHub)
+	case srcIsSpoke && dstIsSpoke:
+		hub, err := wh.hub(srcGVK.GroupKind())
+		if err != nil {
+			return err
+		}
+		if err := srcSpoke.ConvertTo(hub); err != nil {
+			return err
+		}
+		return dstSpoke.ConvertFrom(hub)
+	default:
+		return fmt.Errorf("%s is not convertible to %s", srcGVK, dstGVK)
+	}
+}
+
+// hub returns an empty object of the hub version of gk
+func (wh *Webhook) hub(gk schema.GroupKind) (conversion.Hub, error) {
+	for gvk := range wh.scheme.AllKnownTypes() {
+		if gvk.GroupKind() != gk {
+			continue
+		}
+		obj, err := wh.scheme.New(gvk)
+		if err != nil {
+			return nil, err
+		}
+		if hub, ok := obj.(conversion.Hub); ok {
+			return hub, nil
+		}
+	}
+	return nil, fmt.Errorf("no hub version for %s", gk)
+}
@@ -0,0 +1,119 @@
+package conversion
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/infobloxopen/cluster-operator/pkg/apis"
+	"github.com/infobloxopen/cluster-operator/pkg/apis/clusteroperator/v1alpha1"
+	"github.com/infobloxopen/cluster-operator/pkg/apis/clusteroperator/v1alpha2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func newTestWebhook(t *testing.T) *Webhook {
+	scheme := runtime.NewScheme()
+	if err := apis.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	wh := &Webhook{}
+	if err := wh.InjectScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	return wh
+}
+
+func review(t *testing.T, wh *Webhook, desired string, obj runtime.Object) *Review {
+	raw, err := json.Marshal(obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := json.Marshal(&Review{
+		TypeMeta: metav1.TypeMeta{APIVersion: "apiextensions.k8s.io/v1beta1", Kind: "ConversionReview"},
+		Request: &Request{
+			UID:               "42",
+			DesiredAPIVersion: desired,
+			Objects:           []runtime.RawExtension{{Raw: raw}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	wh.ServeHTTP(w, httptest.NewRequest(http.MethodPost, Path, bytes.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 got %d", w.Code)
+	}
+	resp := &Review{}
+	if err := json.Unmarshal(w.Body.Bytes(), resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Response == nil || resp.Response.UID != "42" {
+		t.Fatalf("Expected response for request 42 got %+v", resp.Response)
+	}
+	return resp
+}
+
+func TestConvert(t *testing.T) {
+	wh := newTestWebhook(t)
+
+	in := &v1alpha1.Cluster{
+		TypeMeta:   metav1.TypeMeta{APIVersion: v1alpha1.SchemeGroupVersion.String(), Kind: "Cluster"},
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+		Spec: v1alpha1.ClusterSpec{
+			Name: "test",
+			KopsConfig: v1alpha1.KopsConfig{
+				Name:        "test.example.com",
+				MasterCount: 1,
+				MasterEc2:   "t2.micro",
+				StateStore:  "s3://state",
+			},
+		},
+		Status: v1alpha1.ClusterStatus{Phase: v1alpha1.ClusterReady},
+	}
+
+	resp := review(t, wh, v1alpha2.SchemeGroupVersion.String(), in)
+	if resp.Response.Result.Status != metav1.StatusSuccess || len(resp.Response.ConvertedObjects) != 1 {
+		t.Fatalf("Expected one converted object got %+v", resp.Response)
+	}
+	hub := &v1alpha2.Cluster{}
+	if err := json.Unmarshal(resp.Response.ConvertedObjects[0].Raw, hub); err != nil {
+		t.Fatal(err)
+	}
+	if hub.APIVersion != v1alpha2.SchemeGroupVersion.String() || hub.Kind != "Cluster" {
+		t.Errorf("Expected v1alpha2 Cluster got %s %s", hub.APIVersion, hub.Kind)
+	}
+	if hub.Spec.KopsConfig.StateStore != "s3://state" || hub.Spec.KopsConfig.Masters.Count != 1 || hub.Spec.KopsConfig.Masters.MachineType != "t2.micro" {
+		t.Errorf("Unexpected kopsConfig %+v", hub.Spec.KopsConfig)
+	}
+	if hub.Status.Phase != v1alpha2.ClusterReady {
+		t.Errorf("Expected phase Ready got %s", hub.Status.Phase)
+	}
+
+	// and back
+	resp = review(t, wh, v1alpha1.SchemeGroupVersion.String(), hub)
+	out := &v1alpha1.Cluster{}
+	if err := json.Unmarshal(resp.Response.ConvertedObjects[0].Raw, out); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(out.Spec, in.Spec) {
+		t.Errorf("Expected spec %+v got %+v", in.Spec, out.Spec)
+	}
+}
+
+func TestConvertSameVersion(t *testing.T) {
+	wh := newTestWebhook(t)
+	in := &v1alpha1.Cluster{
+		TypeMeta:   metav1.TypeMeta{APIVersion: v1alpha1.SchemeGroupVersion.String(), Kind: "Cluster"},
+		ObjectMeta: metav1.ObjectMeta{Name: "test"},
+	}
+	resp := review(t, wh, v1alpha1.SchemeGroupVersion.String(), in)
+	if resp.Response.Result.Status != metav1.StatusFailure {
+		t.Errorf("Expected failure got %+v", resp.Response.Result)
+	}
+}
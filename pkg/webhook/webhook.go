@@ -0,0 +1,19 @@
+package webhook
+
+import (
+	"github.com/infobloxopen/cluster-operator/pkg/webhook/cluster"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+// AddToManagerFuncs is a list of functions to add all Webhooks to the Manager
+var AddToManagerFuncs []func(manager.Manager, cluster.Config) error
+
+// AddToManager adds all Webhooks to the Manager
+func AddToManager(m manager.Manager, cfg cluster.Config) error {
+	for _, f := range AddToManagerFuncs {
+		if err := f(m, cfg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
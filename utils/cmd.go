@@ -1,12 +1,9 @@
 package utils
 
 import (
-	"bytes"
 	"context"
 	"fmt"
-	"github.com/spf13/viper"
 	"io"
-	"os"
 	"os/exec"
 	"sync"
 
@@ -14,137 +11,6 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
-func CopyBufferContentsToFile(srcBuff []byte, destFile string) (err error) {
-	out, err := os.Create(destFile)
-	if err != nil {
-		return
-	}
-	defer func() {
-		cerr := out.Close()
-		if err == nil {
-			err = cerr
-		}
-	}()
-	if _, err = out.Write(srcBuff); err != nil {
-		return
-	}
-	err = out.Sync()
-	return
-}
-
-// Copies source buffer to the tmp directory
-// Insures tmp directory exists and prepends path
-// Temporary, used for writing Kops Manifest to file but exploring using STDIN instead
-func CopyBufferContentsToTempFile(srcBuff []byte, destFile string) (err error) {
-	var mode os.FileMode = 509
-	err = os.MkdirAll("." + viper.GetString("kops.kube.dir"), mode)
-	if err != nil {
-		return err
-	}
-
-	out, err := os.Create("." + viper.GetString("kops.kube.dir") + "/" + destFile)
-	if err != nil {
-		return
-	}
-	defer func() {
-		cerr := out.Close()
-		if err == nil {
-			err = cerr
-		}
-	}()
-	if _, err = out.Write(srcBuff); err != nil {
-		return
-	}
-	err = out.Sync()
-	return
-}
-
-func RunCmd(cmdString string) (*bytes.Buffer, error) {
-	var out bytes.Buffer
-
-	cmd := exec.Command("echo", cmdString)
-	cmd.Stdout = &out
-	err := cmd.Run()
-	if err != nil {
-		return nil, err
-	}
-
-	var mode os.FileMode = 509
-	err = os.MkdirAll("./tmp", mode)
-	if err != nil {
-		return nil, err
-	}
-
-	err = CopyBufferContentsToFile(out.Bytes(), "./tmp/cmd.sh")
-	if err != nil {
-		return nil, err
-	}
-
-	out.Reset()
-	cmd = exec.Command("/bin/sh", "./tmp/cmd.sh")
-	cmd.Stdout = &out
-	var errout bytes.Buffer
-	cmd.Stderr = &errout
-	err = cmd.Run()
-	if err != nil {
-		CopyBufferContentsToFile(errout.Bytes(), "./tmp/error.txt")
-		return &errout, err
-	}
-
-	return &out, nil
-}
-
-func RunStreamingCmd(cmdString string) error {
-	var out bytes.Buffer
-
-	cmd := exec.Command("echo", cmdString)
-	cmd.Stdout = &out
-	err := cmd.Run()
-	if err != nil {
-		return err
-	}
-
-	var mode os.FileMode = 509
-	err = os.MkdirAll("./tmp", mode)
-	if err != nil {
-		return err
-	}
-
-	err = CopyBufferContentsToFile(out.Bytes(), "./tmp/cmd.sh")
-	if err != nil {
-		return err
-	}
-
-	out.Reset()
-	command := New(context.TODO(), nil, "/bin/sh", "./tmp/cmd.sh")
-
-	if err := command.Start(); err != nil {
-		return err
-	}
-	if err := command.Wait(); err != nil {
-		return err
-	}
-
-	return nil
-}
-
-func RunDockerCmd(dockerArgs []string) (*bytes.Buffer, error) {
-	var out bytes.Buffer
-
-	//cmd := exec.Command(cmdString)
-	cmd := exec.Command("/usr/local/bin/docker", dockerArgs...)
-	cmd.Stdout = &out
-	var errout bytes.Buffer
-	cmd.Stderr = &errout
-	err := cmd.Run()
-	if err != nil {
-		CopyBufferContentsToFile(errout.Bytes(), "./tmp/error.txt")
-		return &errout, err
-	}
-
-	return &out, nil
-}
-
 type Cmd struct {
 	*exec.Cmd
 	entry     *logrus.Entry
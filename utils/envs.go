@@ -1,7 +1,6 @@
 package utils
 
 import (
-	"github.com/spf13/viper"
 	"os"
 	"strings"
 )
@@ -40,10 +39,3 @@ func CheckEnvs(envs [][]string, reqEnvs []string) []string {
 	}
 	return missingEnvs
 }
-
-func GetDockerEnvFlags() string {
-	envFlags := " -e AWS_ACCESS_KEY_ID=" + viper.GetString("aws.access.key.id") +
-		"-e AWS_SECRET_ACCESS_KEY=" + viper.GetString("aws.secret.access.key") +
-		"-e KOPS_STATE_STORE=" + viper.GetString("kops.state.store")
-	return envFlags
-}
@@ -52,17 +52,3 @@ func TestCheckEnvs(t *testing.T) {
 	}
 	os.Unsetenv(key)
 }
-
-func TestGetDockerEnvFlags(t *testing.T) {
-	envs := [][]string{
-		{"key1", "value1"},
-		{"key2", "value2"},
-	}
-	expectedFlags := " -e key1=value1 -e key2=value2"
-
-	envFlags := GetDockerEnvFlags(envs)
-
-	if envFlags != expectedFlags {
-		t.Error("Expected ", expectedFlags, " got ", envFlags)
-	}
-}
@@ -0,0 +1,80 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrorReason classifies why a command failed
+type ErrorReason string
+
+const (
+	// ReasonNotFound means the command positively reported the object as missing
+	ReasonNotFound ErrorReason = "NotFound"
+	// ReasonUnauthorized means the credentials were missing, invalid or lacked permissions
+	ReasonUnauthorized ErrorReason = "Unauthorized"
+	// ReasonThrottled means the cloud provider rate limited the request
+	ReasonThrottled ErrorReason = "Throttled"
+	// ReasonTransient means the failure is expected to go away on retry,
+	// e.g. network errors or a command that ran out of time or was cancelled
+	ReasonTransient ErrorReason = "Transient"
+	// ReasonUnknown is used for everything that could not be classified
+	ReasonUnknown ErrorReason = "Unknown"
+)
+
+// CommandError is returned by an Executor when a command could not be
+// started, exited non-zero or was cancelled.
+type CommandError struct {
+	// Argv is the command line that failed
+	Argv []string
+	// ExitCode of the process, -1 when it did not exit on its own
+	ExitCode int
+	// Stderr captured from the process
+	Stderr string
+	// Reason is the classified cause of the failure
+	Reason ErrorReason
+	// Err is the underlying error from os/exec or the context
+	Err error
+}
+
+func (e *CommandError) Error() string {
+	msg := fmt.Sprintf("cmd: %q exit code: %d reason: %s err: %s", e.Argv, e.ExitCode, e.Reason, e.Err)
+	if line := lastLine(e.Stderr); line != "" {
+		msg += " stderr: " + line
+	}
+	return msg
+}
+
+func (e *CommandError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorReasonFor returns the Reason of a CommandError in the chain of err,
+// ReasonUnknown otherwise.
+func ErrorReasonFor(err error) ErrorReason {
+	var cmdErr *CommandError
+	if errors.As(err, &cmdErr) {
+		return cmdErr.Reason
+	}
+	return ReasonUnknown
+}
+
+// IsNotFound is true only for a CommandError positively classified as NotFound
+func IsNotFound(err error) bool {
+	return err != nil && ErrorReasonFor(err) == ReasonNotFound
+}
+
+// IsRetryable is true for failures that are expected to go away on their own
+func IsRetryable(err error) bool {
+	switch ErrorReasonFor(err) {
+	case ReasonThrottled, ReasonTransient:
+		return true
+	}
+	return false
+}
+
+func lastLine(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
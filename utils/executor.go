@@ -0,0 +1,150 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Command describes a single process invocation. Args are handed to the
+// binary as an argv slice, no shell is involved.
+type Command struct {
+	// Path is the binary to run
+	Path string
+	// Args are the arguments passed to the binary, not including Path
+	Args []string
+	// Env is appended to the operator environment, later entries win
+	Env []string
+	// Dir is the working directory, empty means the operator working directory
+	Dir string
+	// Timeout bounds the run time of the command, zero means only the
+	// deadline of the context applies
+	Timeout time.Duration
+	// Stdin is connected to the process standard input when set
+	Stdin io.Reader
+}
+
+// Argv returns the command line as a slice, used for logging and errors.
+func (c Command) Argv() []string {
+	return append([]string{c.Path}, c.Args...)
+}
+
+// Result is the outcome of a finished command.
+type Result struct {
+	ExitCode int
+	Stdout   []byte
+	Stderr   []byte
+	Duration time.Duration
+}
+
+// Executor runs commands. Implementations must honor cancellation of the
+// context and make sure no child process outlives it.
+type Executor interface {
+	Run(ctx context.Context, c Command) (*Result, error)
+}
+
+// NewExecutor returns an Executor that runs commands on the host, streaming
+// stdout and stderr to entry while capturing them in the Result.
+func NewExecutor(entry *logrus.Entry) Executor {
+	if entry == nil {
+		entry = defaultEntry
+	}
+	return &execExecutor{entry: entry}
+}
+
+type execExecutor struct {
+	entry *logrus.Entry
+}
+
+func (e *execExecutor) Run(ctx context.Context, c Command) (*Result, error) {
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, commandError(c, nil, err)
+	}
+
+	entry := e.entry.WithField("cmd", c.Path)
+	stdoutLog := entry.WriterLevel(logrus.InfoLevel)
+	defer stdoutLog.Close()
+	stderrLog := entry.WriterLevel(logrus.ErrorLevel)
+	defer stderrLog.Close()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(c.Path, c.Args...)
+	cmd.Env = append(os.Environ(), c.Env...)
+	cmd.Dir = c.Dir
+	cmd.Stdin = c.Stdin
+	cmd.Stdout = io.MultiWriter(&stdout, stdoutLog)
+	cmd.Stderr = io.MultiWriter(&stderr, stderrLog)
+	// Run the command in its own process group so that cancellation also
+	// reaches anything it spawned (ssh, aws cli, ...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+
+	start := time.Now()
+	if err := cmd.Start(); err != nil {
+		return nil, commandError(c, nil, err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		killProcessGroup(cmd.Process.Pid)
+		<-done
+		err = ctx.Err()
+	}
+
+	res := &Result{
+		ExitCode: -1,
+		Stdout:   stdout.Bytes(),
+		Stderr:   stderr.Bytes(),
+		Duration: time.Since(start),
+	}
+	if cmd.ProcessState != nil {
+		res.ExitCode = cmd.ProcessState.ExitCode()
+	}
+	if err != nil {
+		return res, commandError(c, res, err)
+	}
+	return res, nil
+}
+
+// killProcessGroup sends SIGKILL to every process in the group led by pid
+func killProcessGroup(pid int) {
+	if err := syscall.Kill(-pid, syscall.SIGKILL); err != nil {
+		defaultEntry.WithError(err).Warnf("cannot kill process group %d", pid)
+	}
+}
+
+func commandError(c Command, res *Result, err error) error {
+	cmdErr := &CommandError{
+		Argv:     c.Argv(),
+		ExitCode: -1,
+		Reason:   ReasonUnknown,
+		Err:      err,
+	}
+	if res != nil {
+		cmdErr.ExitCode = res.ExitCode
+		cmdErr.Stderr = string(res.Stderr)
+	}
+	// a command cut short by its deadline or by the reconcile being cancelled,
+	// e.g. at manager shutdown, is run again
+	if err == context.DeadlineExceeded || err == context.Canceled {
+		cmdErr.Reason = ReasonTransient
+	}
+	return cmdErr
+}
@@ -0,0 +1,98 @@
+package utils
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExecutorRun(t *testing.T) {
+	e := NewExecutor(nil)
+	res, err := e.Run(context.TODO(), Command{
+		Path: outErrCmdString[0],
+		Args: outErrCmdString[1:],
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.ExitCode != 0 {
+		t.Errorf("got: %d wanted: 0", res.ExitCode)
+	}
+	if e := "out"; strings.TrimSpace(string(res.Stdout)) != e {
+		t.Errorf("got: %s wanted: %s", res.Stdout, e)
+	}
+	if e := "error"; strings.TrimSpace(string(res.Stderr)) != e {
+		t.Errorf("got: %s wanted: %s", res.Stderr, e)
+	}
+}
+
+func TestExecutorEnvAndDir(t *testing.T) {
+	e := NewExecutor(nil)
+	res, err := e.Run(context.TODO(), Command{
+		Path: "sh",
+		Args: []string{"-c", "echo $EXECUTOR_TEST_VALUE && pwd"},
+		Env:  []string{"EXECUTOR_TEST_VALUE=2020"},
+		Dir:  "/",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(res.Stdout)), "\n")
+	if len(lines) != 2 || lines[0] != "2020" || lines[1] != "/" {
+		t.Errorf("got: %q wanted: [2020 /]", lines)
+	}
+}
+
+func TestExecutorExitCode(t *testing.T) {
+	e := NewExecutor(nil)
+	res, err := e.Run(context.TODO(), Command{
+		Path: "sh",
+		Args: []string{"-c", "exit 3"},
+	})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if res == nil || res.ExitCode != 3 {
+		t.Errorf("got: %v wanted exit code 3", res)
+	}
+}
+
+func TestExecutorTimeout(t *testing.T) {
+	e := NewExecutor(nil)
+	start := time.Now()
+	// The background sleep keeps stdout open, it must be killed with the group
+	_, err := e.Run(context.TODO(), Command{
+		Path:    "sh",
+		Args:    []string{"-c", "sleep 30 & sleep 30"},
+		Timeout: 100 * time.Millisecond,
+	})
+	if err == nil {
+		t.Fatal("expected timeout error")
+	}
+	if !strings.Contains(err.Error(), context.DeadlineExceeded.Error()) {
+		t.Errorf("got: %s wanted: %s", err, context.DeadlineExceeded)
+	}
+	if d := time.Since(start); d > 5*time.Second {
+		t.Errorf("command was not killed, ran for %s", d)
+	}
+}
+
+func TestExecutorCancel(t *testing.T) {
+	e := NewExecutor(nil)
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+
+	_, err := e.Run(ctx, Command{
+		Path: "sh",
+		Args: []string{"-c", "sleep 30"},
+	})
+	if err == nil {
+		t.Fatal("expected cancellation error")
+	}
+	if r := ErrorReasonFor(err); r != ReasonTransient {
+		t.Errorf("got: %s wanted: %s", r, ReasonTransient)
+	}
+}
@@ -0,0 +1,65 @@
+package utils
+
+import (
+	"context"
+	"sync"
+)
+
+// KeyedSemaphore allows at most size holders per key. Keys are created on
+// first use and dropped again once nobody holds or waits for them.
+type KeyedSemaphore struct {
+	size int
+
+	mu   sync.Mutex
+	sems map[string]*keyedSem
+}
+
+type keyedSem struct {
+	ch   chan struct{}
+	refs int
+}
+
+// NewKeyedSemaphore returns a KeyedSemaphore with size slots per key, a size
+// below one is treated as one, i.e. a keyed mutex.
+func NewKeyedSemaphore(size int) *KeyedSemaphore {
+	if size < 1 {
+		size = 1
+	}
+	return &KeyedSemaphore{size: size, sems: map[string]*keyedSem{}}
+}
+
+// Acquire blocks until a slot for key is free or ctx is done. The returned
+// func releases the slot and must be called exactly once.
+func (k *KeyedSemaphore) Acquire(ctx context.Context, key string) (func(), error) {
+	k.mu.Lock()
+	s, ok := k.sems[key]
+	if !ok {
+		s = &keyedSem{ch: make(chan struct{}, k.size)}
+		k.sems[key] = s
+	}
+	s.refs++
+	k.mu.Unlock()
+
+	select {
+	case s.ch <- struct{}{}:
+		var once sync.Once
+		return func() {
+			once.Do(func() {
+				<-s.ch
+				k.unref(key, s)
+			})
+		}, nil
+	case <-ctx.Done():
+		k.unref(key, s)
+		return nil, ctx.Err()
+	}
+}
+
+func (k *KeyedSemaphore) unref(key string, s *keyedSem) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	s.refs--
+	if s.refs == 0 {
+		delete(k.sems, key)
+	}
+}
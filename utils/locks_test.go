@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestKeyedSemaphore(t *testing.T) {
+	k := NewKeyedSemaphore(2)
+
+	r1, err := k.Acquire(context.TODO(), "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r2, err := k.Acquire(context.TODO(), "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// other keys are not affected
+	r3, err := k.Acquire(context.TODO(), "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r3()
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := k.Acquire(ctx, "a"); err == nil {
+		t.Fatal("expected third holder of a to time out")
+	}
+
+	r1()
+	r1() // releasing twice is a no-op
+	r4, err := k.Acquire(context.TODO(), "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r2()
+	r4()
+
+	if len(k.sems) != 0 {
+		t.Errorf("got: %d keys wanted: 0", len(k.sems))
+	}
+}
@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+const workspaceKubeConfig = "kubeconfig"
+
+// Workspace is a private directory for the files and environment of the
+// commands run on behalf of a single cluster. Nothing in a workspace is shared
+// with other clusters or written to the operator process environment, so
+// several clusters can be reconciled at the same time.
+type Workspace struct {
+	// Dir is the working directory of commands run in the workspace
+	Dir string
+	env []string
+}
+
+// NewWorkspace creates an empty workspace directory named id under root. Any
+// leftover from a previous run with the same id, e.g. after a crash, is
+// removed first.
+func NewWorkspace(root, id string) (*Workspace, error) {
+	dir := filepath.Join(root, id)
+	if err := os.RemoveAll(dir); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	w := &Workspace{Dir: dir}
+	w.SetEnv("KUBECONFIG", w.KubeConfigPath())
+	return w, nil
+}
+
+// Path returns the absolute path of name inside the workspace
+func (w *Workspace) Path(name string) string {
+	return filepath.Join(w.Dir, name)
+}
+
+// KubeConfigPath is where commands in the workspace read and write the
+// kubeconfig, it is exported to them as KUBECONFIG
+func (w *Workspace) KubeConfigPath() string {
+	return w.Path(workspaceKubeConfig)
+}
+
+// WriteFile writes data to name inside the workspace and returns its path
+func (w *Workspace) WriteFile(name string, data []byte) (string, error) {
+	path := w.Path(name)
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// SetEnv adds key=value to the environment of commands run in the workspace
+func (w *Workspace) SetEnv(key, value string) {
+	w.env = append(w.env, key+"="+value)
+}
+
+// Env returns the environment of commands run in the workspace
+func (w *Workspace) Env() []string {
+	return append([]string{}, w.env...)
+}
+
+// Close removes the workspace directory and everything in it
+func (w *Workspace) Close() error {
+	return os.RemoveAll(w.Dir)
+}
@@ -0,0 +1,89 @@
+package utils
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWorkspace(t *testing.T) {
+	root, err := ioutil.TempDir("", "workspace")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	// leftovers from a previous run are removed
+	stale := filepath.Join(root, "uid-1", "stale")
+	if err := os.MkdirAll(stale, 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := NewWorkspace(root, "uid-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Error("expected stale workspace content to be removed")
+	}
+
+	path, err := w.WriteFile("cluster.yaml", []byte("spec: {}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e := filepath.Join(root, "uid-1", "cluster.yaml"); path != e {
+		t.Errorf("got: %s wanted: %s", path, e)
+	}
+
+	w.SetEnv("AWS_PROFILE", "test")
+	res, err := NewExecutor(nil).Run(context.TODO(), Command{
+		Path: "sh",
+		Args: []string{"-c", "echo $KUBECONFIG $AWS_PROFILE && ls"},
+		Env:  w.Env(),
+		Dir:  w.Dir,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e := w.KubeConfigPath() + " test\ncluster.yaml"; strings.TrimSpace(string(res.Stdout)) != e {
+		t.Errorf("got: %q wanted: %q", res.Stdout, e)
+	}
+	if os.Getenv("KUBECONFIG") == w.KubeConfigPath() {
+		t.Error("workspace must not change the process environment")
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(w.Dir); !os.IsNotExist(err) {
+		t.Error("expected workspace to be removed")
+	}
+}
+
+func TestWorkspacesAreIsolated(t *testing.T) {
+	root, err := ioutil.TempDir("", "workspace")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	a, err := NewWorkspace(root, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := NewWorkspace(root, "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.KubeConfigPath() == b.KubeConfigPath() {
+		t.Error("expected workspaces to have their own kubeconfig")
+	}
+
+	a.Close()
+	if _, err := os.Stat(b.Dir); err != nil {
+		t.Error("closing a workspace removed another one", err)
+	}
+}